@@ -6,18 +6,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// Money columns are numeric(15,3): three decimals cover every ISO 4217 minor unit (KWD, BHD, ...)
+// and twelve integer digits leave room for very large shared costs. Values are still rounded to
+// the group currency's own minor unit by the services. Migrate widens older numeric(10,2) columns
+// in place, so existing amounts keep their values.
+
 // Group represents a group of people sharing expenses
 type Group struct {
-	ID           uint          `gorm:"primaryKey" json:"id"`
-	URLSlug      string        `gorm:"uniqueIndex;not null" json:"url_slug"`
-	Name         string        `gorm:"not null" json:"name"`
-	SettleUpDate *time.Time    `json:"settle_up_date"`
-	State        string        `gorm:"default:'active'" json:"state"`
-	Currency     string        `gorm:"size:3;not null" json:"currency"`
-	Participants []Participant `gorm:"foreignKey:GroupID" json:"participants"`
-	Expenses     []Expense     `gorm:"foreignKey:GroupID" json:"expenses"`
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
+	ID              uint          `gorm:"primaryKey" json:"id"`
+	URLSlug         string        `gorm:"uniqueIndex;not null" json:"url_slug"`
+	Name            string        `gorm:"not null" json:"name"`
+	SettleUpDate    *time.Time    `json:"settle_up_date"`
+	State           string        `gorm:"default:'active'" json:"state"`
+	Currency        string        `gorm:"size:3;not null" json:"currency"`
+	Description     string        `gorm:"type:text;not null;default:''" json:"description"`
+	AmountDisplay   string        `gorm:"size:5;not null;default:'cents'" json:"amount_display"`
+	SimplifyDebts   bool          `gorm:"not null;default:true" json:"simplify_debts"`
+	RotateRemainder bool          `gorm:"not null;default:false" json:"rotate_remainder"`
+	RemainderCursor int           `gorm:"not null;default:0" json:"-"` // Position in the participant list (by ID) whose turn is next
+	BankerID        *uint         `json:"banker_id"`                   // When set, everyone settles with this participant instead of each other
+	EditTokenHash   string        `json:"-"`                           // SHA-256 of the edit token; empty for groups created before edit tokens
+	Participants    []Participant `gorm:"foreignKey:GroupID" json:"participants"`
+	Expenses        []Expense     `gorm:"foreignKey:GroupID" json:"expenses"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
 }
 
 // Participant represents a member of a group
@@ -34,11 +46,16 @@ type Participant struct {
 type Expense struct {
 	ID        uint        `gorm:"primaryKey" json:"id"`
 	Name      string      `gorm:"not null" json:"name"`
-	Cost      float64     `gorm:"type:decimal(10,2);not null" json:"cost"`
+	Cost      float64     `gorm:"type:numeric(15,3);not null" json:"cost"`
+	Subtotal  float64     `gorm:"type:numeric(15,3);not null;default:0" json:"subtotal"` // Optional breakdown: Cost = Subtotal + Tax + Tip
+	Tax       float64     `gorm:"type:numeric(15,3);not null;default:0" json:"tax"`
+	Tip       float64     `gorm:"type:numeric(15,3);not null;default:0" json:"tip"`
 	Emoji     string      `json:"emoji"`
 	PayerID   uint        `gorm:"not null" json:"payer_id"`
 	Payer     Participant `gorm:"foreignKey:PayerID" json:"payer"`
-	SplitType string      `gorm:"not null" json:"split_type"` // "equal", "amount", "shares"
+	SplitType string      `gorm:"not null" json:"split_type"`             // "equal", "amount", "shares", "itemized", "adjustment", "mixed"
+	IsShared  *bool       `gorm:"not null;default:true" json:"is_shared"` // false for personal expenses that only the payer carries
+	Locked    bool        `gorm:"not null;default:false" json:"locked"`   // Verified expenses are locked against edits and deletion
 	GroupID   uint        `gorm:"not null" json:"group_id"`
 	Group     Group       `gorm:"foreignKey:GroupID" json:"group"`
 	Splits    []Split     `gorm:"foreignKey:ExpenseID" json:"splits"`
@@ -48,16 +65,19 @@ type Expense struct {
 
 // Split represents how an expense is split among participants
 type Split struct {
-	ID            uint        `gorm:"primaryKey" json:"id"`
-	GroupID       uint        `gorm:"not null" json:"group_id"`
-	Group         Group       `gorm:"foreignKey:GroupID" json:"group"`
-	ExpenseID     uint        `gorm:"not null" json:"expense_id"`
-	Expense       Expense     `gorm:"foreignKey:ExpenseID" json:"expense"`
-	ParticipantID uint        `gorm:"not null" json:"participant_id"`
-	Participant   Participant `gorm:"foreignKey:ParticipantID" json:"participant"`
-	SplitAmount   float64     `gorm:"type:decimal(10,2);not null" json:"split_amount"`
-	CreatedAt     time.Time   `json:"created_at"`
-	UpdatedAt     time.Time   `json:"updated_at"`
+	ID              uint        `gorm:"primaryKey" json:"id"`
+	GroupID         uint        `gorm:"not null" json:"group_id"`
+	Group           Group       `gorm:"foreignKey:GroupID" json:"group"`
+	ExpenseID       uint        `gorm:"not null" json:"expense_id"`
+	Expense         Expense     `gorm:"foreignKey:ExpenseID" json:"expense"`
+	ParticipantID   uint        `gorm:"not null" json:"participant_id"`
+	Participant     Participant `gorm:"foreignKey:ParticipantID" json:"participant"`
+	SplitAmount     float64     `gorm:"type:numeric(15,3);not null" json:"split_amount"`
+	Adjustment      float64     `gorm:"type:numeric(15,3);not null;default:0" json:"adjustment"` // Extra (or, if negative, reduced) amount for "adjustment" splits
+	Weight          float64     `gorm:"type:numeric(15,3);not null;default:0" json:"weight"`     // Units (e.g. nights stayed) for "weighted" splits
+	SharesRemainder bool        `gorm:"not null;default:false" json:"shares_remainder"`          // "mixed" splits: shares what the exact amounts leave instead of owing SplitAmount
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
 }
 
 // Debt represents simplified debts between participants
@@ -66,7 +86,7 @@ type Debt struct {
 	GroupID    uint      `gorm:"not null" json:"group_id"`
 	LenderID   uint      `gorm:"not null" json:"lender_id"`
 	DebtorID   uint      `gorm:"not null" json:"debtor_id"`
-	DebtAmount float64   `gorm:"type:decimal(10,2);not null" json:"debt_amount"`
+	DebtAmount float64   `gorm:"type:numeric(15,3);not null" json:"debt_amount"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
@@ -77,11 +97,35 @@ type Payment struct {
 	GroupID   uint      `gorm:"not null" json:"group_id"`
 	PayerID   uint      `gorm:"not null" json:"payer_id"`
 	PayeeID   uint      `gorm:"not null" json:"payee_id"`
-	Amount    float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Amount    float64   `gorm:"type:numeric(15,3);not null" json:"amount"`
+	Note      string    `gorm:"type:text;not null;default:''" json:"note"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Activity is one entry in a group's activity log
+type Activity struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	GroupID     uint      `gorm:"not null;index" json:"group_id"`
+	Action      string    `gorm:"not null" json:"action"` // "expense_created", "expense_updated", "expense_deleted", "payment_created", "payment_deleted", "ledger_reset", "group_merged"
+	EntityID    uint      `json:"entity_id"`              // ID of the expense or payment the action touched, 0 if none
+	Description string    `json:"description"`
+	Undone      bool      `gorm:"not null;default:false" json:"undone"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserGroupPreference is how one device identity wants a group shown in its group list, synced across devices
+type UserGroupPreference struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	IdentityHash string    `gorm:"not null;uniqueIndex:idx_identity_group" json:"-"` // SHA-256 of the client's identity token
+	GroupSlug    string    `gorm:"not null;uniqueIndex:idx_identity_group" json:"group_slug"`
+	Pinned       bool      `gorm:"not null;default:false" json:"pinned"`
+	SortOrder    int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 // Migrate runs database migrations
 func Migrate(db *gorm.DB) error {
 	return db.AutoMigrate(
@@ -91,5 +135,7 @@ func Migrate(db *gorm.DB) error {
 		&Split{},
 		&Debt{},
 		&Payment{},
+		&Activity{},
+		&UserGroupPreference{},
 	)
 }
@@ -0,0 +1,121 @@
+// Package logger provides a small leveled logger on top of the standard log package.
+// The level is usually set once at startup from the LOG_LEVEL environment variable.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the minimum severity a Logger writes
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+// String returns the level name as printed in log lines
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LEVEL(%d)", int32(l))
+}
+
+// ParseLevel converts a level name ("debug", "info", "warn", "error"; case-insensitive) to a Level.
+// An empty string means info.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level: %q", s)
+}
+
+// Logger writes messages at or above its level and drops the rest
+type Logger struct {
+	level atomic.Int32
+	out   *log.Logger
+}
+
+// New creates a Logger writing to w with the standard log date/time prefix
+func New(w io.Writer, level Level) *Logger {
+	l := &Logger{out: log.New(w, "", log.LstdFlags)}
+	l.SetLevel(level)
+	return l
+}
+
+// SetLevel changes the minimum level; safe to call while logging
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// Level returns the current minimum level
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
+}
+
+// Enabled reports whether messages at the given level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+func (l *Logger) logf(level Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.out.Printf(level.String()+" "+format, args...)
+}
+
+// Debugf logs per-request and per-step detail
+func (l *Logger) Debugf(format string, args ...interface{}) { l.logf(LevelDebug, format, args...) }
+
+// Infof logs lifecycle events such as startup and configuration
+func (l *Logger) Infof(format string, args ...interface{}) { l.logf(LevelInfo, format, args...) }
+
+// Warnf logs recoverable problems such as malformed client requests
+func (l *Logger) Warnf(format string, args ...interface{}) { l.logf(LevelWarn, format, args...) }
+
+// Errorf logs failed operations
+func (l *Logger) Errorf(format string, args ...interface{}) { l.logf(LevelError, format, args...) }
+
+// std is the process-wide logger used by the package-level functions
+var std = New(os.Stderr, LevelInfo)
+
+// Default returns the process-wide logger
+func Default() *Logger { return std }
+
+// SetLevel changes the level of the process-wide logger
+func SetLevel(level Level) { std.SetLevel(level) }
+
+// Debugf logs to the process-wide logger at debug level
+func Debugf(format string, args ...interface{}) { std.logf(LevelDebug, format, args...) }
+
+// Infof logs to the process-wide logger at info level
+func Infof(format string, args ...interface{}) { std.logf(LevelInfo, format, args...) }
+
+// Warnf logs to the process-wide logger at warn level
+func Warnf(format string, args ...interface{}) { std.logf(LevelWarn, format, args...) }
+
+// Errorf logs to the process-wide logger at error level
+func Errorf(format string, args ...interface{}) { std.logf(LevelError, format, args...) }
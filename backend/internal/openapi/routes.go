@@ -0,0 +1,178 @@
+package openapi
+
+import (
+	"net/http"
+
+	"freesplit/internal/services"
+)
+
+// Routes lists every endpoint registered in rest_server.go.
+// Keep this in sync when adding or changing handlers.
+var Routes = []Route{
+	// Group Management
+	{Method: "POST", Path: "/api/group", Summary: "Create a new group with participants",
+		Request: services.CreateGroupRequest{}, Response: services.CreateGroupResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}", Summary: "Get group information and participants by URL slug",
+		Response: services.GetGroupResponse{}},
+	{Method: "HEAD", Path: "/api/group/{url_slug}", Summary: "Check that a URL slug still resolves to a group (200 or 404, no body)"},
+	{Method: "POST", Path: "/api/group/{url_slug}/rotate-token", Summary: "Replace the group's edit token (send the current one in X-Edit-Token)",
+		Response: services.RotateEditTokenResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/meta", Summary: "Get group fields only, without participants or expenses",
+		Response: services.GetGroupMetaResponse{}},
+	{Method: "GET", Path: "/api/group/by-id/{group_id}", Summary: "Get group information and participants by numeric ID",
+		Response: services.GetGroupResponse{}},
+	{Method: "PUT", Path: "/api/group/{url_slug}", Summary: "Update group name and currency",
+		Request: services.UpdateGroupRequest{}, Response: services.UpdateGroupResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/statistics", Summary: "Get spending totals and percentages per participant",
+		Response: services.GetGroupStatisticsResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/by-payer", Summary: "Get expense count and total paid per participant",
+		Response: services.GetExpensesByPayerResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/spending-timeseries", Summary: "Get total spending per day, week or month (bucket, default month)",
+		Response: services.GetSpendingTimeSeriesResponse{}, Query: []string{"bucket"}},
+	{Method: "GET", Path: "/api/group/{url_slug}/diagnostics", Summary: "Check the group's data for inconsistencies (read-only)",
+		Response: services.GetGroupDiagnosticsResponse{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/reset", Summary: "Delete all expenses, splits, debts and payments, keeping participants (send the edit token in X-Edit-Token)",
+		Response: services.ResetGroupResponse{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/merge", Summary: "Move another group's expenses, splits and payments into this group and archive it (send this group's edit token in X-Edit-Token)",
+		Request: services.MergeGroupsRequest{}, Response: services.MergeGroupsResponse{}},
+
+	// Participant Management
+	{Method: "GET", Path: "/api/group/{url_slug}/participants", Summary: "List participants with their net balances (sort=balance orders by balance, highest first)",
+		Response: services.ListParticipantsResponse{}, Query: []string{"sort"}},
+	{Method: "POST", Path: "/api/group/{url_slug}/participants", Summary: "Add a new participant to the group",
+		Request: services.AddParticipantRequest{}, Response: services.AddParticipantResponse{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/participants/bulk", Summary: "Add several participants in one transaction",
+		Request: struct {
+			Names []string `json:"names"`
+		}{}, Response: services.AddParticipantsResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/payments", Summary: "List payments a participant sent or received",
+		Response: services.GetParticipantPaymentsResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/statement", Summary: "Get a participant's paid expenses, splits, payments and net balance in one call",
+		Response: services.GetParticipantStatementResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/owes", Summary: "List the debts a participant still has to pay",
+		Response: services.GetParticipantOwedDebtsResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/settle-plan", Summary: "List the payments a participant makes to be fully settled",
+		Response: services.GetParticipantSettlePlanResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/fair-share", Summary: "Compare what a participant paid with their fair share so far",
+		Response: services.GetParticipantFairShareResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/summary", Summary: "Total what a participant consumed and paid for between from and to",
+		Response: services.GetParticipantSpendingSummaryResponse{}, Query: []string{"from", "to"}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/paid-expenses", Summary: "List expenses a participant paid for, with their total",
+		Response: services.GetParticipantPaidExpensesResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/balance", Summary: "Get a participant's net balance and the debts behind it",
+		Response: services.GetParticipantBalanceResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/participants/{participant_id}/verify", Summary: "Check that a stored participant ID still exists in the group and get its current name",
+		Response: services.VerifyParticipantResponse{}},
+	{Method: "PUT", Path: "/api/participants/{participant_id}", Summary: "Update participant name",
+		Request: services.UpdateParticipantRequest{}, Response: services.UpdateParticipantResponse{}},
+	{Method: "DELETE", Path: "/api/participants/{participant_id}", Summary: "Delete a participant from the group",
+		Response: map[string]string{}},
+
+	// Expense Management
+	{Method: "GET", Path: "/api/group/{group_id}/expenses", Summary: "Get all expenses for a group (include=splits embeds each expense's splits)",
+		Response: []*services.Expense{}, Query: []string{"include"}},
+	{Method: "POST", Path: "/api/group/{group_id}/expenses", Summary: "Create a new expense",
+		Request: services.CreateExpenseRequest{}, Response: services.CreateExpenseResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/splits", Summary: "Get all splits for a group with participant and payer names",
+		Response: []*services.SplitWithNames{}},
+	{Method: "GET", Path: "/api/expense/{expense_id}", Summary: "Get expense details with splits, payer and participant names",
+		Response: services.GetExpenseWithSplitsResponse{}},
+	{Method: "GET", Path: "/api/expense/{expense_id}/detail", Summary: "Get an expense with payer and participant names, for sharing or printing",
+		Response: services.GetExpenseDetailResponse{}},
+	{Method: "PUT", Path: "/api/expense/{expense_id}", Summary: "Update an existing expense",
+		Request: services.UpdateExpenseRequest{}, Response: services.UpdateExpenseResponse{}},
+	{Method: "POST", Path: "/api/expense/{expense_id}/resplit", Summary: "Split an existing expense equally among a new set of participants",
+		Request: struct {
+			ParticipantIds []int32 `json:"participant_ids"`
+		}{}, Response: services.ResplitExpenseResponse{}},
+	{Method: "PUT", Path: "/api/expense/{expense_id}/lock", Summary: "Lock or unlock an expense against edits and deletion",
+		Request: struct {
+			Locked bool `json:"locked"`
+		}{}, Response: services.SetExpenseLockedResponse{}},
+	{Method: "DELETE", Path: "/api/expense/{expense_id}", Summary: "Delete an expense",
+		Response: map[string]string{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/validate-split", Summary: "Check a proposed split and preview the computed amounts without saving",
+		Request: services.ValidateSplitRequest{}, Response: services.ValidateSplitResponse{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/expenses/delete-multiple", Summary: "Delete several expenses of a group in one transaction",
+		Request: struct {
+			ExpenseIds []int32 `json:"expense_ids"`
+		}{}, Response: services.DeleteExpensesResponse{}},
+
+	// Debt Management
+	{Method: "GET", Path: "/api/group/{url_slug}/debts-page-data", Summary: "Get simplified debts with resolved names and currency",
+		Response: services.GetDebtsPageDataResponse{}},
+	{Method: "PUT", Path: "/api/debts/{debt_id}/paid", Summary: "Record a payment against a debt",
+		Request: services.CreatePaymentRequest{}, Response: services.CreatePaymentResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/debt-graph", Summary: "Get participants and simplified debts as graph nodes and edges",
+		Response: services.GetDebtGraphResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/settlement-comparison", Summary: "Compare raw pairwise debts with the simplified settlement",
+		Response: services.GetSettlementComparisonResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/debt-cycles", Summary: "Find cycles in the pairwise debts and optionally net them out",
+		Response: services.GetDebtCyclesResponse{}, Query: []string{"collapse"}},
+	{Method: "GET", Path: "/api/group/{url_slug}/computed-balances", Summary: "Compute live net balances from expenses and payments without writing debts",
+		Response: services.GetComputedBalancesResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/settlement-circles", Summary: "Suggest a settlement where everyone deals with as few people as possible",
+		Response: services.GetSettlementCirclesResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/settlement-steps", Summary: "Get the simplified debts as numbered, human-readable payment instructions",
+		Response: services.GetSettlementStepsResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/debts", Summary: "Recompute the simplified debts as they were at a past time (asof, RFC 3339)",
+		Response: services.GetDebtsAsOfResponse{}, Query: []string{"asof"}},
+	{Method: "GET", Path: "/api/group/{url_slug}/debts/count", Summary: "Count outstanding debts and their total, for a badge",
+		Response: services.GetDebtCountResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/debts/largest", Summary: "Get the debt with the most still owed; 204 when there are none",
+		Response: services.GetLargestDebtResponse{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/debts/pay-multiple", Summary: "Record payments against several debts in one transaction",
+		Request: struct {
+			Payments []*services.DebtPaymentItem `json:"payments"`
+			Note     string                      `json:"note"`
+		}{}, Response: services.PayMultipleDebtsResponse{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/debts/project", Summary: "Preview the debts after hypothetical payments without recording them",
+		Request: struct {
+			Payments []*services.DebtPaymentItem `json:"payments"`
+		}{}, Response: services.ProjectDebtsResponse{}},
+	{Method: "POST", Path: "/api/group/{url_slug}/settle-pair", Summary: "Record a payment settling one payer -> payee debt",
+		Request: services.SettlePairRequest{}, Response: services.SettlePairResponse{}},
+	{Method: "GET", Path: "/api/group/{group_id}/payments", Summary: "Get all payments for a group",
+		Response: []*services.Payment{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/payments-page-data", Summary: "Get all payments with payer/payee names and currency",
+		Response: services.GetPaymentsPageDataResponse{}},
+	{Method: "GET", Path: "/api/group/{url_slug}/payments.csv", Summary: "Download all payments as CSV (date, payer, payee, amount, currency)"},
+	{Method: "GET", Path: "/api/group/{url_slug}/summary.md", Summary: "Download a human-readable markdown recap of the group (participants, expenses, payments, settlement)"},
+	{Method: "DELETE", Path: "/api/payments/{payment_id}", Summary: "Delete a payment and recalculate debts",
+		Status: http.StatusNoContent},
+
+	// Activity
+	{Method: "GET", Path: "/api/group/{url_slug}/activity", Summary: "Get the group's activity log, newest first",
+		Response: services.GetGroupActivityResponse{}, Query: []string{"limit"}},
+	{Method: "POST", Path: "/api/group/{url_slug}/undo", Summary: "Undo the most recent created expense or recorded payment",
+		Response: services.UndoResponse{}},
+
+	// User Groups
+	{Method: "POST", Path: "/api/user-groups/summary", Summary: "Get net balances for a user across groups, with pinned flags and sort order for the identity in X-Identity-Token",
+		Request: services.UserGroupsSummaryRequest{}, Response: services.UserGroupsSummaryResponse{}},
+	{Method: "POST", Path: "/api/user-groups/combined-settlement", Summary: "Net several groups with the same people into one settlement",
+		Request: services.CombinedSettlementRequest{}, Response: services.CombinedSettlementResponse{}},
+	{Method: "POST", Path: "/api/user-groups/participants", Summary: "Get participants for several groups",
+		Request: services.GroupParticipantsRequest{}, Response: services.GroupParticipantsResponse{}},
+	{Method: "POST", Path: "/api/user-groups/activity", Summary: "Get the most recent activity across several groups",
+		Request: services.UserGroupsActivityRequest{}, Response: services.UserGroupsActivityResponse{}},
+	{Method: "POST", Path: "/api/user-groups/find-participant", Summary: "Find the groups a participant name appears in, case-insensitively",
+		Request: services.FindParticipantRequest{}, Response: services.FindParticipantResponse{}},
+	{Method: "GET", Path: "/api/user-groups/preferences", Summary: "Get the pinned flags and sort order stored for the identity in X-Identity-Token",
+		Response: services.GetUserGroupPreferencesResponse{}},
+	{Method: "PUT", Path: "/api/user-groups/preferences", Summary: "Store pinned flags and sort order for some groups of the identity in X-Identity-Token",
+		Request: services.SetUserGroupPreferencesRequest{}, Response: services.SetUserGroupPreferencesResponse{}},
+
+	// Admin (X-Admin-Token required)
+	{Method: "GET", Path: "/api/admin/currencies", Summary: "Count groups per currency, most used first",
+		Response: services.ListCurrenciesResponse{}},
+
+	// Meta
+	{Method: "GET", Path: "/openapi.json", Summary: "This OpenAPI document",
+		Response: map[string]interface{}{}},
+}
+
+// Document returns the OpenAPI document for the FreeSplit REST API
+func Document() map[string]interface{} {
+	return Spec("FreeSplit API", "1.0.0", Routes)
+}
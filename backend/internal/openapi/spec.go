@@ -0,0 +1,184 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route describes one REST endpoint exposed by rest_server.go
+type Route struct {
+	Method   string
+	Path     string
+	Summary  string
+	Request  interface{} // zero value of the request body type, nil when there is no body
+	Response interface{} // zero value of the response body type, nil for empty responses
+	Status   int         // success status code, defaults to 200
+	Query    []string    // optional query string parameters
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// Spec builds the OpenAPI 3 document for the given routes.
+// Input: API title, version and the list of routes
+// Output: OpenAPI document ready to be encoded as JSON
+// Description: Path parameters come from the {name} segments of each path; request and response
+// schemas are derived from the Go types so they follow the service types automatically
+func Spec(title, version string, routes []Route) map[string]interface{} {
+	b := &builder{schemas: make(map[string]interface{})}
+
+	paths := make(map[string]interface{})
+	for _, route := range routes {
+		item, ok := paths[route.Path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = b.operation(route)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.schemas,
+		},
+	}
+}
+
+type builder struct {
+	schemas map[string]interface{}
+}
+
+func (b *builder) operation(route Route) map[string]interface{} {
+	op := map[string]interface{}{
+		"summary": route.Summary,
+	}
+
+	var parameters []interface{}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		name := match[1]
+		schema := map[string]interface{}{"type": "string"}
+		if strings.HasSuffix(name, "_id") {
+			schema = map[string]interface{}{"type": "integer", "format": "int32"}
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+	for _, name := range route.Query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+
+	if route.Request != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": b.schemaFor(reflect.TypeOf(route.Request)),
+				},
+			},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := map[string]interface{}{
+		"description": http.StatusText(status),
+	}
+	if route.Response != nil {
+		response["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": b.schemaFor(reflect.TypeOf(route.Response)),
+			},
+		}
+	}
+	op["responses"] = map[string]interface{}{
+		strconv.Itoa(status): response,
+	}
+
+	return op
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor maps a Go type to a JSON schema, registering named structs under components
+func (b *builder) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, ok := b.schemas[t.Name()]; !ok {
+			// Register before recursing so self-referencing types terminate
+			b.schemas[t.Name()] = map[string]interface{}{}
+			b.schemas[t.Name()] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	return map[string]interface{}{}
+}
+
+func (b *builder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schemaFor(field.Type)
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
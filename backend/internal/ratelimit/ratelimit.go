@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter caps how many events happen within a sliding time window, both per key (e.g. client IP)
+// and across all keys. A limit of zero or less disables that cap.
+type Limiter struct {
+	mu        sync.Mutex
+	perKey    int
+	global    int
+	window    time.Duration
+	events    map[string][]time.Time
+	all       []time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates a limiter allowing perKey events per key and global events overall within each window.
+func New(perKey, global int, window time.Duration) *Limiter {
+	return &Limiter{
+		perKey: perKey,
+		global: global,
+		window: window,
+		events: make(map[string][]time.Time),
+		now:    time.Now,
+	}
+}
+
+// SetClock replaces the limiter's time source; meant for tests.
+func (l *Limiter) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+}
+
+// Allow records an event for key and reports whether it is within both limits.
+// Rejected events are not recorded, so a client that keeps retrying is let in again once the window passes.
+func (l *Limiter) Allow(key string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	cutoff := now.Add(-l.window)
+	l.sweep(now, cutoff)
+
+	keyEvents := recent(l.events[key], cutoff)
+	l.all = recent(l.all, cutoff)
+
+	if l.perKey > 0 && len(keyEvents) >= l.perKey {
+		l.events[key] = keyEvents
+		return false
+	}
+	if l.global > 0 && len(l.all) >= l.global {
+		l.events[key] = keyEvents
+		return false
+	}
+
+	l.events[key] = append(keyEvents, now)
+	l.all = append(l.all, now)
+	return true
+}
+
+// sweep drops keys without recent events, at most once per window, so idle clients don't pile up in memory
+func (l *Limiter) sweep(now, cutoff time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, times := range l.events {
+		if kept := recent(times, cutoff); len(kept) > 0 {
+			l.events[key] = kept
+		} else {
+			delete(l.events, key)
+		}
+	}
+}
+
+// recent drops the timestamps at or before cutoff; times are kept in the order they were recorded
+func recent(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// ClientIP returns the address a request came from. With trustProxy set, the first address in
+// X-Forwarded-For wins; only enable that behind a proxy that sets the header, since clients can forge it.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"freesplit/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Activity actions recorded in the activity log
+const (
+	ActionExpenseCreated = "expense_created"
+	ActionExpenseUpdated = "expense_updated"
+	ActionExpenseDeleted = "expense_deleted"
+	ActionPaymentCreated = "payment_created"
+	ActionPaymentDeleted = "payment_deleted"
+	ActionLedgerReset    = "ledger_reset"
+	ActionDebtSettled    = "debt_settled"
+	ActionGroupMerged    = "group_merged"
+)
+
+// reversibleActions are the actions Undo knows how to reverse
+var reversibleActions = []string{ActionExpenseCreated, ActionPaymentCreated}
+
+// defaultActivityLimit is how many activity entries are returned when no limit is given
+const defaultActivityLimit = 50
+
+type activityService struct {
+	db *gorm.DB
+}
+
+// NewActivityService creates a new instance of the activity service with database connection.
+// Input: gorm.DB database connection
+// Output: ActivityService interface implementation
+// Description: Initializes activity service with database dependency injection
+func NewActivityService(db *gorm.DB) ActivityService {
+	return &activityService{db: db}
+}
+
+// GetGroupActivity retrieves the most recent entries of a group's activity log.
+// Input: GetGroupActivityRequest with UrlSlug and optional Limit
+// Output: GetGroupActivityResponse with activities, newest first
+// Description: Returns at most Limit entries (defaultActivityLimit when not set)
+func (s *activityService) GetGroupActivity(ctx context.Context, req *GetGroupActivityRequest) (*GetGroupActivityResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+
+	var activities []database.Activity
+	if err := s.db.Where("group_id = ?", group.ID).Order("created_at DESC, id DESC").Limit(limit).Find(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to get activity: %v", err)
+	}
+
+	responseActivities := make([]*Activity, len(activities))
+	for i, a := range activities {
+		responseActivities[i] = ActivityFromDB(&a)
+	}
+
+	return &GetGroupActivityResponse{
+		Activities: responseActivities,
+	}, nil
+}
+
+// GetUserGroupsActivity retrieves the most recent activity across several groups as one feed.
+// Input: UserGroupsActivityRequest with group slugs and optional Limit
+// Output: UserGroupsActivityResponse with activities from all groups, newest first
+// Description: Slugs that don't resolve to a group are skipped; returns at most Limit entries in total
+// (defaultActivityLimit when not set)
+func (s *activityService) GetUserGroupsActivity(ctx context.Context, req *UserGroupsActivityRequest) (*UserGroupsActivityResponse, error) {
+	response := &UserGroupsActivityResponse{Activities: []*UserGroupActivity{}}
+	if len(req.GroupSlugs) == 0 {
+		return response, nil
+	}
+
+	var groups []database.Group
+	if err := s.db.Where("url_slug IN ?", req.GroupSlugs).Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get groups: %v", err)
+	}
+	if len(groups) == 0 {
+		return response, nil
+	}
+
+	groupIDs := make([]uint, len(groups))
+	groupMap := make(map[uint]*database.Group)
+	for i := range groups {
+		groupIDs[i] = groups[i].ID
+		groupMap[groups[i].ID] = &groups[i]
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+
+	var activities []database.Activity
+	if err := s.db.Where("group_id IN ?", groupIDs).Order("created_at DESC, id DESC").Limit(limit).Find(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to get activity: %v", err)
+	}
+
+	for _, a := range activities {
+		group := groupMap[a.GroupID]
+		response.Activities = append(response.Activities, &UserGroupActivity{
+			Activity:     *ActivityFromDB(&a),
+			GroupUrlSlug: group.URLSlug,
+			GroupName:    group.Name,
+		})
+	}
+
+	return response, nil
+}
+
+// Undo reverses the most recent reversible action in a group.
+// Input: UndoRequest with UrlSlug
+// Output: UndoResponse with the activity entry that was undone
+// Description: A created expense is deleted with its splits and a recorded payment is deleted; debts are
+// recalculated and the entry is marked as undone, all in one transaction
+func (s *activityService) Undo(ctx context.Context, req *UndoRequest) (*UndoResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureGroupActive(group); err != nil {
+		return nil, err
+	}
+
+	activity, err := lastReversibleActivity(s.db, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	release := acquireRecalculationSlot()
+	defer release()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		switch activity.Action {
+		case ActionExpenseCreated:
+			if err := undoExpenseCreated(tx, activity); err != nil {
+				return err
+			}
+		case ActionPaymentCreated:
+			if err := undoPaymentCreated(tx, activity); err != nil {
+				return err
+			}
+		}
+
+		if err := recalculateDebts(tx, group.ID); err != nil {
+			return fmt.Errorf("failed to recalculate debts: %v", err)
+		}
+
+		activity.Undone = true
+		if err := tx.Save(activity).Error; err != nil {
+			return fmt.Errorf("failed to mark activity as undone: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UndoResponse{
+		Activity: ActivityFromDB(activity),
+	}, nil
+}
+
+// lastReversibleActivity finds the newest activity entry Undo can still reverse.
+// Input: gorm.DB database connection and groupID
+// Output: the activity entry, or a "nothing to undo" error
+// Description: Skips entries that were already undone, whose expense or payment has since been deleted,
+// or that happened before the last ledger reset
+func lastReversibleActivity(db *gorm.DB, groupID uint) (*database.Activity, error) {
+	query := db.Where("group_id = ? AND undone = ? AND action IN ?", groupID, false, reversibleActions)
+
+	var resets []database.Activity
+	if err := db.Where("group_id = ? AND action = ?", groupID, ActionLedgerReset).Order("id DESC").Limit(1).Find(&resets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get activity: %v", err)
+	}
+	if len(resets) > 0 {
+		query = query.Where("id > ?", resets[0].ID)
+	}
+
+	var candidates []database.Activity
+	if err := query.Order("created_at DESC, id DESC").Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get activity: %v", err)
+	}
+
+	for i := range candidates {
+		var model interface{} = &database.Expense{}
+		if candidates[i].Action == ActionPaymentCreated {
+			model = &database.Payment{}
+		}
+
+		var count int64
+		if err := db.Model(model).Where("id = ?", candidates[i].EntityID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check activity: %v", err)
+		}
+		if count > 0 {
+			return &candidates[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("nothing to undo")
+}
+
+// undoExpenseCreated deletes the expense an expense_created entry refers to, with its splits
+func undoExpenseCreated(tx *gorm.DB, activity *database.Activity) error {
+	var expenses []database.Expense
+	if err := tx.Where("id = ? AND group_id = ?", activity.EntityID, activity.GroupID).Limit(1).Find(&expenses).Error; err != nil {
+		return fmt.Errorf("failed to get expense: %v", err)
+	}
+	if len(expenses) > 0 {
+		if err := ensureExpenseUnlocked(&expenses[0]); err != nil {
+			return fmt.Errorf("cannot undo: %v", err)
+		}
+	}
+
+	result := tx.Where("id = ? AND group_id = ?", activity.EntityID, activity.GroupID).Delete(&database.Expense{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete expense: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cannot undo: expense %d no longer exists", activity.EntityID)
+	}
+
+	if err := tx.Where("expense_id = ?", activity.EntityID).Delete(&database.Split{}).Error; err != nil {
+		return fmt.Errorf("failed to delete splits: %v", err)
+	}
+	return nil
+}
+
+// undoPaymentCreated deletes the payment a payment_created entry refers to
+func undoPaymentCreated(tx *gorm.DB, activity *database.Activity) error {
+	result := tx.Where("id = ? AND group_id = ?", activity.EntityID, activity.GroupID).Delete(&database.Payment{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete payment: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cannot undo: payment %d no longer exists", activity.EntityID)
+	}
+	return nil
+}
+
+// recordActivity appends an entry to a group's activity log inside the caller's transaction.
+// Input: gorm.DB transaction, groupID, action, the ID of the touched entity and a description
+// Output: error if the entry can't be written
+func recordActivity(tx *gorm.DB, groupID uint, action string, entityID uint, description string) error {
+	activity := database.Activity{
+		GroupID:     groupID,
+		Action:      action,
+		EntityID:    entityID,
+		Description: description,
+	}
+	if err := tx.Create(&activity).Error; err != nil {
+		return fmt.Errorf("failed to record activity: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"freesplit/internal/database"
+
+	"gorm.io/gorm"
+)
+
+type adminService struct {
+	db *gorm.DB
+}
+
+// NewAdminService creates a new instance of the admin service with database connection.
+// Input: gorm.DB database connection
+// Output: AdminService interface implementation
+// Description: Initializes admin service with database dependency injection
+func NewAdminService(db *gorm.DB) AdminService {
+	return &adminService{db: db}
+}
+
+// ListCurrencies reports which currencies groups use and how many groups use each.
+// Input: ListCurrenciesRequest (no fields)
+// Output: ListCurrenciesResponse with one entry per distinct currency, most used first (ties by code)
+// Description: Counted with a single GROUP BY over groups; archived and settled groups are included
+func (s *adminService) ListCurrencies(ctx context.Context, req *ListCurrenciesRequest) (*ListCurrenciesResponse, error) {
+	var currencies []*CurrencyUsage
+	if err := s.db.Model(&database.Group{}).
+		Select("currency, COUNT(*) as group_count").
+		Group("currency").
+		Order("group_count DESC, currency").
+		Scan(&currencies).Error; err != nil {
+		return nil, fmt.Errorf("failed to count groups by currency: %v", err)
+	}
+
+	if currencies == nil {
+		currencies = []*CurrencyUsage{}
+	}
+
+	return &ListCurrenciesResponse{Currencies: currencies}, nil
+}
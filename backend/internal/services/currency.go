@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"freesplit/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// defaultMinorUnits is the number of decimal places used by most currencies (USD, EUR, GBP, ...)
+const defaultMinorUnits = 2
+
+// currencyMinorUnits lists ISO 4217 currencies whose minor unit differs from the default
+var currencyMinorUnits = map[string]int{
+	// Zero-decimal currencies
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	// Three-decimal currencies
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorUnits returns how many decimal places a currency uses (JPY → 0, USD → 2, KWD → 3).
+// Unknown or empty currencies fall back to two decimals.
+func MinorUnits(currency string) int {
+	if units, ok := currencyMinorUnits[strings.ToUpper(currency)]; ok {
+		return units
+	}
+	return defaultMinorUnits
+}
+
+// AmountThreshold returns the smallest amount worth tracking in a currency: half its minor unit
+// (JPY → 0.5, USD → 0.005, KWD → 0.0005). Balances and differences below it are rounding noise.
+func AmountThreshold(currency string) float64 {
+	return 0.5 / math.Pow10(MinorUnits(currency))
+}
+
+// ToMinorUnits converts an amount to an integer count of the currency's minor unit
+// (12.34 USD → 1234 cents, 1.235 KWD → 1235 fils), rounding to the nearest unit.
+// Use it to add up or compare amounts exactly.
+func ToMinorUnits(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(MinorUnits(currency))))
+}
+
+// FromMinorUnits converts an integer count of the currency's minor unit back to an amount.
+func FromMinorUnits(units int64, currency string) float64 {
+	return float64(units) / math.Pow10(MinorUnits(currency))
+}
+
+// currencySymbols lists the currencies shown with a symbol prefix instead of their ISO code suffix
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "INR": "₹",
+}
+
+// Amount display modes a group can choose. They only change how amounts are formatted for people;
+// stored amounts and debt calculations always keep the currency's full precision.
+const (
+	AmountDisplayCents = "cents" // The currency's minor units, e.g. $10.50
+	AmountDisplayWhole = "whole" // Rounded to whole units, halves away from zero, e.g. $10.50 → $11
+)
+
+// normalizeAmountDisplay checks a group's amount display mode; empty means AmountDisplayCents.
+func normalizeAmountDisplay(display string) (string, error) {
+	switch display {
+	case "":
+		return AmountDisplayCents, nil
+	case AmountDisplayCents, AmountDisplayWhole:
+		return display, nil
+	}
+	return "", fmt.Errorf("invalid group: amount_display must be %q or %q", AmountDisplayCents, AmountDisplayWhole)
+}
+
+// DisplayDecimals returns how many decimals amounts are shown with in a display mode:
+// none for AmountDisplayWhole, the currency's minor units otherwise.
+func DisplayDecimals(currency, display string) int {
+	if display == AmountDisplayWhole {
+		return 0
+	}
+	return MinorUnits(currency)
+}
+
+// DisplayAmount rounds an amount the way a display mode shows it (10.50 USD → 11 in "whole" mode).
+func DisplayAmount(amount float64, currency, display string) float64 {
+	scale := math.Pow10(DisplayDecimals(currency, display))
+	return math.Round(amount*scale) / scale
+}
+
+// FormatAmount renders an amount for people to read, with the currency's number of decimals
+// (12 USD → "$12.00", 1500 JPY → "¥1500", 2.5 CHF → "2.50 CHF").
+func FormatAmount(amount float64, currency string) string {
+	return FormatDisplayAmount(amount, currency, AmountDisplayCents)
+}
+
+// FormatDisplayAmount renders an amount like FormatAmount, rounded for a group's display mode
+// (10.5 USD → "$11" in "whole" mode).
+func FormatDisplayAmount(amount float64, currency, display string) string {
+	value := fmt.Sprintf("%.*f", DisplayDecimals(currency, display), DisplayAmount(amount, currency, display))
+	code := strings.ToUpper(currency)
+	if symbol, ok := currencySymbols[code]; ok {
+		if strings.HasPrefix(value, "-") {
+			return "-" + symbol + value[1:]
+		}
+		return symbol + value
+	}
+	if code == "" {
+		return value
+	}
+	return value + " " + code
+}
+
+// roundToMinorUnits rounds an amount to the currency's number of decimal places.
+func roundToMinorUnits(amount float64, currency string) float64 {
+	return FromMinorUnits(ToMinorUnits(amount, currency), currency)
+}
+
+// groupCurrency returns the currency of a group, or "" when the group can't be found
+// (callers then get the default two-decimal behavior).
+func groupCurrency(db *gorm.DB, groupID uint) (string, error) {
+	var groups []database.Group
+	if err := db.Where("id = ?", groupID).Limit(1).Find(&groups).Error; err != nil {
+		return "", err
+	}
+	if len(groups) == 0 {
+		return "", nil
+	}
+	return groups[0].Currency, nil
+}
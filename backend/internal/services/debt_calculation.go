@@ -3,6 +3,11 @@ package services
 import (
 	"fmt"
 	"freesplit/internal/database"
+	"freesplit/internal/logger"
+	"math"
+	"math/bits"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -48,64 +53,105 @@ The debt simplification will create:
 
 */
 func CalculateNetDebts(db *gorm.DB, groupID uint) ([]database.Debt, error) {
-	// Get all participants in the group
-	var participants []database.Participant
-	if err := db.Where("group_id = ?", groupID).Find(&participants).Error; err != nil {
+	return calculateNetDebts(db, groupID, nil)
+}
+
+// CalculateNetDebtsAsOf calculates the simplified debts a group had at a point in time.
+// Input: gorm.DB database connection, groupID and the cutoff time
+// Output: []database.Debt list of calculated debts (not persisted) and error
+// Description: Like CalculateNetDebts, but only expenses and payments created at or before asOf count
+func CalculateNetDebtsAsOf(db *gorm.DB, groupID uint, asOf time.Time) ([]database.Debt, error) {
+	return calculateNetDebts(db, groupID, &asOf)
+}
+
+// calculateNetDebts simplifies the balances of a group, optionally only counting entries up to asOf
+func calculateNetDebts(db *gorm.DB, groupID uint, asOf *time.Time) ([]database.Debt, error) {
+	balances, participants, err := calculateBalances(db, groupID, asOf)
+	if err != nil {
 		return nil, err
 	}
 
-	// Calculate net balances for each participant
-	balances := make(map[uint]float64)
-	for _, participant := range participants {
-		balances[participant.ID] = 0
+	// Amounts below half the currency's minor unit are rounding noise
+	currency, err := groupCurrency(db, groupID)
+	if err != nil {
+		return nil, err
 	}
-
-	// Get all expenses for the group
-	var expenses []database.Expense
-	if err := db.Where("group_id = ?", groupID).Find(&expenses).Error; err != nil {
+	bankerID, err := groupBanker(db, groupID)
+	if err != nil {
 		return nil, err
 	}
+	var newDebts []database.Debt
+	if _, ok := balances[bankerID]; ok {
+		newDebts = bankerDebts(groupID, bankerID, balances, AmountThreshold(currency))
+	} else {
+		newDebts = simplifyBalances(groupID, balances, AmountThreshold(currency))
+	}
 
-	// Calculate balances based on expenses and splits
-	for _, expense := range expenses {
-		// Add the full amount to the payer's balance (they paid for it)
-		balances[expense.PayerID] += expense.Cost
-
-		// Get splits for this expense
-		var splits []database.Split
-		if err := db.Where("expense_id = ?", expense.ID).Find(&splits).Error; err != nil {
-			return nil, err
+	// The invariant check is only run when debug logging is on so production recalculations stay cheap
+	if logger.Default().Enabled(logger.LevelDebug) {
+		if err := CheckDebtCountInvariant(newDebts, len(participants)); err != nil {
+			return nil, fmt.Errorf("group %d: %v", groupID, err)
 		}
-
-		// Subtract each participant's share from their balance
-		for _, split := range splits {
-			balances[split.ParticipantID] -= split.SplitAmount
+		if err := CheckBalanceConservation(balances, currency); err != nil {
+			return nil, fmt.Errorf("group %d: %v", groupID, err)
 		}
 	}
 
-	// Get all historical payments from the Payment table
-	var payments []database.Payment
-	if err := db.Where("group_id = ?", groupID).Find(&payments).Error; err != nil {
-		return nil, err
+	return newDebts, nil
+}
+
+// groupBanker returns the participant a group settles through, or 0 when it has none
+func groupBanker(db *gorm.DB, groupID uint) (uint, error) {
+	var groups []database.Group
+	if err := db.Select("banker_id").Where("id = ?", groupID).Limit(1).Find(&groups).Error; err != nil {
+		return 0, err
+	}
+	if len(groups) == 0 || groups[0].BankerID == nil {
+		return 0, nil
 	}
+	return *groups[0].BankerID, nil
+}
 
-	// Calculate total payments per participant pair
-	paymentTotals := make(map[string]float64) // key: "payerID-payeeID", value: total paid
-	for _, payment := range payments {
-		key := fmt.Sprintf("%d-%d", payment.PayerID, payment.PayeeID)
-		paymentTotals[key] += payment.Amount
+// groupSimplifiesDebts reports whether a group's stored debts are simplified; unknown groups default to true
+func groupSimplifiesDebts(db *gorm.DB, groupID uint) (bool, error) {
+	var groups []database.Group
+	if err := db.Select("simplify_debts").Where("id = ?", groupID).Limit(1).Find(&groups).Error; err != nil {
+		return false, err
 	}
+	return len(groups) == 0 || groups[0].SimplifyDebts, nil
+}
 
-	// Subtract payments from balances
-	for key, amount := range paymentTotals {
-		var payerID, payeeID uint
-		fmt.Sscanf(key, "%d-%d", &payerID, &payeeID)
-		// The payer has made a payment, so reduce what they owe
-		balances[payerID] += amount
-		// The payee has received a payment, so reduce what they're owed
-		balances[payeeID] -= amount
+// bankerDebts routes every net balance through the group's banker.
+// Input: group ID for the debts, the banker's participant ID, net balance per participant ID and the currency threshold
+// Output: debts ordered by participant ID (not persisted)
+// Description: Everyone who owes money owes it to the banker, and the banker owes everyone who is owed
+// money, so each participant makes or receives at most one payment and all of them involve the banker
+func bankerDebts(groupID, bankerID uint, balances map[uint]float64, threshold float64) []database.Debt {
+	participantIDs := make([]uint, 0, len(balances))
+	for participantID := range balances {
+		if participantID != bankerID {
+			participantIDs = append(participantIDs, participantID)
+		}
+	}
+	sort.Slice(participantIDs, func(i, j int) bool { return participantIDs[i] < participantIDs[j] })
+
+	var debts []database.Debt
+	for _, participantID := range participantIDs {
+		balance := balances[participantID]
+		if balance > threshold {
+			debts = append(debts, database.Debt{GroupID: groupID, LenderID: participantID, DebtorID: bankerID, DebtAmount: balance})
+		} else if balance < -threshold {
+			debts = append(debts, database.Debt{GroupID: groupID, LenderID: bankerID, DebtorID: participantID, DebtAmount: -balance})
+		}
 	}
+	return debts
+}
 
+// simplifyBalances turns net balances into as few debts as the greedy matching finds.
+// Input: group ID for the debts, net balance per participant ID and the currency threshold
+// Output: debts from debtors to creditors (not persisted)
+// Description: Balances within the threshold are ignored as rounding noise
+func simplifyBalances(groupID uint, balances map[uint]float64, threshold float64) []database.Debt {
 	// Create creditors and debtors lists
 	var creditors []struct {
 		ID      uint
@@ -117,13 +163,13 @@ func CalculateNetDebts(db *gorm.DB, groupID uint) ([]database.Debt, error) {
 	}
 
 	for participantID, balance := range balances {
-		// Using 0.01 as a threshold to avoid floating point precision issues
-		if balance > 0.01 { // They are owed money (creditor)
+		// Using the currency threshold to avoid floating point precision issues
+		if balance > threshold { // They are owed money (creditor)
 			creditors = append(creditors, struct {
 				ID      uint
 				Balance float64
 			}{ID: participantID, Balance: balance})
-		} else if balance < -0.01 { // They owe money (debtor)
+		} else if balance < -threshold { // They owe money (debtor)
 			debtors = append(debtors, struct {
 				ID      uint
 				Balance float64
@@ -131,6 +177,21 @@ func CalculateNetDebts(db *gorm.DB, groupID uint) ([]database.Debt, error) {
 		}
 	}
 
+	// Match the largest balances first so big creditors and debtors settle with each other directly,
+	// breaking ties by ID so the result doesn't depend on map iteration order
+	sort.Slice(creditors, func(i, j int) bool {
+		if creditors[i].Balance != creditors[j].Balance {
+			return creditors[i].Balance > creditors[j].Balance
+		}
+		return creditors[i].ID < creditors[j].ID
+	})
+	sort.Slice(debtors, func(i, j int) bool {
+		if debtors[i].Balance != debtors[j].Balance {
+			return debtors[i].Balance > debtors[j].Balance
+		}
+		return debtors[i].ID < debtors[j].ID
+	})
+
 	// Simplify debts using greedy algorithm
 	var newDebts []database.Debt
 	creditorIdx := 0
@@ -161,13 +222,447 @@ func CalculateNetDebts(db *gorm.DB, groupID uint) ([]database.Debt, error) {
 		debtor.Balance -= settleAmount
 
 		// Move to next creditor/debtor if current one is settled
-		if creditor.Balance <= 0.01 {
+		if creditor.Balance <= threshold {
 			creditorIdx++
 		}
-		if debtor.Balance <= 0.01 {
+		if debtor.Balance <= threshold {
 			debtorIdx++
 		}
 	}
 
-	return newDebts, nil
+	return newDebts
+}
+
+// maxExactSettlementCircleBalances bounds the exact search in SettlementCircles, which looks at every
+// subset of the open balances (2^16 subsets is still instant)
+const maxExactSettlementCircleBalances = 16
+
+// SettlementCircles splits net balances into as many groups that can settle among themselves as possible.
+// Input: net balance per participant ID and the group currency
+// Output: the circles, each a list of participant IDs in ascending order, ordered by their first ID
+// Description: A circle is a set of participants whose balances add up to zero, so nobody in it has to deal
+// with anyone outside it; more circles means everyone coordinates with fewer people, and a circle of k people
+// needs at most k-1 transfers. Up to maxExactSettlementCircleBalances open balances the best partition is
+// found exactly; above that, exactly opposite balances are paired and everyone else forms one circle.
+// Balances that round to zero in the currency are left out
+func SettlementCircles(balances map[uint]float64, currency string) [][]uint {
+	var ids []uint
+	for id, balance := range balances {
+		if ToMinorUnits(balance, currency) != 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	amounts := make([]int64, len(ids))
+	for i, id := range ids {
+		amounts[i] = ToMinorUnits(balances[id], currency)
+	}
+
+	var circles [][]uint
+	if len(ids) > maxExactSettlementCircleBalances {
+		circles = pairOppositeBalances(ids, amounts)
+	} else {
+		circles = zeroSumPartition(ids, amounts)
+	}
+
+	for _, circle := range circles {
+		sort.Slice(circle, func(i, j int) bool { return circle[i] < circle[j] })
+	}
+	sort.Slice(circles, func(i, j int) bool { return circles[i][0] < circles[j][0] })
+	return circles
+}
+
+// zeroSumPartition splits balances into the largest number of zero-sum groups.
+// Description: best[mask] is the most zero-sum groups that the balances in mask can be laid out into one after
+// another; walking back from the full set recovers an order in which every zero prefix sum closes a group.
+// Whatever doesn't add up to zero (rounding) ends up in the last group
+func zeroSumPartition(ids []uint, amounts []int64) [][]uint {
+	n := len(ids)
+	if n == 0 {
+		return nil
+	}
+	sums := make([]int64, 1<<n)
+	best := make([]int, 1<<n)
+	closes := func(mask int) int {
+		if sums[mask] == 0 {
+			return 1
+		}
+		return 0
+	}
+	for mask := 1; mask < 1<<n; mask++ {
+		sums[mask] = sums[mask&(mask-1)] + amounts[bits.TrailingZeros(uint(mask))]
+		most := 0
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			if b := best[mask&^(rest&-rest)]; b > most {
+				most = b
+			}
+		}
+		best[mask] = most + closes(mask)
+	}
+
+	// Recover the order from the last participant placed back to the first
+	order := make([]int, 0, n)
+	for mask := 1<<n - 1; mask != 0; {
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			bit := rest & -rest
+			if best[mask&^bit]+closes(mask) == best[mask] {
+				order = append(order, bits.TrailingZeros(uint(bit)))
+				mask &^= bit
+				break
+			}
+		}
+	}
+
+	var circles [][]uint
+	var current []uint
+	placed := 0
+	for k := len(order) - 1; k >= 0; k-- {
+		placed |= 1 << order[k]
+		current = append(current, ids[order[k]])
+		if sums[placed] == 0 {
+			circles = append(circles, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		circles = append(circles, current)
+	}
+	return circles
+}
+
+// pairOppositeBalances is the fallback for large groups: every creditor and debtor with exactly opposite
+// balances settle as a pair (lowest IDs first) and everyone else forms one circle
+func pairOppositeBalances(ids []uint, amounts []int64) [][]uint {
+	paired := make([]bool, len(ids))
+	var circles [][]uint
+	for i := range ids {
+		if paired[i] || amounts[i] <= 0 {
+			continue
+		}
+		for j := range ids {
+			if !paired[j] && amounts[j] == -amounts[i] {
+				paired[i], paired[j] = true, true
+				circles = append(circles, []uint{ids[i], ids[j]})
+				break
+			}
+		}
+	}
+
+	var rest []uint
+	for i, id := range ids {
+		if !paired[i] {
+			rest = append(rest, id)
+		}
+	}
+	if len(rest) > 0 {
+		circles = append(circles, rest)
+	}
+	return circles
+}
+
+// CalculateBalances computes every participant's net balance from a group's expenses and payments.
+// Input: gorm.DB database connection and groupID
+// Output: net balance per participant ID (positive = owed money, negative = owes money), the participants, and error
+// Description: Read-only; nothing is written, so it is safe for dashboards that must not touch the debts table
+func CalculateBalances(db *gorm.DB, groupID uint) (map[uint]float64, []database.Participant, error) {
+	return calculateBalances(db, groupID, nil)
+}
+
+// calculateBalances computes net balances, only counting expenses and payments created at or before asOf when it is set
+func calculateBalances(db *gorm.DB, groupID uint, asOf *time.Time) (map[uint]float64, []database.Participant, error) {
+	// Restricts a query to entries that existed at asOf
+	upToAsOf := func(query *gorm.DB) *gorm.DB {
+		if asOf == nil {
+			return query
+		}
+		return query.Where("created_at <= ?", *asOf)
+	}
+
+	// Get all participants in the group
+	var participants []database.Participant
+	if err := db.Where("group_id = ?", groupID).Find(&participants).Error; err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate net balances for each participant
+	balances := make(map[uint]float64)
+	for _, participant := range participants {
+		balances[participant.ID] = 0
+	}
+
+	// Get all expenses for the group
+	var expenses []database.Expense
+	if err := upToAsOf(db.Where("group_id = ?", groupID)).Find(&expenses).Error; err != nil {
+		return nil, nil, err
+	}
+
+	// Get the splits of all those expenses in one query instead of one per expense
+	splitsByExpense, err := loadSplitsByExpense(db, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate balances based on expenses and splits
+	for _, expense := range expenses {
+		// Add the full amount to the payer's balance (they paid for it)
+		balances[expense.PayerID] += expense.Cost
+
+		// Subtract each participant's share from their balance
+		for _, split := range splitsByExpense[expense.ID] {
+			balances[split.ParticipantID] -= split.SplitAmount
+		}
+	}
+
+	// Get all historical payments from the Payment table
+	var payments []database.Payment
+	if err := upToAsOf(db.Where("group_id = ?", groupID)).Find(&payments).Error; err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate total payments per participant pair
+	paymentTotals := make(map[string]float64) // key: "payerID-payeeID", value: total paid
+	for _, payment := range payments {
+		key := fmt.Sprintf("%d-%d", payment.PayerID, payment.PayeeID)
+		paymentTotals[key] += payment.Amount
+	}
+
+	// Subtract payments from balances
+	for key, amount := range paymentTotals {
+		var payerID, payeeID uint
+		fmt.Sscanf(key, "%d-%d", &payerID, &payeeID)
+		// The payer has made a payment, so reduce what they owe
+		balances[payerID] += amount
+		// The payee has received a payment, so reduce what they're owed
+		balances[payeeID] -= amount
+	}
+
+	return balances, participants, nil
+}
+
+// CheckDebtCountInvariant verifies that a simplified settlement is no larger than it needs to be.
+// Input: debts produced by CalculateNetDebts and the number of participants in the group
+// Output: error when the invariant is violated
+// Description: Settling N participants never needs more than N-1 debts; more means the greedy loop misbehaved
+func CheckDebtCountInvariant(debts []database.Debt, participantCount int) error {
+	maxDebts := participantCount - 1
+	if maxDebts < 0 {
+		maxDebts = 0
+	}
+	if len(debts) > maxDebts {
+		return fmt.Errorf("debt invariant violated: %d debts for %d participants (at most %d allowed)", len(debts), participantCount, maxDebts)
+	}
+	return nil
+}
+
+// CheckBalanceConservation verifies that no money was created or lost in a group's balances.
+// Input: net balances per participant (from CalculateBalances) and the group currency
+// Output: error when the balances don't add up to zero within the currency threshold
+// Description: Every expense credits its payer exactly what its splits charge and every payment moves money
+// between two participants, so the balances must cancel out; anything else means the splits or the algorithm are wrong
+func CheckBalanceConservation(balances map[uint]float64, currency string) error {
+	var total float64
+	for _, balance := range balances {
+		total += balance
+	}
+	if math.Abs(total) > AmountThreshold(currency) {
+		return fmt.Errorf("balance invariant violated: net balances add up to %.4f instead of zero", total)
+	}
+	return nil
+}
+
+// loadSplitsByExpense loads the splits of every expense in a group with a single query.
+// Input: gorm.DB database connection and groupID
+// Output: splits keyed by expense ID and error
+func loadSplitsByExpense(db *gorm.DB, groupID uint) (map[uint][]database.Split, error) {
+	var splits []database.Split
+	expenseIDs := db.Model(&database.Expense{}).Select("id").Where("group_id = ?", groupID)
+	if err := db.Where("expense_id IN (?)", expenseIDs).Find(&splits).Error; err != nil {
+		return nil, err
+	}
+	splitsByExpense := make(map[uint][]database.Split)
+	for _, split := range splits {
+		splitsByExpense[split.ExpenseID] = append(splitsByExpense[split.ExpenseID], split)
+	}
+	return splitsByExpense, nil
+}
+
+// CalculateRawDebts calculates the pairwise debts of a group without simplification.
+// Input: gorm.DB database connection and groupID
+// Output: []database.Debt list of pairwise debts (not persisted) and error
+// Description: Every split makes its participant owe the expense's payer their share; obligations
+// between the same two people are netted against each other and against payments between them,
+// but nothing is routed through third parties. Ordered by debtor, then lender
+func CalculateRawDebts(db *gorm.DB, groupID uint) ([]database.Debt, error) {
+	var expenses []database.Expense
+	if err := db.Where("group_id = ?", groupID).Find(&expenses).Error; err != nil {
+		return nil, err
+	}
+
+	splitsByExpense, err := loadSplitsByExpense(db, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []database.Payment
+	if err := db.Where("group_id = ?", groupID).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	currency, err := groupCurrency(db, groupID)
+	if err != nil {
+		return nil, err
+	}
+	threshold := AmountThreshold(currency)
+
+	// owed[pair] is what pair.debtor owes pair.lender; the reverse direction is netted in below
+	type pair struct{ debtor, lender uint }
+	owed := make(map[pair]float64)
+	for _, expense := range expenses {
+		for _, split := range splitsByExpense[expense.ID] {
+			if split.ParticipantID == expense.PayerID {
+				continue
+			}
+			owed[pair{debtor: split.ParticipantID, lender: expense.PayerID}] += split.SplitAmount
+		}
+	}
+	for _, payment := range payments {
+		owed[pair{debtor: payment.PayerID, lender: payment.PayeeID}] -= payment.Amount
+	}
+
+	var debts []database.Debt
+	for p, amount := range owed {
+		if reverse, ok := owed[pair{debtor: p.lender, lender: p.debtor}]; ok {
+			// Handle each unordered pair once, from the side with the lower debtor ID
+			if p.debtor > p.lender {
+				continue
+			}
+			amount -= reverse
+		}
+		debtor, lender := p.debtor, p.lender
+		if amount < 0 {
+			debtor, lender, amount = lender, debtor, -amount
+		}
+		if amount <= threshold {
+			continue
+		}
+		debts = append(debts, database.Debt{
+			GroupID:    groupID,
+			LenderID:   lender,
+			DebtorID:   debtor,
+			DebtAmount: roundToMinorUnits(amount, currency),
+		})
+	}
+
+	sort.Slice(debts, func(i, j int) bool {
+		if debts[i].DebtorID != debts[j].DebtorID {
+			return debts[i].DebtorID < debts[j].DebtorID
+		}
+		return debts[i].LenderID < debts[j].LenderID
+	})
+
+	return debts, nil
+}
+
+// DebtCycleNetting is one cycle of debts netted out by CollapseDebtCycles
+type DebtCycleNetting struct {
+	ParticipantIDs []uint  // Debtors in cycle order; each owes the next, and the last owes the first
+	Amount         float64 // Subtracted from every debt on the cycle
+}
+
+// CollapseDebtCycles nets out cycles such as A→B→C→A in a list of debts.
+// Input: debts (not modified) and the group currency
+// Output: the remaining debts and the cycles that were netted out, in the order they were found
+// Description: Subtracting the smallest debt on a cycle from every debt on it leaves each participant's
+// balance unchanged and removes at least one debt. Repeats until no cycle of debts above the currency
+// threshold is left. Simplified debts from CalculateNetDebts never contain cycles (everyone is only a
+// lender or only a debtor), so this matters for pairwise debts such as those from CalculateRawDebts
+func CollapseDebtCycles(debts []database.Debt, currency string) ([]database.Debt, []DebtCycleNetting) {
+	threshold := AmountThreshold(currency)
+	remaining := make([]database.Debt, len(debts))
+	copy(remaining, debts)
+
+	var cycles []DebtCycleNetting
+	for {
+		cycle := findDebtCycle(remaining, threshold)
+		if cycle == nil {
+			break
+		}
+		amount := remaining[cycle[0]].DebtAmount
+		for _, i := range cycle[1:] {
+			amount = math.Min(amount, remaining[i].DebtAmount)
+		}
+		netting := DebtCycleNetting{Amount: roundToMinorUnits(amount, currency)}
+		for _, i := range cycle {
+			netting.ParticipantIDs = append(netting.ParticipantIDs, remaining[i].DebtorID)
+			remaining[i].DebtAmount = roundToMinorUnits(remaining[i].DebtAmount-amount, currency)
+		}
+		cycles = append(cycles, netting)
+	}
+
+	kept := remaining[:0]
+	for _, debt := range remaining {
+		if debt.DebtAmount > threshold {
+			kept = append(kept, debt)
+		}
+	}
+	return kept, cycles
+}
+
+// findDebtCycle returns the indexes of debts forming a cycle (each debt's lender is the next one's
+// debtor), ignoring debts at or below threshold, or nil when there is none
+func findDebtCycle(debts []database.Debt, threshold float64) []int {
+	outgoing := make(map[uint][]int)
+	var debtors []uint
+	for i, debt := range debts {
+		if debt.DebtAmount <= threshold {
+			continue
+		}
+		if _, ok := outgoing[debt.DebtorID]; !ok {
+			debtors = append(debtors, debt.DebtorID)
+		}
+		outgoing[debt.DebtorID] = append(outgoing[debt.DebtorID], i)
+	}
+	// Start from the lowest IDs so the same debts always give the same cycles
+	sort.Slice(debtors, func(i, j int) bool { return debtors[i] < debtors[j] })
+
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[uint]int)
+	var path []int // Debts followed from the start of the search to the current participant
+	var visit func(participantID uint) []int
+	visit = func(participantID uint) []int {
+		state[participantID] = onPath
+		for _, i := range outgoing[participantID] {
+			lender := debts[i].LenderID
+			switch state[lender] {
+			case onPath:
+				// The lender is earlier on the path: the cycle runs from there back to this debt
+				cycle := append(append([]int{}, path...), i)
+				for start, j := range cycle {
+					if debts[j].DebtorID == lender {
+						return cycle[start:]
+					}
+				}
+			case unvisited:
+				path = append(path, i)
+				if cycle := visit(lender); cycle != nil {
+					return cycle
+				}
+				path = path[:len(path)-1]
+			}
+		}
+		state[participantID] = done
+		return nil
+	}
+	for _, debtorID := range debtors {
+		if state[debtorID] == unvisited {
+			if cycle := visit(debtorID); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
 }
@@ -2,13 +2,25 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"freesplit/internal/database"
+	"freesplit/internal/logger"
 
 	"gorm.io/gorm"
 )
 
+// deletedParticipantName is shown in place of participants whose row no longer exists
+const deletedParticipantName = "(deleted)"
+
+// maxPaymentNoteLength is the longest note, in characters, a payment can carry
+const maxPaymentNoteLength = 500
+
 type debtService struct {
 	db *gorm.DB
 }
@@ -59,12 +71,15 @@ func (s *debtService) GetDebtsPageData(ctx context.Context, req *GetDebtsRequest
 		Select(`
 			debts.id,
 			debts.debt_amount,
-			debtor.name as debtor_name,
-			lender.name as lender_name,
-			groups.currency
-		`).
-		Joins("JOIN participants as debtor ON debts.debtor_id = debtor.id").
-		Joins("JOIN participants as lender ON debts.lender_id = lender.id").
+			COALESCE(debtor.name, ?) as debtor_name,
+			COALESCE(lender.name, ?) as lender_name,
+			groups.currency,
+			debts.created_at,
+			debts.updated_at
+		`, deletedParticipantName, deletedParticipantName).
+		// LEFT JOINs keep debts whose participant row is gone so totals still reconcile
+		Joins("LEFT JOIN participants as debtor ON debts.debtor_id = debtor.id").
+		Joins("LEFT JOIN participants as lender ON debts.lender_id = lender.id").
 		Joins("JOIN groups ON debts.group_id = groups.id").
 		Where("debts.group_id = ?", groupID).
 		Scan(&debtPageData).Error
@@ -85,6 +100,419 @@ func (s *debtService) GetDebtsPageData(ctx context.Context, req *GetDebtsRequest
 	}, nil
 }
 
+// GetDebtGraph returns the group's simplified debts as graph data for visualization.
+// Input: GetDebtGraphRequest with UrlSlug
+// Output: GetDebtGraphResponse with participant nodes, debt edges and the group currency
+// Description: Loads participants and current debts once and derives every node's net balance
+// from the edges, so nodes and edges always agree
+func (s *debtService) GetDebtGraph(ctx context.Context, req *GetDebtGraphRequest) (*GetDebtGraphResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Order("id").Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	var debts []database.Debt
+	if err := s.db.Where("group_id = ?", group.ID).Order("debt_amount DESC").Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get debts: %v", err)
+	}
+
+	names := make(map[uint]string, len(participants))
+	for _, p := range participants {
+		names[p.ID] = p.Name
+	}
+	nameOf := func(id uint) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+		return deletedParticipantName
+	}
+
+	balances := make(map[uint]float64, len(participants))
+	edges := make([]*DebtGraphEdge, len(debts))
+	for i, debt := range debts {
+		balances[debt.LenderID] += debt.DebtAmount
+		balances[debt.DebtorID] -= debt.DebtAmount
+		edges[i] = &DebtGraphEdge{
+			DebtId:     int32(debt.ID),
+			FromId:     int32(debt.DebtorID),
+			FromName:   nameOf(debt.DebtorID),
+			ToId:       int32(debt.LenderID),
+			ToName:     nameOf(debt.LenderID),
+			DebtAmount: debt.DebtAmount,
+		}
+	}
+
+	nodes := make([]*DebtGraphNode, len(participants))
+	for i, p := range participants {
+		nodes[i] = &DebtGraphNode{
+			ParticipantId: int32(p.ID),
+			Name:          p.Name,
+			NetBalance:    roundToMinorUnits(balances[p.ID], group.Currency),
+		}
+	}
+
+	return &GetDebtGraphResponse{
+		Currency: group.Currency,
+		Nodes:    nodes,
+		Edges:    edges,
+	}, nil
+}
+
+// GetSettlementComparison shows how much debt simplification saves for a group.
+// Input: GetSettlementComparisonRequest with UrlSlug
+// Output: GetSettlementComparisonResponse with raw and simplified debts, their counts and the difference
+// Description: Raw debts are the pairwise obligations from CalculateRawDebts; simplified debts come from
+// CalculateNetDebts. Neither is persisted
+func (s *debtService) GetSettlementComparison(ctx context.Context, req *GetSettlementComparisonRequest) (*GetSettlementComparisonResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	rawDebts, err := CalculateRawDebts(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate raw debts: %v", err)
+	}
+	simplifiedDebts, err := CalculateNetDebts(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate simplified debts: %v", err)
+	}
+
+	names, err := participantNames(s.db, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetSettlementComparisonResponse{
+		Currency:            group.Currency,
+		RawDebtCount:        int32(len(rawDebts)),
+		SimplifiedDebtCount: int32(len(simplifiedDebts)),
+		TransactionsSaved:   int32(len(rawDebts) - len(simplifiedDebts)),
+		RawDebts:            settlementTransfers(rawDebts, names, group.Currency),
+		SimplifiedDebts:     settlementTransfers(simplifiedDebts, names, group.Currency),
+	}, nil
+}
+
+// GetDebtCycles finds chains of pairwise debts that lead back to where they started.
+// Input: GetDebtCyclesRequest with UrlSlug and whether to collapse the cycles
+// Output: GetDebtCyclesResponse with the cycles found and the pairwise debts, cycles netted out if collapsed
+// Description: Works on the raw pairwise debts from CalculateRawDebts, where cycles such as A→B→C→A
+// can build up; see CollapseDebtCycles. The stored simplified debts never contain cycles. Nothing is persisted
+func (s *debtService) GetDebtCycles(ctx context.Context, req *GetDebtCyclesRequest) (*GetDebtCyclesResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	rawDebts, err := CalculateRawDebts(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate raw debts: %v", err)
+	}
+	collapsedDebts, nettings := CollapseDebtCycles(rawDebts, group.Currency)
+
+	names, err := participantNames(s.db, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cycles := make([]*DebtCycle, len(nettings))
+	for i, netting := range nettings {
+		cycle := &DebtCycle{Amount: netting.Amount}
+		for _, id := range netting.ParticipantIDs {
+			cycle.ParticipantIds = append(cycle.ParticipantIds, int32(id))
+			cycle.ParticipantNames = append(cycle.ParticipantNames, names[id])
+		}
+		cycles[i] = cycle
+	}
+
+	debts := rawDebts
+	if req.Collapse {
+		debts = collapsedDebts
+	}
+
+	return &GetDebtCyclesResponse{
+		Currency:  group.Currency,
+		Cycles:    cycles,
+		Collapsed: req.Collapse,
+		Debts:     settlementTransfers(debts, names, group.Currency),
+	}, nil
+}
+
+// GetSettlementCircles suggests a settlement in which everyone coordinates with as few people as possible.
+// Input: GetSettlementCirclesRequest with UrlSlug
+// Output: GetSettlementCirclesResponse with the circles, their transfers and how many transfers the usual plan needs
+// Description: Splits the net balances into SettlementCircles and simplifies each circle on its own, so no
+// transfer crosses from one circle to another. Ignores the group's banker and simplification settings and
+// stores nothing
+func (s *debtService) GetSettlementCircles(ctx context.Context, req *GetSettlementCirclesRequest) (*GetSettlementCirclesResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, participants, err := CalculateBalances(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balances: %v", err)
+	}
+	names := make(map[uint]string, len(participants))
+	for _, p := range participants {
+		names[p.ID] = p.Name
+	}
+	threshold := AmountThreshold(group.Currency)
+
+	circles := []*SettlementCircle{}
+	var transferCount int32
+	for _, ids := range SettlementCircles(balances, group.Currency) {
+		circle := &SettlementCircle{}
+		circleBalances := make(map[uint]float64, len(ids))
+		for _, id := range ids {
+			circle.ParticipantIds = append(circle.ParticipantIds, int32(id))
+			circle.ParticipantNames = append(circle.ParticipantNames, names[id])
+			circleBalances[id] = balances[id]
+		}
+		circle.Transfers = settlementTransfers(simplifyBalances(group.ID, circleBalances, threshold), names, group.Currency)
+		transferCount += int32(len(circle.Transfers))
+		circles = append(circles, circle)
+	}
+
+	return &GetSettlementCirclesResponse{
+		Currency:            group.Currency,
+		Circles:             circles,
+		TransferCount:       transferCount,
+		GreedyTransferCount: int32(len(simplifyBalances(group.ID, balances, threshold))),
+	}, nil
+}
+
+// participantNames maps the IDs of a group's participants to their names
+func participantNames(db *gorm.DB, groupID uint) (map[uint]string, error) {
+	var participants []database.Participant
+	if err := db.Where("group_id = ?", groupID).Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	names := make(map[uint]string, len(participants))
+	for _, p := range participants {
+		names[p.ID] = p.Name
+	}
+	return names, nil
+}
+
+// settlementTransfers turns debts into named transfers from debtor to lender
+func settlementTransfers(debts []database.Debt, names map[uint]string, currency string) []*SettlementTransfer {
+	result := make([]*SettlementTransfer, len(debts))
+	for i, debt := range debts {
+		result[i] = &SettlementTransfer{
+			FromId:   int32(debt.DebtorID),
+			FromName: names[debt.DebtorID],
+			ToId:     int32(debt.LenderID),
+			ToName:   names[debt.LenderID],
+			Amount:   roundToMinorUnits(debt.DebtAmount, currency),
+		}
+	}
+	return result
+}
+
+// GetDebtCount counts a group's outstanding debts without loading them.
+// Input: GetDebtCountRequest with UrlSlug
+// Output: GetDebtCountResponse with the number of debts, their total and the group currency
+// Description: Uses a single aggregate query over the stored (already simplified) debts
+func (s *debtService) GetDebtCount(ctx context.Context, req *GetDebtCountRequest) (*GetDebtCountResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var totals struct {
+		Count       int64
+		TotalAmount float64
+	}
+	if err := s.db.Model(&database.Debt{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(debt_amount), 0) AS total_amount").
+		Where("group_id = ? AND debt_amount > 0", group.ID).
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to count debts: %v", err)
+	}
+
+	return &GetDebtCountResponse{
+		Count:       totals.Count,
+		TotalAmount: roundToMinorUnits(totals.TotalAmount, group.Currency),
+		Currency:    group.Currency,
+	}, nil
+}
+
+// GetLargestDebt finds the debt with the most still owed in a group.
+// Input: GetLargestDebtRequest with UrlSlug
+// Output: GetLargestDebtResponse with both names and the group currency, or nil when the group has no debts
+// Description: Picks the largest stored debt in SQL, lowest debt ID first on ties
+func (s *debtService) GetLargestDebt(ctx context.Context, req *GetLargestDebtRequest) (*GetLargestDebtResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var debts []database.Debt
+	if err := s.db.Where("group_id = ? AND debt_amount > 0", group.ID).
+		Order("debt_amount DESC, id").
+		Limit(1).
+		Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get debts: %v", err)
+	}
+	if len(debts) == 0 {
+		return nil, nil
+	}
+	debt := debts[0]
+
+	names, err := participantNames(s.db, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetLargestDebtResponse{
+		DebtId:     int32(debt.ID),
+		DebtorId:   int32(debt.DebtorID),
+		DebtorName: names[debt.DebtorID],
+		LenderId:   int32(debt.LenderID),
+		LenderName: names[debt.LenderID],
+		Amount:     roundToMinorUnits(debt.DebtAmount, group.Currency),
+		Currency:   group.Currency,
+	}, nil
+}
+
+// GetSettlementSteps turns a group's simplified debts into numbered instructions.
+// Input: GetSettlementStepsRequest with UrlSlug
+// Output: GetSettlementStepsResponse with one step per debt and the group currency
+// Description: Largest debts come first (ties by debt ID); each step names both people and formats the
+// amount in the group currency, e.g. "Charlie pays Alice $12.00"
+func (s *debtService) GetSettlementSteps(ctx context.Context, req *GetSettlementStepsRequest) (*GetSettlementStepsResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var debts []database.Debt
+	if err := s.db.Where("group_id = ?", group.ID).Order("debt_amount DESC, id").Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get debts: %v", err)
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	names := make(map[uint]string, len(participants))
+	for _, p := range participants {
+		names[p.ID] = p.Name
+	}
+	nameOf := func(id uint) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+		return deletedParticipantName
+	}
+
+	steps := make([]*SettlementStep, len(debts))
+	for i, debt := range debts {
+		amount := roundToMinorUnits(debt.DebtAmount, group.Currency)
+		steps[i] = &SettlementStep{
+			Step:        int32(i + 1),
+			FromId:      int32(debt.DebtorID),
+			FromName:    nameOf(debt.DebtorID),
+			ToId:        int32(debt.LenderID),
+			ToName:      nameOf(debt.LenderID),
+			Amount:      amount,
+			Instruction: fmt.Sprintf("%s pays %s %s", nameOf(debt.DebtorID), nameOf(debt.LenderID), FormatDisplayAmount(amount, group.Currency, group.AmountDisplay)),
+		}
+	}
+
+	return &GetSettlementStepsResponse{
+		Currency: group.Currency,
+		Steps:    steps,
+	}, nil
+}
+
+// GetComputedBalances derives each participant's net balance straight from expenses and payments.
+// Input: GetComputedBalancesRequest with UrlSlug
+// Output: GetComputedBalancesResponse with one balance per participant, ordered by participant ID
+// Description: Runs the balance math in memory via CalculateBalances and never writes, so the stored
+// debts are left exactly as they are
+func (s *debtService) GetComputedBalances(ctx context.Context, req *GetComputedBalancesRequest) (*GetComputedBalancesResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, participants, err := CalculateBalances(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balances: %v", err)
+	}
+
+	sort.Slice(participants, func(i, j int) bool { return participants[i].ID < participants[j].ID })
+	result := make([]*ComputedBalance, len(participants))
+	for i, p := range participants {
+		result[i] = &ComputedBalance{
+			ParticipantId: int32(p.ID),
+			Name:          p.Name,
+			NetBalance:    roundToMinorUnits(balances[p.ID], group.Currency),
+		}
+	}
+
+	return &GetComputedBalancesResponse{
+		Currency: group.Currency,
+		Balances: result,
+	}, nil
+}
+
+// GetDebtsAsOf recomputes the simplified debts a group had at a past point in time.
+// Input: GetDebtsAsOfRequest with UrlSlug and AsOf
+// Output: GetDebtsAsOfResponse with the debts at that time, largest first
+// Description: Only expenses and payments created at or before AsOf count; nothing is persisted
+func (s *debtService) GetDebtsAsOf(ctx context.Context, req *GetDebtsAsOfRequest) (*GetDebtsAsOfResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	debts, err := CalculateNetDebtsAsOf(s.db, group.ID, req.AsOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate debts: %v", err)
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	names := make(map[uint]string, len(participants))
+	for _, p := range participants {
+		names[p.ID] = p.Name
+	}
+	nameOf := func(id uint) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+		return deletedParticipantName
+	}
+
+	transfers := make([]*SettlementTransfer, len(debts))
+	for i, debt := range debts {
+		transfers[i] = &SettlementTransfer{
+			FromId:   int32(debt.DebtorID),
+			FromName: nameOf(debt.DebtorID),
+			ToId:     int32(debt.LenderID),
+			ToName:   nameOf(debt.LenderID),
+			Amount:   roundToMinorUnits(debt.DebtAmount, group.Currency),
+		}
+	}
+
+	return &GetDebtsAsOfResponse{
+		Currency: group.Currency,
+		AsOf:     req.AsOf,
+		Debts:    transfers,
+	}, nil
+}
+
 // CreatePayment records a payment and recalculates all debts for the group.
 // Input: CreatePaymentRequest with DebtId and PaidAmount
 // Output: CreatePaymentResponse with updated debt information
@@ -99,6 +527,11 @@ func (s *debtService) CreatePayment(ctx context.Context, req *CreatePaymentReque
 		return nil, fmt.Errorf("paid amount cannot be negative")
 	}
 
+	// A zero payment changes nothing and only clutters the payment history
+	if req.PaidAmount == 0 {
+		return nil, fmt.Errorf("paid amount must be positive")
+	}
+
 	var debt database.Debt
 	if err := s.db.First(&debt, req.DebtId).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -107,11 +540,23 @@ func (s *debtService) CreatePayment(ctx context.Context, req *CreatePaymentReque
 		return nil, fmt.Errorf("failed to get debt: %v", err)
 	}
 
+	// Payments can only be recorded against active groups
+	var group database.Group
+	if err := s.db.First(&group, debt.GroupID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get group: %v", err)
+	}
+	if err := ensureGroupActive(&group); err != nil {
+		return nil, err
+	}
+
 	// Validate that paid amount doesn't exceed debt amount
 	if req.PaidAmount > debt.DebtAmount {
 		return nil, fmt.Errorf("paid amount (%.2f) cannot exceed debt amount (%.2f)", req.PaidAmount, debt.DebtAmount)
 	}
 
+	release := acquireRecalculationSlot()
+	defer release()
+
 	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -132,6 +577,11 @@ func (s *debtService) CreatePayment(ctx context.Context, req *CreatePaymentReque
 		return nil, fmt.Errorf("failed to record payment: %v", err)
 	}
 
+	if err := recordActivity(tx, payment.GroupID, ActionPaymentCreated, payment.ID, "Recorded "+paymentDescription(tx, &payment)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Recalculate and update all debts for the group
 	if err := s.updateDebts(tx, debt.GroupID); err != nil {
 		tx.Rollback()
@@ -164,6 +614,276 @@ func (s *debtService) CreatePayment(ctx context.Context, req *CreatePaymentReque
 	}, nil
 }
 
+// PayMultipleDebts records payments against several debts of a group at once.
+// Input: PayMultipleDebtsRequest with UrlSlug and a list of debt ID / amount pairs
+// Output: PayMultipleDebtsResponse with the recorded payments and the group's debts afterwards
+// Description: Every item is validated before anything is written; payments are recorded in one
+// transaction followed by a single debt recalculation. The optional Note is stored on every payment
+func (s *debtService) PayMultipleDebts(ctx context.Context, req *PayMultipleDebtsRequest) (*PayMultipleDebtsResponse, error) {
+	if len(req.Payments) == 0 {
+		return nil, fmt.Errorf("payments list cannot be empty")
+	}
+
+	note, err := normalizePaymentNote(req.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureGroupActive(group); err != nil {
+		return nil, err
+	}
+
+	// Validate every item against the current debts before writing anything
+	payments, err := debtPayments(s.db, group.ID, req.Payments, note)
+	if err != nil {
+		return nil, err
+	}
+
+	release := acquireRecalculationSlot()
+	defer release()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&payments).Error; err != nil {
+			return fmt.Errorf("failed to record payments: %v", err)
+		}
+		for i := range payments {
+			if err := recordActivity(tx, group.ID, ActionPaymentCreated, payments[i].ID, "Recorded "+paymentDescription(tx, &payments[i])); err != nil {
+				return err
+			}
+		}
+		if err := s.updateDebts(tx, group.ID); err != nil {
+			return fmt.Errorf("failed to recalculate debts: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var debts []database.Debt
+	if err := s.db.Where("group_id = ?", group.ID).Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get debts: %v", err)
+	}
+
+	responsePayments := make([]*Payment, len(payments))
+	for i, p := range payments {
+		responsePayments[i] = PaymentFromDB(&p)
+	}
+	responseDebts := make([]*Debt, len(debts))
+	for i, d := range debts {
+		responseDebts[i] = DebtFromDB(&d)
+	}
+
+	return &PayMultipleDebtsResponse{
+		Payments: responsePayments,
+		Debts:    responseDebts,
+	}, nil
+}
+
+// debtPayments turns payments against a group's debts into payment records, without saving them.
+// Input: gorm.DB, the group ID, the payment items and the note to record on each
+// Output: one payment per item, from the debt's debtor to its lender, and error
+// Description: Rejects missing or duplicate debt IDs, non-positive amounts, debts of other groups
+// and amounts larger than the debt
+func debtPayments(db *gorm.DB, groupID uint, items []*DebtPaymentItem, note string) ([]database.Payment, error) {
+	payments := make([]database.Payment, len(items))
+	seen := make(map[int32]bool)
+	for i, item := range items {
+		if item.DebtId <= 0 {
+			return nil, fmt.Errorf("invalid debt ID")
+		}
+		if seen[item.DebtId] {
+			return nil, fmt.Errorf("duplicate debt ID: %d", item.DebtId)
+		}
+		seen[item.DebtId] = true
+
+		if item.Amount <= 0 {
+			return nil, fmt.Errorf("amount for debt %d must be positive", item.DebtId)
+		}
+
+		var debt database.Debt
+		if err := db.Where("id = ? AND group_id = ?", item.DebtId, groupID).First(&debt).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("debt %d not found", item.DebtId)
+			}
+			return nil, fmt.Errorf("failed to get debt: %v", err)
+		}
+
+		if item.Amount > debt.DebtAmount {
+			return nil, fmt.Errorf("amount (%.2f) cannot exceed debt amount (%.2f) for debt %d", item.Amount, debt.DebtAmount, item.DebtId)
+		}
+
+		payments[i] = database.Payment{
+			GroupID: groupID,
+			PayerID: debt.DebtorID,
+			PayeeID: debt.LenderID,
+			Amount:  item.Amount,
+			Note:    note,
+		}
+	}
+	return payments, nil
+}
+
+// errProjectionRollback ends the transaction of a debt projection so nothing it wrote is kept
+var errProjectionRollback = errors.New("debt projection rolled back")
+
+// ProjectDebts shows what a group's debts would be after some payments, without recording them.
+// Input: ProjectDebtsRequest with UrlSlug and hypothetical payments against current debts
+// Output: ProjectDebtsResponse with the simplified debts as they would be after the payments
+// Description: Validates the payments like PayMultipleDebts, then records them and recalculates the
+// debts inside a transaction that is always rolled back
+func (s *debtService) ProjectDebts(ctx context.Context, req *ProjectDebtsRequest) (*ProjectDebtsResponse, error) {
+	if len(req.Payments) == 0 {
+		return nil, fmt.Errorf("payments list cannot be empty")
+	}
+
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := debtPayments(s.db, group.ID, req.Payments, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var projected []database.Debt
+	release := acquireRecalculationSlot()
+	defer release()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&payments).Error; err != nil {
+			return fmt.Errorf("failed to record payments: %v", err)
+		}
+		if err := s.updateDebts(tx, group.ID); err != nil {
+			return fmt.Errorf("failed to recalculate debts: %v", err)
+		}
+		if err := tx.Where("group_id = ?", group.ID).Order("debt_amount DESC, id").Find(&projected).Error; err != nil {
+			return fmt.Errorf("failed to get debts: %v", err)
+		}
+		return errProjectionRollback
+	})
+	if err != errProjectionRollback {
+		return nil, err
+	}
+
+	names, err := participantNames(s.db, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProjectDebtsResponse{
+		Currency: group.Currency,
+		Debts:    settlementTransfers(projected, names, group.Currency),
+	}, nil
+}
+
+// SettlePair records a payment between two specific participants and recalculates debts.
+// Input: SettlePairRequest with UrlSlug, PayerId, PayeeId and Amount
+// Output: SettlePairResponse with the recorded payment and the remaining debt between the pair
+// Description: Only settles an existing payer -> payee debt; other debts in the group are left to the recalculation
+func (s *debtService) SettlePair(ctx context.Context, req *SettlePairRequest) (*SettlePairResponse, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	if req.PayerId == req.PayeeId {
+		return nil, fmt.Errorf("payer and payee must be different participants")
+	}
+
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureGroupActive(group); err != nil {
+		return nil, err
+	}
+
+	// Both sides must belong to this group
+	if _, err := getGroupParticipant(s.db, group.ID, req.PayerId); err != nil {
+		return nil, err
+	}
+	if _, err := getGroupParticipant(s.db, group.ID, req.PayeeId); err != nil {
+		return nil, err
+	}
+
+	// The payer must currently owe the payee
+	var debt database.Debt
+	if err := s.db.Where("group_id = ? AND debtor_id = ? AND lender_id = ?", group.ID, req.PayerId, req.PayeeId).First(&debt).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("failed to get debt: %v", err)
+		}
+
+		// Tell a reversed pair apart from one with no debt at all
+		var reversed int64
+		if err := s.db.Model(&database.Debt{}).Where("group_id = ? AND debtor_id = ? AND lender_id = ?", group.ID, req.PayeeId, req.PayerId).Count(&reversed).Error; err != nil {
+			return nil, fmt.Errorf("failed to get debt: %v", err)
+		}
+		if reversed > 0 {
+			return nil, fmt.Errorf("no debt from participant %d to participant %d: participant %d owes participant %d instead", req.PayerId, req.PayeeId, req.PayeeId, req.PayerId)
+		}
+		return nil, fmt.Errorf("no debt from participant %d to participant %d", req.PayerId, req.PayeeId)
+	}
+
+	if req.Amount > debt.DebtAmount {
+		return nil, fmt.Errorf("amount (%.2f) cannot exceed debt amount (%.2f)", req.Amount, debt.DebtAmount)
+	}
+
+	release := acquireRecalculationSlot()
+	defer release()
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	payment := database.Payment{
+		GroupID: group.ID,
+		PayerID: debt.DebtorID,
+		PayeeID: debt.LenderID,
+		Amount:  req.Amount,
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to record payment: %v", err)
+	}
+
+	if err := recordActivity(tx, payment.GroupID, ActionPaymentCreated, payment.ID, "Recorded "+paymentDescription(tx, &payment)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.updateDebts(tx, group.ID); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to recalculate debts: %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	response := &SettlePairResponse{
+		Payment: PaymentFromDB(&payment),
+	}
+
+	var remaining database.Debt
+	err = s.db.Where("group_id = ? AND debtor_id = ? AND lender_id = ?", group.ID, req.PayerId, req.PayeeId).First(&remaining).Error
+	if err == nil {
+		response.Debt = DebtFromDB(&remaining)
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("failed to get updated debt: %v", err)
+	}
+
+	return response, nil
+}
+
 // GetPayments retrieves all payments for a specific group from the database.
 // Input: GetPaymentsRequest containing GroupId
 // Output: GetPaymentsResponse with list of payments
@@ -176,14 +896,7 @@ func (s *debtService) GetPayments(ctx context.Context, req *GetPaymentsRequest)
 
 	responsePayments := make([]*Payment, len(payments))
 	for i, p := range payments {
-		responsePayments[i] = &Payment{
-			Id:        int32(p.ID),
-			GroupId:   int32(p.GroupID),
-			PayerId:   int32(p.PayerID),
-			PayeeId:   int32(p.PayeeID),
-			Amount:    p.Amount,
-			CreatedAt: p.CreatedAt,
-		}
+		responsePayments[i] = PaymentFromDB(&p)
 	}
 
 	return &GetPaymentsResponse{
@@ -191,6 +904,269 @@ func (s *debtService) GetPayments(ctx context.Context, req *GetPaymentsRequest)
 	}, nil
 }
 
+// GetPaymentsPageData retrieves all payments of a group with payer and payee names resolved.
+// Input: GetPaymentsPageDataRequest with UrlSlug
+// Output: GetPaymentsPageDataResponse with named payments (newest first) and the group currency
+// Description: Joins payments with participants in a single query; deleted participants show as "(deleted)"
+func (s *debtService) GetPaymentsPageData(ctx context.Context, req *GetPaymentsPageDataRequest) (*GetPaymentsPageDataResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []*PaymentWithNames
+	err = s.db.Table("payments").
+		Select(`
+			payments.id,
+			payments.payer_id,
+			COALESCE(payer.name, ?) as payer_name,
+			payments.payee_id,
+			COALESCE(payee.name, ?) as payee_name,
+			payments.amount,
+			payments.note,
+			payments.created_at
+		`, deletedParticipantName, deletedParticipantName).
+		Joins("LEFT JOIN participants as payer ON payments.payer_id = payer.id").
+		Joins("LEFT JOIN participants as payee ON payments.payee_id = payee.id").
+		Where("payments.group_id = ?", group.ID).
+		Order("payments.created_at DESC, payments.id DESC").
+		Scan(&payments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments: %v", err)
+	}
+
+	if payments == nil {
+		payments = []*PaymentWithNames{}
+	}
+
+	return &GetPaymentsPageDataResponse{
+		Payments:      payments,
+		Currency:      group.Currency,
+		AmountDisplay: GroupFromDB(group).AmountDisplay,
+	}, nil
+}
+
+// GetParticipantPayments retrieves every payment a participant sent or received in a group.
+// Input: GetParticipantPaymentsRequest with UrlSlug and ParticipantId
+// Output: GetParticipantPaymentsResponse with payments labeled by direction and counterpart name
+// Description: Joins payments with participants to resolve the other side of each payment
+func (s *debtService) GetParticipantPayments(ctx context.Context, req *GetParticipantPaymentsRequest) (*GetParticipantPaymentsResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getGroupParticipant(s.db, group.ID, req.ParticipantId); err != nil {
+		return nil, err
+	}
+
+	payments, err := participantPayments(s.db, group.ID, req.ParticipantId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetParticipantPaymentsResponse{
+		Payments: payments,
+		Currency: group.Currency,
+	}, nil
+}
+
+// participantPayments loads every payment a participant sent or received in a group, newest first.
+// Input: gorm.DB database connection, groupID and participantID
+// Output: payments labeled by direction and counterpart name, and error
+func participantPayments(db *gorm.DB, groupID uint, participantID int32) ([]*ParticipantPayment, error) {
+	var rows []struct {
+		ID        uint
+		PayerID   uint
+		PayeeID   uint
+		PayerName string
+		PayeeName string
+		Amount    float64
+		CreatedAt time.Time
+	}
+	err := db.Table("payments").
+		Select(`
+			payments.id,
+			payments.payer_id,
+			payments.payee_id,
+			COALESCE(payer.name, ?) as payer_name,
+			COALESCE(payee.name, ?) as payee_name,
+			payments.amount,
+			payments.created_at
+		`, deletedParticipantName, deletedParticipantName).
+		Joins("LEFT JOIN participants as payer ON payments.payer_id = payer.id").
+		Joins("LEFT JOIN participants as payee ON payments.payee_id = payee.id").
+		Where("payments.group_id = ? AND (payments.payer_id = ? OR payments.payee_id = ?)", groupID, participantID, participantID).
+		Order("payments.created_at DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participant payments: %v", err)
+	}
+
+	responsePayments := make([]*ParticipantPayment, len(rows))
+	for i, row := range rows {
+		payment := &ParticipantPayment{
+			Id:        int32(row.ID),
+			Amount:    row.Amount,
+			CreatedAt: row.CreatedAt,
+		}
+		if row.PayerID == uint(participantID) {
+			payment.Direction = "sent"
+			payment.CounterpartId = int32(row.PayeeID)
+			payment.CounterpartName = row.PayeeName
+		} else {
+			payment.Direction = "received"
+			payment.CounterpartId = int32(row.PayerID)
+			payment.CounterpartName = row.PayerName
+		}
+		responsePayments[i] = payment
+	}
+
+	return responsePayments, nil
+}
+
+// GetParticipantOwedDebts lists the debts a participant has to pay, for a "pay up" screen.
+// Input: GetParticipantOwedDebtsRequest with UrlSlug and ParticipantId
+// Output: GetParticipantOwedDebtsResponse with debts where the participant is the debtor, largest first
+// Description: Debts the participant is owed are left out. Stored debts are recalculated after every
+// payment, so each amount is what remains to be paid
+func (s *debtService) GetParticipantOwedDebts(ctx context.Context, req *GetParticipantOwedDebtsRequest) (*GetParticipantOwedDebtsResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getGroupParticipant(s.db, group.ID, req.ParticipantId); err != nil {
+		return nil, err
+	}
+
+	var debts []*OwedDebt
+	err = s.db.Table("debts").
+		Select(`
+			debts.id,
+			debts.lender_id,
+			COALESCE(lender.name, ?) as lender_name,
+			debts.debt_amount
+		`, deletedParticipantName).
+		Joins("LEFT JOIN participants as lender ON debts.lender_id = lender.id").
+		Where("debts.group_id = ? AND debts.debtor_id = ?", group.ID, req.ParticipantId).
+		Order("debts.debt_amount DESC, debts.id").
+		Scan(&debts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owed debts: %v", err)
+	}
+
+	var total float64
+	for _, d := range debts {
+		total += d.DebtAmount
+	}
+	if debts == nil {
+		debts = []*OwedDebt{}
+	}
+
+	return &GetParticipantOwedDebtsResponse{
+		Debts:    debts,
+		Total:    roundToMinorUnits(total, group.Currency),
+		Currency: group.Currency,
+	}, nil
+}
+
+// GetParticipantSettlePlan lists the payments a participant has to make to be fully settled.
+// Input: GetParticipantSettlePlanRequest with UrlSlug and ParticipantId
+// Output: GetParticipantSettlePlanResponse with one payment per lender, largest first, and their total
+// Description: Built from the simplified debts where the participant is the debtor, which hold at most
+// one debt per lender, so no payment can be merged away
+func (s *debtService) GetParticipantSettlePlan(ctx context.Context, req *GetParticipantSettlePlanRequest) (*GetParticipantSettlePlanResponse, error) {
+	owed, err := s.GetParticipantOwedDebts(ctx, &GetParticipantOwedDebtsRequest{UrlSlug: req.UrlSlug, ParticipantId: req.ParticipantId})
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]*SettlePlanPayment, len(owed.Debts))
+	for i, debt := range owed.Debts {
+		payments[i] = &SettlePlanPayment{
+			PayeeId:   debt.LenderId,
+			PayeeName: debt.LenderName,
+			Amount:    roundToMinorUnits(debt.DebtAmount, owed.Currency),
+		}
+	}
+
+	return &GetParticipantSettlePlanResponse{
+		Payments: payments,
+		Total:    owed.Total,
+		Currency: owed.Currency,
+	}, nil
+}
+
+// GetParticipantBalance retrieves a participant's current net balance in a group.
+// Input: GetParticipantBalanceRequest with UrlSlug and ParticipantId
+// Output: GetParticipantBalanceResponse with net balance and the debts behind it
+// Description: Derived from the current simplified debts, so it matches the debts page
+func (s *debtService) GetParticipantBalance(ctx context.Context, req *GetParticipantBalanceRequest) (*GetParticipantBalanceResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getGroupParticipant(s.db, group.ID, req.ParticipantId); err != nil {
+		return nil, err
+	}
+
+	netBalance, err := s.calculateNetBalance(group.ID, req.ParticipantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		LenderID   uint
+		DebtorID   uint
+		LenderName string
+		DebtorName string
+		DebtAmount float64
+	}
+	err = s.db.Table("debts").
+		Select(`
+			debts.lender_id,
+			debts.debtor_id,
+			COALESCE(lender.name, ?) as lender_name,
+			COALESCE(debtor.name, ?) as debtor_name,
+			debts.debt_amount
+		`, deletedParticipantName, deletedParticipantName).
+		Joins("LEFT JOIN participants as lender ON debts.lender_id = lender.id").
+		Joins("LEFT JOIN participants as debtor ON debts.debtor_id = debtor.id").
+		Where("debts.group_id = ? AND (debts.lender_id = ? OR debts.debtor_id = ?)", group.ID, req.ParticipantId, req.ParticipantId).
+		Order("debts.debt_amount DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participant debts: %v", err)
+	}
+
+	owes := []*BalanceEntry{}
+	owedBy := []*BalanceEntry{}
+	for _, row := range rows {
+		if row.DebtorID == uint(req.ParticipantId) {
+			owes = append(owes, &BalanceEntry{
+				ParticipantId:   int32(row.LenderID),
+				ParticipantName: row.LenderName,
+				Amount:          row.DebtAmount,
+			})
+		} else {
+			owedBy = append(owedBy, &BalanceEntry{
+				ParticipantId:   int32(row.DebtorID),
+				ParticipantName: row.DebtorName,
+				Amount:          row.DebtAmount,
+			})
+		}
+	}
+
+	return &GetParticipantBalanceResponse{
+		NetBalance: roundToMinorUnits(netBalance, group.Currency),
+		Currency:   group.Currency,
+		Owes:       owes,
+		OwedBy:     owedBy,
+	}, nil
+}
+
 // DeletePayment removes a payment and recalculates debts for the group.
 // Input: DeletePaymentRequest with PaymentId
 // Output: DeletePaymentResponse confirming deletion
@@ -208,6 +1184,8 @@ func (s *debtService) DeletePayment(ctx context.Context, req *DeletePaymentReque
 		return nil, fmt.Errorf("failed to get payment: %v", err)
 	}
 
+	release := acquireRecalculationSlot()
+	defer release()
 	tx := s.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -220,6 +1198,11 @@ func (s *debtService) DeletePayment(ctx context.Context, req *DeletePaymentReque
 		return nil, fmt.Errorf("failed to delete payment: %v", err)
 	}
 
+	if err := recordActivity(tx, payment.GroupID, ActionPaymentDeleted, payment.ID, "Deleted "+paymentDescription(tx, &payment)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := s.updateDebts(tx, payment.GroupID); err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to recalculate debts: %v", err)
@@ -232,39 +1215,62 @@ func (s *debtService) DeletePayment(ctx context.Context, req *DeletePaymentReque
 	return &DeletePaymentResponse{}, nil
 }
 
-// updateDebts recalculates and updates debts in the database after payments
-// Input: gorm.DB transaction and groupID
-// Output: error if debt calculation fails
-// Description: Calculates new debts using the improved algorithm and updates database
-func (s *debtService) updateDebts(tx *gorm.DB, groupID uint) error {
-	// Calculate new debts using the improved algorithm
-	newDebts, err := CalculateNetDebts(tx, groupID)
-	if err != nil {
-		return err
-	}
+// paymentDescription describes a payment for the activity log, e.g. "payment of 20.00 from Bob to Alice"
+func paymentDescription(db *gorm.DB, payment *database.Payment) string {
+	var participants []database.Participant
+	db.Where("id IN ?", []uint{payment.PayerID, payment.PayeeID}).Find(&participants)
 
-	// Clear existing debts
-	if err := tx.Where("group_id = ?", groupID).Delete(&database.Debt{}).Error; err != nil {
-		return err
+	names := map[uint]string{payment.PayerID: deletedParticipantName, payment.PayeeID: deletedParticipantName}
+	for _, p := range participants {
+		names[p.ID] = p.Name
 	}
+	return fmt.Sprintf("payment of %.2f from %s to %s", payment.Amount, names[payment.PayerID], names[payment.PayeeID])
+}
 
-	// Create new debts
-	for _, debt := range newDebts {
-		if err := tx.Create(&debt).Error; err != nil {
-			return err
-		}
+// normalizePaymentNote trims a payment note and checks its length.
+// Input: raw note, possibly empty
+// Output: trimmed note and error if it is longer than maxPaymentNoteLength characters
+func normalizePaymentNote(note string) (string, error) {
+	trimmed := strings.TrimSpace(note)
+	if utf8.RuneCountInString(trimmed) > maxPaymentNoteLength {
+		return "", fmt.Errorf("invalid note: cannot be longer than %d characters", maxPaymentNoteLength)
 	}
+	return trimmed, nil
+}
 
-	return nil
+// updateDebts recalculates and updates debts in the database after payments
+// Input: gorm.DB transaction and groupID
+// Output: error if debt calculation fails
+// Description: Delegates to recalculateDebts, which rewrites the group's debts within the transaction
+func (s *debtService) updateDebts(tx *gorm.DB, groupID uint) error {
+	return recalculateDebts(tx, groupID)
 }
 
 // GetUserGroupsSummary retrieves debt summary for multiple groups by slug and participant.
 // Input: UserGroupsSummaryRequest with list of groups and participant info
-// Output: UserGroupsSummaryResponse with group summaries including net balances
-// Description: Calculates net balance for each user in their respective groups
+// Output: UserGroupsSummaryResponse with group summaries including net balances, plus totals per currency
+// Description: Calculates net balance for each user in their respective groups; totals are grouped by
+// currency (in order of first appearance) since amounts in different currencies can't be added up.
+// A participant ID that isn't in its group gets a summary with Error set instead of a zero balance,
+// and is left out of the totals
 func (s *debtService) GetUserGroupsSummary(ctx context.Context, req *UserGroupsSummaryRequest) (*UserGroupsSummaryResponse, error) {
 	if len(req.Groups) == 0 {
-		return &UserGroupsSummaryResponse{Groups: []*UserGroupSummary{}}, nil
+		return &UserGroupsSummaryResponse{Groups: []*UserGroupSummary{}, Totals: []*CurrencyTotal{}}, nil
+	}
+
+	// Pinned flags and sort order the identity stored, by group slug
+	preferences := make(map[string]*UserGroupPreference)
+	if req.IdentityToken != "" {
+		if err := validateIdentityToken(req.IdentityToken); err != nil {
+			return nil, err
+		}
+		stored, err := listUserGroupPreferences(s.db, req.IdentityToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, preference := range stored {
+			preferences[preference.GroupUrlSlug] = preference
+		}
 	}
 
 	// Get all groups by URL slug
@@ -280,8 +1286,23 @@ func (s *debtService) GetUserGroupsSummary(ctx context.Context, req *UserGroupsS
 
 	// Create map for quick lookup
 	groupMap := make(map[string]*database.Group)
-	for _, group := range groups {
+	groupIDs := make([]uint, len(groups))
+	for i, group := range groups {
 		groupMap[group.URLSlug] = &group
+		groupIDs[i] = group.ID
+	}
+
+	// Membership of every resolved group in one query, to check each entry's participant against
+	type membership struct{ groupID, participantID uint }
+	members := make(map[membership]bool)
+	if len(groupIDs) > 0 {
+		var participants []database.Participant
+		if err := s.db.Select("id, group_id").Where("group_id IN ?", groupIDs).Find(&participants).Error; err != nil {
+			return nil, fmt.Errorf("failed to get participants: %v", err)
+		}
+		for _, p := range participants {
+			members[membership{p.GroupID, p.ID}] = true
+		}
 	}
 
 	var summaries []*UserGroupSummary
@@ -292,25 +1313,187 @@ func (s *debtService) GetUserGroupsSummary(ctx context.Context, req *UserGroupsS
 			continue // Skip groups that don't exist
 		}
 
+		summary := &UserGroupSummary{
+			GroupUrlSlug: group.URLSlug,
+			GroupName:    group.Name,
+			Currency:     group.Currency,
+		}
+		if preference, ok := preferences[group.URLSlug]; ok {
+			summary.Pinned = preference.Pinned
+			summary.SortOrder = preference.SortOrder
+		}
+
+		// A stale or mistyped participant ID would otherwise look like a settled-up balance
+		if userGroup.UserParticipantId <= 0 || !members[membership{group.ID, uint(userGroup.UserParticipantId)}] {
+			summary.Error = fmt.Sprintf("participant %d not found in this group", userGroup.UserParticipantId)
+			summaries = append(summaries, summary)
+			continue
+		}
+
 		// Calculate net balance for this participant in this group
 		netBalance, err := s.calculateNetBalance(group.ID, userGroup.UserParticipantId)
 		if err != nil {
 			// Log error but continue with other groups
-			fmt.Printf("Error calculating net balance for group %s, participant %d: %v\n",
+			logger.Warnf("Error calculating net balance for group %s, participant %d: %v",
 				userGroup.GroupUrlSlug, userGroup.UserParticipantId, err)
 			netBalance = 0
 		}
-
-		summaries = append(summaries, &UserGroupSummary{
-			GroupUrlSlug: group.URLSlug,
-			GroupName:    group.Name,
-			Currency:     group.Currency,
-			NetBalance:   netBalance,
-		})
+		summary.NetBalance = netBalance
+		summaries = append(summaries, summary)
 	}
 
 	return &UserGroupsSummaryResponse{
 		Groups: summaries,
+		Totals: totalsByCurrency(summaries),
+	}, nil
+}
+
+// totalsByCurrency sums group net balances per currency, keeping what the user is owed and what
+// they owe separate.
+func totalsByCurrency(summaries []*UserGroupSummary) []*CurrencyTotal {
+	totals := []*CurrencyTotal{}
+	byCurrency := make(map[string]*CurrencyTotal)
+	for _, summary := range summaries {
+		if summary.Error != "" {
+			continue
+		}
+		total, ok := byCurrency[summary.Currency]
+		if !ok {
+			total = &CurrencyTotal{Currency: summary.Currency}
+			byCurrency[summary.Currency] = total
+			totals = append(totals, total)
+		}
+
+		if summary.NetBalance > 0 {
+			total.OwedToUser += summary.NetBalance
+		} else {
+			total.UserOwes -= summary.NetBalance
+		}
+	}
+
+	for _, total := range totals {
+		total.OwedToUser = roundToMinorUnits(total.OwedToUser, total.Currency)
+		total.UserOwes = roundToMinorUnits(total.UserOwes, total.Currency)
+		total.NetBalance = roundToMinorUnits(total.OwedToUser-total.UserOwes, total.Currency)
+	}
+	return totals
+}
+
+// GetCombinedSettlement nets several groups with the same people into one settlement.
+// Input: CombinedSettlementRequest with the group slugs and optional aliases
+// Output: CombinedSettlementResponse with each person's combined net balance and the simplified transfers
+// Description: Participants are the same person when their names match case-insensitively, ignoring
+// surrounding whitespace, unless an alias names them differently. Each person's balances from all groups
+// are summed and simplified like a single group's. All groups must use the same currency. Nothing is
+// persisted; each group's own debts are unchanged
+func (s *debtService) GetCombinedSettlement(ctx context.Context, req *CombinedSettlementRequest) (*CombinedSettlementResponse, error) {
+	if len(req.GroupSlugs) == 0 {
+		return nil, fmt.Errorf("invalid combined settlement: at least one group is required")
+	}
+
+	groups := make([]*database.Group, 0, len(req.GroupSlugs))
+	seen := make(map[string]bool)
+	for _, slug := range req.GroupSlugs {
+		if seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		group, err := getGroupBySlug(s.db, slug)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, slug)
+		}
+		if len(groups) > 0 && group.Currency != groups[0].Currency {
+			return nil, fmt.Errorf("invalid combined settlement: groups use different currencies (%s and %s)", groups[0].Currency, group.Currency)
+		}
+		groups = append(groups, group)
+	}
+	currency := groups[0].Currency
+
+	// aliases[slug][participant ID] is the person name an alias gives that participant
+	aliases := make(map[string]map[uint]string)
+	for _, alias := range req.Aliases {
+		name := strings.TrimSpace(alias.Name)
+		if !seen[alias.GroupUrlSlug] {
+			return nil, fmt.Errorf("invalid combined settlement: alias for group %q, which is not being combined", alias.GroupUrlSlug)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid combined settlement: alias for participant %d has no name", alias.ParticipantId)
+		}
+		if aliases[alias.GroupUrlSlug] == nil {
+			aliases[alias.GroupUrlSlug] = make(map[uint]string)
+		}
+		aliases[alias.GroupUrlSlug][uint(alias.ParticipantId)] = name
+	}
+
+	// People are keyed by their lowercased name; the first spelling seen is the one shown
+	type person struct {
+		name       string
+		balance    float64
+		groupSlugs []string
+	}
+	people := make(map[string]*person)
+	for _, group := range groups {
+		balances, participants, err := CalculateBalances(s.db, group.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate balances for group %s: %v", group.URLSlug, err)
+		}
+		found := make(map[uint]bool, len(participants))
+		for _, participant := range participants {
+			found[participant.ID] = true
+			name := strings.TrimSpace(participant.Name)
+			if alias, ok := aliases[group.URLSlug][participant.ID]; ok {
+				name = alias
+			}
+			key := strings.ToLower(name)
+			p, ok := people[key]
+			if !ok {
+				p = &person{name: name}
+				people[key] = p
+			}
+			p.balance += balances[participant.ID]
+			if len(p.groupSlugs) == 0 || p.groupSlugs[len(p.groupSlugs)-1] != group.URLSlug {
+				p.groupSlugs = append(p.groupSlugs, group.URLSlug)
+			}
+		}
+		for participantID := range aliases[group.URLSlug] {
+			if !found[participantID] {
+				return nil, fmt.Errorf("invalid combined settlement: participant %d is not in group %q", participantID, group.URLSlug)
+			}
+		}
+	}
+
+	// Number people in name order so the settlement doesn't depend on map iteration order
+	keys := make([]string, 0, len(people))
+	for key := range people {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	balances := make(map[uint]float64, len(keys))
+	positions := make([]*CombinedPosition, len(keys))
+	for i, key := range keys {
+		p := people[key]
+		balances[uint(i+1)] = p.balance
+		positions[i] = &CombinedPosition{
+			Name:       p.name,
+			NetBalance: roundToMinorUnits(p.balance, currency),
+			GroupSlugs: p.groupSlugs,
+		}
+	}
+
+	debts := simplifyBalances(0, balances, AmountThreshold(currency))
+	transfers := make([]*CombinedTransfer, len(debts))
+	for i, debt := range debts {
+		transfers[i] = &CombinedTransfer{
+			FromName: positions[debt.DebtorID-1].Name,
+			ToName:   positions[debt.LenderID-1].Name,
+			Amount:   roundToMinorUnits(debt.DebtAmount, currency),
+		}
+	}
+
+	return &CombinedSettlementResponse{
+		Currency:  currency,
+		People:    positions,
+		Transfers: transfers,
 	}, nil
 }
 
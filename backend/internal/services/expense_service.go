@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 
 	"freesplit/internal/database"
 
@@ -21,19 +24,110 @@ func NewExpenseService(db *gorm.DB) ExpenseService {
 	return &expenseService{db: db}
 }
 
+// defaultMaxExpenseNameLength is the longest expense name accepted, in characters
+const defaultMaxExpenseNameLength = 100
+
+var maxExpenseNameLength atomic.Int32
+
+func init() {
+	maxExpenseNameLength.Store(defaultMaxExpenseNameLength)
+}
+
+// SetMaxExpenseNameLength sets the longest expense name (in characters) accepted by
+// CreateExpense and UpdateExpense. Meant to be called once at startup.
+func SetMaxExpenseNameLength(n int) {
+	maxExpenseNameLength.Store(int32(n))
+}
+
+// MaxExpenseNameLength returns the current expense name length limit.
+func MaxExpenseNameLength() int {
+	return int(maxExpenseNameLength.Load())
+}
+
+// allParticipantSplits expands an expense's "all participants" flag into one split per current group member.
+// Input: gorm.DB, the expense being created and the splits sent with it
+// Output: a split for every participant of the group, ordered by ID, and error
+// Description: Only equal splits can be expanded, since every other split type needs per-person values;
+// an empty split type becomes "equal". Sending splits as well is rejected as ambiguous
+func allParticipantSplits(db *gorm.DB, expense *Expense, splits []*Split) ([]*Split, error) {
+	if len(splits) > 0 {
+		return nil, fmt.Errorf("invalid expense: send either splits or all_participants, not both")
+	}
+	switch expense.SplitType {
+	case "":
+		expense.SplitType = "equal"
+	case "equal", "equal_excluding_payer":
+	default:
+		return nil, fmt.Errorf("invalid expense: all_participants only works with \"equal\" or \"equal_excluding_payer\" splits")
+	}
+
+	var participants []database.Participant
+	if err := db.Where("group_id = ?", expense.GroupId).Order("id").Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("invalid expense: the group has no participants to split with")
+	}
+
+	expanded := make([]*Split, len(participants))
+	for i, participant := range participants {
+		expanded[i] = &Split{GroupId: expense.GroupId, ParticipantId: int32(participant.ID)}
+	}
+	return expanded, nil
+}
+
+// rotatingRemainderParticipant picks whose turn it is to absorb rounding differences in a group that rotates them.
+// Input: gorm.DB, the group, and the expense being created and its splits
+// Output: the participant ID (0 to fall back to the default rule), the group's next cursor and error
+// Description: Walks the group's participants in ID order starting at the group's cursor and picks the first
+// one sharing the expense (for "mixed" splits, the remainder), so over successive expenses everyone takes a turn
+func rotatingRemainderParticipant(db *gorm.DB, group *database.Group, expense *Expense, splits []*Split) (int32, int, error) {
+	var participants []database.Participant
+	if err := db.Where("group_id = ?", group.ID).Order("id").Find(&participants).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	sharing := make(map[int32]bool, len(splits))
+	for _, split := range splits {
+		// Exact amounts of a mixed split absorb no rounding
+		if expense.SplitType != "mixed" || split.SharesRemainder {
+			sharing[split.ParticipantId] = true
+		}
+	}
+	for k := range participants {
+		position := (group.RemainderCursor + k) % len(participants)
+		if id := int32(participants[position].ID); sharing[id] {
+			return id, (position + 1) % len(participants), nil
+		}
+	}
+	return 0, group.RemainderCursor, nil
+}
+
 // GetExpensesByGroup retrieves all expenses for a specific group ordered by creation date.
-// Input: GetExpensesByGroupRequest containing GroupId
+// Input: GetExpensesByGroupRequest containing GroupId and optionally IncludeSplits
 // Output: GetExpensesByGroupResponse with list of expenses
-// Description: Fetches all expenses for a group in descending order by creation date
+// Description: Fetches all expenses for a group in descending order by creation date; with IncludeSplits
+// the splits are preloaded and embedded so clients don't need a follow-up call per expense
 func (s *expenseService) GetExpensesByGroup(ctx context.Context, req *GetExpensesByGroupRequest) (*GetExpensesByGroupResponse, error) {
+	query := s.db.Where("group_id = ?", req.GroupId).Order("created_at DESC")
+	if req.IncludeSplits {
+		query = query.Preload("Splits")
+	}
+
 	var expenses []database.Expense
-	if err := s.db.Where("group_id = ?", req.GroupId).Order("created_at DESC").Find(&expenses).Error; err != nil {
+	if err := query.Find(&expenses).Error; err != nil {
 		return nil, fmt.Errorf("failed to get expenses: %v", err)
 	}
 
 	responseExpenses := make([]*Expense, len(expenses))
 	for i, e := range expenses {
 		responseExpenses[i] = ExpenseFromDB(&e)
+		if req.IncludeSplits {
+			responseExpenses[i].Splits = make([]*Split, len(e.Splits))
+			for j, split := range e.Splits {
+				responseExpenses[i].Splits[j] = SplitFromDB(&split)
+			}
+		}
 	}
 
 	return &GetExpensesByGroupResponse{
@@ -41,6 +135,43 @@ func (s *expenseService) GetExpensesByGroup(ctx context.Context, req *GetExpense
 	}, nil
 }
 
+// GetParticipantPaidExpenses retrieves the expenses a participant paid for in a group.
+// Input: GetParticipantPaidExpensesRequest with UrlSlug and ParticipantId
+// Output: GetParticipantPaidExpensesResponse with expenses newest first and their total cost
+// Description: Returns only expenses whose payer is the participant; the total is rounded to the group currency
+func (s *expenseService) GetParticipantPaidExpenses(ctx context.Context, req *GetParticipantPaidExpensesRequest) (*GetParticipantPaidExpensesResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getGroupParticipant(s.db, group.ID, req.ParticipantId); err != nil {
+		return nil, err
+	}
+
+	var expenses []database.Expense
+	if err := s.db.Where("group_id = ? AND payer_id = ?", group.ID, req.ParticipantId).Order("created_at DESC").Find(&expenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get paid expenses: %v", err)
+	}
+
+	responseExpenses := make([]*Expense, len(expenses))
+	var total float64
+	for i, e := range expenses {
+		responseExpenses[i] = ExpenseFromDB(&e)
+		total += e.Cost
+	}
+
+	return &GetParticipantPaidExpensesResponse{
+		Expenses: responseExpenses,
+		Total:    roundToMinorUnits(total, group.Currency),
+		Currency: group.Currency,
+	}, nil
+}
+
+// GetExpenseWithSplits retrieves an expense with its splits.
+// Input: GetExpenseWithSplitsRequest with ExpenseId
+// Output: GetExpenseWithSplitsResponse with the expense, payer name and splits with participant names
+// Description: Resolves names with joins; participants that were deleted show as deletedParticipantName
 func (s *expenseService) GetExpenseWithSplits(ctx context.Context, req *GetExpenseWithSplitsRequest) (*GetExpenseWithSplitsResponse, error) {
 	var expense database.Expense
 	if err := s.db.First(&expense, req.ExpenseId).Error; err != nil {
@@ -50,19 +181,88 @@ func (s *expenseService) GetExpenseWithSplits(ctx context.Context, req *GetExpen
 		return nil, fmt.Errorf("failed to get expense: %v", err)
 	}
 
-	var splits []database.Split
-	if err := s.db.Where("expense_id = ?", req.ExpenseId).Find(&splits).Error; err != nil {
+	var payerName string
+	err := s.db.Table("expenses").
+		Select("COALESCE(payer.name, ?)", deletedParticipantName).
+		Joins("LEFT JOIN participants as payer ON expenses.payer_id = payer.id").
+		Where("expenses.id = ?", expense.ID).
+		Scan(&payerName).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payer name: %v", err)
+	}
+
+	var splits []struct {
+		database.Split
+		ParticipantName string
+	}
+	err = s.db.Table("splits").
+		Select("splits.*, COALESCE(participants.name, ?) as participant_name", deletedParticipantName).
+		Joins("LEFT JOIN participants ON splits.participant_id = participants.id").
+		Where("splits.expense_id = ?", expense.ID).
+		Order("splits.id").
+		Scan(&splits).Error
+	if err != nil {
 		return nil, fmt.Errorf("failed to get splits: %v", err)
 	}
 
 	responseSplits := make([]*Split, len(splits))
-	for i, s := range splits {
-		responseSplits[i] = SplitFromDB(&s)
+	for i := range splits {
+		responseSplits[i] = SplitFromDB(&splits[i].Split)
+		responseSplits[i].ParticipantName = splits[i].ParticipantName
 	}
 
 	return &GetExpenseWithSplitsResponse{
-		Expense: ExpenseFromDB(&expense),
-		Splits:  responseSplits,
+		Expense:   ExpenseFromDB(&expense),
+		PayerName: payerName,
+		Splits:    responseSplits,
+	}, nil
+}
+
+// GetExpenseDetail retrieves everything needed to show or print a single expense.
+// Input: GetExpenseDetailRequest with ExpenseId
+// Output: GetExpenseDetailResponse with the expense, payer and group names, currency and named shares
+// Description: Resolves names with joins; participants that were deleted show as deletedParticipantName
+func (s *expenseService) GetExpenseDetail(ctx context.Context, req *GetExpenseDetailRequest) (*GetExpenseDetailResponse, error) {
+	var expense database.Expense
+	if err := s.db.First(&expense, req.ExpenseId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("expense not found")
+		}
+		return nil, fmt.Errorf("failed to get expense: %v", err)
+	}
+
+	var header struct {
+		PayerName string
+		GroupName string
+		Currency  string
+	}
+	err := s.db.Table("expenses").
+		Select("COALESCE(payer.name, ?) as payer_name, groups.name as group_name, groups.currency", deletedParticipantName).
+		Joins("LEFT JOIN participants as payer ON expenses.payer_id = payer.id").
+		Joins("JOIN groups ON expenses.group_id = groups.id").
+		Where("expenses.id = ?", expense.ID).
+		Scan(&header).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense names: %v", err)
+	}
+
+	var shares []*ExpenseShare
+	err = s.db.Table("splits").
+		Select("splits.participant_id, COALESCE(participants.name, ?) as participant_name, splits.split_amount as amount", deletedParticipantName).
+		Joins("LEFT JOIN participants ON splits.participant_id = participants.id").
+		Where("splits.expense_id = ?", expense.ID).
+		Order("splits.id").
+		Scan(&shares).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense shares: %v", err)
+	}
+
+	return &GetExpenseDetailResponse{
+		Expense:   ExpenseFromDB(&expense),
+		PayerName: header.PayerName,
+		GroupName: header.GroupName,
+		Currency:  header.Currency,
+		Shares:    shares,
 	}, nil
 }
 
@@ -109,6 +309,52 @@ func (s *expenseService) GetSplitsByGroup(ctx context.Context, req *GetSplitsByG
 // Output: CreateExpenseResponse with created expense and splits
 // Description: Creates expense, saves splits, and recalculates simplified debts for the group
 func (s *expenseService) CreateExpense(ctx context.Context, req *CreateExpenseRequest) (*CreateExpenseResponse, error) {
+	// Without this check the expense would be stored for a group that doesn't exist
+	group, err := getGroupByID(s.db, uint(req.Expense.GroupId))
+	if err != nil {
+		return nil, err
+	}
+	currency := group.Currency
+
+	name, err := normalizeExpenseName(req.Expense.Name)
+	if err != nil {
+		return nil, err
+	}
+	req.Expense.Name = name
+
+	if req.AllParticipants && req.Expense.Shared() {
+		if req.Splits, err = allParticipantSplits(s.db, req.Expense, req.Splits); err != nil {
+			return nil, err
+		}
+	}
+
+	if !req.Expense.Shared() {
+		req.Splits = personalSplits(req.Expense)
+	} else if req.Expense.SplitType == "equal_excluding_payer" {
+		if req.Splits, err = splitsWithoutPayer(req.Expense, req.Splits); err != nil {
+			return nil, err
+		}
+	}
+
+	// Groups that rotate the rounding remainder pick whose turn it is unless the request chose someone
+	remainderID, nextCursor := req.RemainderParticipantId, group.RemainderCursor
+	if group.RotateRemainder && remainderID == 0 && req.Expense.Shared() {
+		if remainderID, nextCursor, err = rotatingRemainderParticipant(s.db, group, req.Expense, req.Splits); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compute split amounts for server-side split types before touching the database
+	opts := splitOptions{Currency: currency, RemainderParticipantId: remainderID, Tolerance: req.SplitTolerance}
+	warnings, err := applySplitType(req.Expense, req.Splits, opts)
+	if err != nil {
+		return nil, err
+	}
+	isShared := req.Expense.Shared()
+
+	release := acquireRecalculationSlot()
+	defer release()
+
 	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -121,9 +367,13 @@ func (s *expenseService) CreateExpense(ctx context.Context, req *CreateExpenseRe
 	expense := database.Expense{
 		Name:      req.Expense.Name,
 		Cost:      req.Expense.Cost,
+		Subtotal:  req.Expense.Subtotal,
+		Tax:       req.Expense.Tax,
+		Tip:       req.Expense.Tip,
 		Emoji:     req.Expense.Emoji,
 		PayerID:   uint(req.Expense.PayerId),
 		SplitType: req.Expense.SplitType,
+		IsShared:  &isShared,
 		GroupID:   uint(req.Expense.GroupId),
 	}
 
@@ -134,12 +384,16 @@ func (s *expenseService) CreateExpense(ctx context.Context, req *CreateExpenseRe
 
 	// Create splits
 	var splits []database.Split
+	// Splits always belong to this expense and its group, whatever IDs the client sent
 	for _, split := range req.Splits {
 		splitRecord := database.Split{
-			GroupID:       uint(split.GroupId),
-			ExpenseID:     expense.ID,
-			ParticipantID: uint(split.ParticipantId),
-			SplitAmount:   split.SplitAmount,
+			GroupID:         expense.GroupID,
+			ExpenseID:       expense.ID,
+			ParticipantID:   uint(split.ParticipantId),
+			SplitAmount:     split.SplitAmount,
+			Adjustment:      split.Adjustment,
+			Weight:          split.Weight,
+			SharesRemainder: split.SharesRemainder,
 		}
 		splits = append(splits, splitRecord)
 	}
@@ -149,6 +403,18 @@ func (s *expenseService) CreateExpense(ctx context.Context, req *CreateExpenseRe
 		return nil, fmt.Errorf("failed to create splits: %v", err)
 	}
 
+	if nextCursor != group.RemainderCursor {
+		if err := tx.Model(&database.Group{}).Where("id = ?", group.ID).Update("remainder_cursor", nextCursor).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to advance remainder rotation: %v", err)
+		}
+	}
+
+	if err := recordActivity(tx, expense.GroupID, ActionExpenseCreated, expense.ID, fmt.Sprintf("Added expense %q (%.2f)", expense.Name, expense.Cost)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Calculate and update simplified debts
 	if err := s.updateDebts(tx, expense.GroupID); err != nil {
 		tx.Rollback()
@@ -166,8 +432,97 @@ func (s *expenseService) CreateExpense(ctx context.Context, req *CreateExpenseRe
 	}
 
 	return &CreateExpenseResponse{
-		Expense: ExpenseFromDB(&expense),
-		Splits:  responseSplits,
+		Expense:  ExpenseFromDB(&expense),
+		Splits:   responseSplits,
+		Warnings: warnings,
+	}, nil
+}
+
+// ValidateSplit checks a proposed split the way CreateExpense would, without saving anything.
+// Input: ValidateSplitRequest with UrlSlug, the cost (and optional breakdown), split type and splits
+// Output: ValidateSplitResponse with every problem found and, when valid, the amounts the server would compute
+// Description: Lets the frontend show computed shares live. Participants and payer must belong to the group;
+// the split math is checked by the same code that creates expenses. Returns an error only when the group
+// can't be found or loaded
+func (s *expenseService) ValidateSplit(ctx context.Context, req *ValidateSplitRequest) (*ValidateSplitResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	inGroup := make(map[int32]bool, len(participants))
+	for _, p := range participants {
+		inGroup[int32(p.ID)] = true
+	}
+
+	errs := []string{}
+	if req.Cost <= 0 {
+		errs = append(errs, "cost must be greater than 0")
+	}
+	if req.PayerId != 0 && !inGroup[req.PayerId] {
+		errs = append(errs, fmt.Sprintf("payer %d is not a participant of the group", req.PayerId))
+	}
+	for _, split := range req.Splits {
+		if !inGroup[split.ParticipantId] {
+			errs = append(errs, fmt.Sprintf("participant %d is not a participant of the group", split.ParticipantId))
+		}
+	}
+
+	// Work on copies so the computation can rewrite amounts freely
+	expense := &Expense{
+		Cost:      req.Cost,
+		Subtotal:  req.Subtotal,
+		Tax:       req.Tax,
+		Tip:       req.Tip,
+		PayerId:   req.PayerId,
+		SplitType: req.SplitType,
+		GroupId:   int32(group.ID),
+	}
+	splits := make([]*Split, len(req.Splits))
+	for i, split := range req.Splits {
+		splits[i] = &Split{
+			ParticipantId:   split.ParticipantId,
+			SplitAmount:     split.SplitAmount,
+			Adjustment:      split.Adjustment,
+			Weight:          split.Weight,
+			SharesRemainder: split.SharesRemainder,
+		}
+	}
+	if !expense.Shared() {
+		splits = personalSplits(expense)
+	} else if expense.SplitType == "equal_excluding_payer" {
+		splits, err = splitsWithoutPayer(expense, splits)
+	}
+	if err == nil && len(errs) == 0 {
+		// Preview the rotation without advancing it
+		remainderID := req.RemainderParticipantId
+		if group.RotateRemainder && remainderID == 0 && expense.Shared() {
+			remainderID, _, err = rotatingRemainderParticipant(s.db, group, expense, splits)
+		}
+		if err == nil {
+			opts := splitOptions{Currency: group.Currency, RemainderParticipantId: remainderID, Tolerance: req.SplitTolerance}
+			_, err = applySplitType(expense, splits, opts)
+		}
+	}
+	if err != nil {
+		errs = append(errs, strings.TrimPrefix(err.Error(), "invalid expense: "))
+	}
+
+	computed := []*ComputedSplitAmount{}
+	if len(errs) == 0 {
+		for _, split := range splits {
+			computed = append(computed, &ComputedSplitAmount{ParticipantId: split.ParticipantId, SplitAmount: split.SplitAmount})
+		}
+	}
+
+	return &ValidateSplitResponse{
+		Valid:           len(errs) == 0,
+		Errors:          errs,
+		ComputedAmounts: computed,
 	}, nil
 }
 
@@ -176,6 +531,52 @@ func (s *expenseService) CreateExpense(ctx context.Context, req *CreateExpenseRe
 // Output: UpdateExpenseResponse with updated expense and splits
 // Description: Updates expense, replaces splits, and recalculates simplified debts
 func (s *expenseService) UpdateExpense(ctx context.Context, req *UpdateExpenseRequest) (*UpdateExpenseResponse, error) {
+	name, err := normalizeExpenseName(req.Expense.Name)
+	if err != nil {
+		return nil, err
+	}
+	req.Expense.Name = name
+
+	// Save would insert a missing expense, so make sure it exists and stays in its group:
+	// moving it would leave the old group's debts stale and may reference participants the new group lacks
+	var existing database.Expense
+	if err := s.db.First(&existing, req.Expense.Id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("expense not found")
+		}
+		return nil, fmt.Errorf("failed to get expense: %v", err)
+	}
+	if existing.GroupID != uint(req.Expense.GroupId) {
+		return nil, fmt.Errorf("invalid expense: expense %d belongs to group %d and cannot be moved to group %d", existing.ID, existing.GroupID, req.Expense.GroupId)
+	}
+	if err := ensureExpenseUnlocked(&existing); err != nil {
+		return nil, err
+	}
+
+	currency, err := groupCurrency(s.db, uint(req.Expense.GroupId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group currency: %v", err)
+	}
+
+	if !req.Expense.Shared() {
+		req.Splits = personalSplits(req.Expense)
+	} else if req.Expense.SplitType == "equal_excluding_payer" {
+		if req.Splits, err = splitsWithoutPayer(req.Expense, req.Splits); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compute split amounts for server-side split types before touching the database
+	opts := splitOptions{Currency: currency, RemainderParticipantId: req.RemainderParticipantId, Tolerance: req.SplitTolerance}
+	warnings, err := applySplitType(req.Expense, req.Splits, opts)
+	if err != nil {
+		return nil, err
+	}
+	isShared := req.Expense.Shared()
+
+	release := acquireRecalculationSlot()
+	defer release()
+
 	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -189,10 +590,16 @@ func (s *expenseService) UpdateExpense(ctx context.Context, req *UpdateExpenseRe
 		ID:        uint(req.Expense.Id),
 		Name:      req.Expense.Name,
 		Cost:      req.Expense.Cost,
+		Subtotal:  req.Expense.Subtotal,
+		Tax:       req.Expense.Tax,
+		Tip:       req.Expense.Tip,
 		Emoji:     req.Expense.Emoji,
 		PayerID:   uint(req.Expense.PayerId),
 		SplitType: req.Expense.SplitType,
+		IsShared:  &isShared,
 		GroupID:   uint(req.Expense.GroupId),
+		// Save writes every column, so keep the original creation time (historical debts are computed from it)
+		CreatedAt: existing.CreatedAt,
 	}
 
 	if err := tx.Save(&expense).Error; err != nil {
@@ -208,12 +615,16 @@ func (s *expenseService) UpdateExpense(ctx context.Context, req *UpdateExpenseRe
 
 	// Create new splits
 	var splits []database.Split
+	// Splits always belong to this expense and its group, whatever IDs the client sent
 	for _, split := range req.Splits {
 		splitRecord := database.Split{
-			GroupID:       uint(split.GroupId),
-			ExpenseID:     expense.ID,
-			ParticipantID: uint(split.ParticipantId),
-			SplitAmount:   split.SplitAmount,
+			GroupID:         expense.GroupID,
+			ExpenseID:       expense.ID,
+			ParticipantID:   uint(split.ParticipantId),
+			SplitAmount:     split.SplitAmount,
+			Adjustment:      split.Adjustment,
+			Weight:          split.Weight,
+			SharesRemainder: split.SharesRemainder,
 		}
 		splits = append(splits, splitRecord)
 	}
@@ -223,6 +634,11 @@ func (s *expenseService) UpdateExpense(ctx context.Context, req *UpdateExpenseRe
 		return nil, fmt.Errorf("failed to create splits: %v", err)
 	}
 
+	if err := recordActivity(tx, expense.GroupID, ActionExpenseUpdated, expense.ID, fmt.Sprintf("Updated expense %q (%.2f)", expense.Name, expense.Cost)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Calculate and update simplified debts
 	if err := s.updateDebts(tx, expense.GroupID); err != nil {
 		tx.Rollback()
@@ -240,16 +656,165 @@ func (s *expenseService) UpdateExpense(ctx context.Context, req *UpdateExpenseRe
 	}
 
 	return &UpdateExpenseResponse{
+		Expense:  ExpenseFromDB(&expense),
+		Splits:   responseSplits,
+		Warnings: warnings,
+	}, nil
+}
+
+// ResplitExpense splits an existing expense equally among a new set of participants.
+// Input: ResplitExpenseRequest with ExpenseId and the participants who should share it
+// Output: ResplitExpenseResponse with the expense and its new splits
+// Description: Keeps cost and payer, switches the expense to an equal split, replaces its splits and
+// recalculates debts in one transaction. Every participant must belong to the expense's group
+func (s *expenseService) ResplitExpense(ctx context.Context, req *ResplitExpenseRequest) (*ResplitExpenseResponse, error) {
+	if len(req.ParticipantIds) == 0 {
+		return nil, fmt.Errorf("invalid expense: at least one participant must share the expense")
+	}
+
+	var expense database.Expense
+	if err := s.db.First(&expense, req.ExpenseId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("expense not found")
+		}
+		return nil, fmt.Errorf("failed to get expense: %v", err)
+	}
+	if err := ensureExpenseUnlocked(&expense); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int32]bool)
+	splits := make([]*Split, len(req.ParticipantIds))
+	for i, participantID := range req.ParticipantIds {
+		if seen[participantID] {
+			return nil, fmt.Errorf("invalid expense: participant %d is listed twice", participantID)
+		}
+		seen[participantID] = true
+
+		if _, err := getGroupParticipant(s.db, expense.GroupID, participantID); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return nil, fmt.Errorf("invalid expense: participant %d is not in the group", participantID)
+			}
+			return nil, err
+		}
+
+		splits[i] = &Split{
+			GroupId:       int32(expense.GroupID),
+			ExpenseId:     int32(expense.ID),
+			ParticipantId: participantID,
+		}
+	}
+
+	currency, err := groupCurrency(s.db, expense.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group currency: %v", err)
+	}
+
+	expense.SplitType = "equal"
+	isShared := true
+	expense.IsShared = &isShared
+	if err := applyEqualSplit(ExpenseFromDB(&expense), splits, splitOptions{Currency: currency}); err != nil {
+		return nil, err
+	}
+
+	splitRecords := make([]database.Split, len(splits))
+	for i, split := range splits {
+		splitRecords[i] = database.Split{
+			GroupID:       expense.GroupID,
+			ExpenseID:     expense.ID,
+			ParticipantID: uint(split.ParticipantId),
+			SplitAmount:   split.SplitAmount,
+		}
+	}
+
+	release := acquireRecalculationSlot()
+	defer release()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&expense).Error; err != nil {
+			return fmt.Errorf("failed to update expense: %v", err)
+		}
+		if err := tx.Where("expense_id = ?", expense.ID).Delete(&database.Split{}).Error; err != nil {
+			return fmt.Errorf("failed to delete existing splits: %v", err)
+		}
+		if err := tx.Create(&splitRecords).Error; err != nil {
+			return fmt.Errorf("failed to create splits: %v", err)
+		}
+		description := fmt.Sprintf("Re-split expense %q among %d participants", expense.Name, len(splitRecords))
+		if err := recordActivity(tx, expense.GroupID, ActionExpenseUpdated, expense.ID, description); err != nil {
+			return err
+		}
+		if err := s.updateDebts(tx, expense.GroupID); err != nil {
+			return fmt.Errorf("failed to calculate debts: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	responseSplits := make([]*Split, len(splitRecords))
+	for i, split := range splitRecords {
+		responseSplits[i] = SplitFromDB(&split)
+	}
+
+	return &ResplitExpenseResponse{
 		Expense: ExpenseFromDB(&expense),
 		Splits:  responseSplits,
 	}, nil
 }
 
+// SetExpenseLocked locks or unlocks an expense against edits.
+// Input: SetExpenseLockedRequest with ExpenseId and the desired Locked state
+// Output: SetExpenseLockedResponse with the updated expense
+// Description: Locked expenses can't be updated, re-split, deleted or undone until they are unlocked again
+func (s *expenseService) SetExpenseLocked(ctx context.Context, req *SetExpenseLockedRequest) (*SetExpenseLockedResponse, error) {
+	var expense database.Expense
+	if err := s.db.First(&expense, req.ExpenseId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("expense not found")
+		}
+		return nil, fmt.Errorf("failed to get expense: %v", err)
+	}
+
+	if expense.Locked == req.Locked {
+		return &SetExpenseLockedResponse{Expense: ExpenseFromDB(&expense)}, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&expense).Update("locked", req.Locked).Error; err != nil {
+			return fmt.Errorf("failed to update expense: %v", err)
+		}
+		verb := "Unlocked"
+		if req.Locked {
+			verb = "Locked"
+		}
+		return recordActivity(tx, expense.GroupID, ActionExpenseUpdated, expense.ID, fmt.Sprintf("%s expense %q", verb, expense.Name))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetExpenseLockedResponse{Expense: ExpenseFromDB(&expense)}, nil
+}
+
+// ensureExpenseUnlocked checks that an expense may still be changed.
+// Input: the expense
+// Output: error when the expense is locked
+func ensureExpenseUnlocked(expense *database.Expense) error {
+	if expense.Locked {
+		return fmt.Errorf("expense %d is locked; unlock it before changing it", expense.ID)
+	}
+	return nil
+}
+
 // DeleteExpense deletes an expense and its splits, then recalculates group debts.
 // Input: DeleteExpenseRequest with expense ID
 // Output: error if deletion fails
 // Description: Removes expense, deletes associated splits, and recalculates debts
 func (s *expenseService) DeleteExpense(ctx context.Context, req *DeleteExpenseRequest) error {
+	release := acquireRecalculationSlot()
+	defer release()
+
 	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -267,6 +832,10 @@ func (s *expenseService) DeleteExpense(ctx context.Context, req *DeleteExpenseRe
 		}
 		return fmt.Errorf("failed to get expense: %v", err)
 	}
+	if err := ensureExpenseUnlocked(&expense); err != nil {
+		tx.Rollback()
+		return err
+	}
 
 	// Delete splits
 	if err := tx.Where("expense_id = ?", req.ExpenseId).Delete(&database.Split{}).Error; err != nil {
@@ -280,6 +849,11 @@ func (s *expenseService) DeleteExpense(ctx context.Context, req *DeleteExpenseRe
 		return fmt.Errorf("failed to delete expense: %v", err)
 	}
 
+	if err := recordActivity(tx, expense.GroupID, ActionExpenseDeleted, expense.ID, fmt.Sprintf("Deleted expense %q (%.2f)", expense.Name, expense.Cost)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Calculate and update simplified debts
 	if err := s.updateDebts(tx, expense.GroupID); err != nil {
 		tx.Rollback()
@@ -293,148 +867,92 @@ func (s *expenseService) DeleteExpense(ctx context.Context, req *DeleteExpenseRe
 	return nil
 }
 
-// calculateSimplifiedDebts implements the debt simplification algorithm
-func (s *expenseService) calculateSimplifiedDebts(tx *gorm.DB, groupID uint) error {
-	// Get all participants in the group
-	var participants []database.Participant
-	if err := tx.Where("group_id = ?", groupID).Find(&participants).Error; err != nil {
-		return err
-	}
-
-	// Calculate net balances for each participant
-	balances := make(map[uint]float64)
-	for _, participant := range participants {
-		balances[participant.ID] = 0
+// DeleteExpenses deletes several expenses of a group and their splits at once.
+// Input: DeleteExpensesRequest with UrlSlug and the expense IDs
+// Output: DeleteExpensesResponse with the number of expenses deleted, and error
+// Description: Every ID must be an unlocked expense of the group, otherwise nothing is deleted.
+// Everything happens in one transaction with a single debt recalculation
+func (s *expenseService) DeleteExpenses(ctx context.Context, req *DeleteExpensesRequest) (*DeleteExpensesResponse, error) {
+	if len(req.ExpenseIds) == 0 {
+		return nil, fmt.Errorf("invalid expense IDs: list cannot be empty")
+	}
+	seen := make(map[int32]bool, len(req.ExpenseIds))
+	for _, id := range req.ExpenseIds {
+		if seen[id] {
+			return nil, fmt.Errorf("invalid expense IDs: duplicate expense ID %d", id)
+		}
+		seen[id] = true
 	}
 
-	// Get all expenses for the group
-	var expenses []database.Expense
-	if err := tx.Where("group_id = ?", groupID).Find(&expenses).Error; err != nil {
-		return err
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate balances based on expenses and splits
-	for _, expense := range expenses {
-		// Add the full amount to the payer's balance (they paid for it)
-		balances[expense.PayerID] += expense.Cost
-
-		// Get splits for this expense
-		var splits []database.Split
-		if err := tx.Where("expense_id = ?", expense.ID).Find(&splits).Error; err != nil {
-			return err
+	release := acquireRecalculationSlot()
+	defer release()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var expenses []database.Expense
+		if err := tx.Where("id IN ? AND group_id = ?", req.ExpenseIds, group.ID).Find(&expenses).Error; err != nil {
+			return fmt.Errorf("failed to get expenses: %v", err)
 		}
-
-		// Subtract each participant's share from their balance
-		for _, split := range splits {
-			balances[split.ParticipantID] -= split.SplitAmount
+		found := make(map[int32]*database.Expense, len(expenses))
+		for i := range expenses {
+			found[int32(expenses[i].ID)] = &expenses[i]
 		}
-	}
-
-	// Factor in existing debt settlements (paid amounts) BEFORE clearing debts
-	var existingDebts []database.Debt
-	if err := tx.Where("group_id = ?", groupID).Find(&existingDebts).Error; err != nil {
-		return err
-	}
-
-	// Clear existing debts for this group AFTER factoring in paid amounts
-	if err := tx.Where("group_id = ?", groupID).Delete(&database.Debt{}).Error; err != nil {
-		return err
-	}
-
-	// Create creditors and debtors lists
-	var creditors []struct {
-		ID      uint
-		Balance float64
-	}
-	var debtors []struct {
-		ID      uint
-		Balance float64
-	}
-
-	for participantID, balance := range balances {
-		if balance > 0.01 { // They are owed money (creditor)
-			creditors = append(creditors, struct {
-				ID      uint
-				Balance float64
-			}{ID: participantID, Balance: balance})
-		} else if balance < -0.01 { // They owe money (debtor)
-			debtors = append(debtors, struct {
-				ID      uint
-				Balance float64
-			}{ID: participantID, Balance: -balance}) // Make positive for easier calculation
+		// Check in request order so the error names the first bad ID the client sent
+		for _, id := range req.ExpenseIds {
+			expense, ok := found[id]
+			if !ok {
+				return fmt.Errorf("expense %d not found in this group", id)
+			}
+			if err := ensureExpenseUnlocked(expense); err != nil {
+				return err
+			}
 		}
-	}
-
-	// Simplify debts using greedy algorithm
-	creditorIdx := 0
-	debtorIdx := 0
-
-	for creditorIdx < len(creditors) && debtorIdx < len(debtors) {
-		creditor := &creditors[creditorIdx]
-		debtor := &debtors[debtorIdx]
 
-		// Determine the amount to settle
-		settleAmount := creditor.Balance
-		if debtor.Balance < settleAmount {
-			settleAmount = debtor.Balance
+		if err := tx.Where("expense_id IN ?", req.ExpenseIds).Delete(&database.Split{}).Error; err != nil {
+			return fmt.Errorf("failed to delete splits: %v", err)
 		}
-
-		// Create debt record
-		debt := database.Debt{
-			GroupID:    groupID,
-			LenderID:   creditor.ID,
-			DebtorID:   debtor.ID,
-			DebtAmount: settleAmount,
+		if err := tx.Where("id IN ?", req.ExpenseIds).Delete(&database.Expense{}).Error; err != nil {
+			return fmt.Errorf("failed to delete expenses: %v", err)
 		}
-
-		if err := tx.Create(&debt).Error; err != nil {
-			return err
+		for _, expense := range expenses {
+			if err := recordActivity(tx, group.ID, ActionExpenseDeleted, expense.ID, fmt.Sprintf("Deleted expense %q (%.2f)", expense.Name, expense.Cost)); err != nil {
+				return err
+			}
 		}
 
-		// Update balances
-		creditor.Balance -= settleAmount
-		debtor.Balance -= settleAmount
-
-		// Move to next creditor/debtor if current one is settled
-		if creditor.Balance <= 0.01 {
-			creditorIdx++
-		}
-		if debtor.Balance <= 0.01 {
-			debtorIdx++
+		if err := s.updateDebts(tx, group.ID); err != nil {
+			return fmt.Errorf("failed to calculate debts: %v", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &DeleteExpensesResponse{DeletedCount: int32(len(req.ExpenseIds))}, nil
 }
 
-// updateDebts updates debts using the new calculation method and preserves paid amounts.
-// Input: gorm.DB transaction and groupID
-// Output: error if debt calculation fails
-// Description: Calculates new debts, preserves existing paid amounts, and updates database
-func (s *expenseService) updateDebts(tx *gorm.DB, groupID uint) error {
-	// Get existing debts to preserve paid amounts
-	var existingDebts []database.Debt
-	if err := tx.Where("group_id = ?", groupID).Find(&existingDebts).Error; err != nil {
-		return err
+// normalizeExpenseName trims surrounding whitespace from an expense name and checks its length.
+// Input: raw expense name
+// Output: trimmed name and error if it is empty or longer than MaxExpenseNameLength
+func normalizeExpenseName(name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return "", fmt.Errorf("invalid expense: name cannot be empty")
 	}
-
-	// Calculate new debts using the improved algorithm
-	newDebts, err := CalculateNetDebts(tx, groupID)
-	if err != nil {
-		return err
-	}
-
-	// Clear existing debts
-	if err := tx.Where("group_id = ?", groupID).Delete(&database.Debt{}).Error; err != nil {
-		return err
-	}
-
-	// Create new debts
-	for _, debt := range newDebts {
-		if err := tx.Create(&debt).Error; err != nil {
-			return err
-		}
+	if limit := MaxExpenseNameLength(); utf8.RuneCountInString(trimmed) > limit {
+		return "", fmt.Errorf("invalid expense: name cannot be longer than %d characters", limit)
 	}
+	return trimmed, nil
+}
 
-	return nil
+// updateDebts updates debts using the new calculation method.
+// Input: gorm.DB transaction and groupID
+// Output: error if debt calculation fails
+// Description: Delegates to recalculateDebts, which rewrites the group's debts within the transaction
+func (s *expenseService) updateDebts(tx *gorm.DB, groupID uint) error {
+	return recalculateDebts(tx, groupID)
 }
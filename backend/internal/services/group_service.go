@@ -3,8 +3,15 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha1"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"freesplit/internal/database"
 
@@ -23,13 +30,22 @@ func NewGroupService(db *gorm.DB) GroupService {
 	return &groupService{db: db}
 }
 
-// GetGroup retrieves a group by URL slug with all participants and expenses.
-// Input: GetGroupRequest with UrlSlug
+// GetGroup retrieves a group by URL slug or ID with all participants and expenses.
+// Input: GetGroupRequest with either UrlSlug or GroupId
 // Output: GetGroupResponse with group data including participants and expenses
-// Description: Fetches group by URL slug and preloads all related participants and expenses
+// Description: Fetches group by URL slug (or numeric ID) and preloads all related participants and expenses
 func (s *groupService) GetGroup(ctx context.Context, req *GetGroupRequest) (*GetGroupResponse, error) {
+	query := s.db.Preload("Participants").Preload("Expenses")
+	if req.UrlSlug != "" {
+		query = query.Where("url_slug = ?", req.UrlSlug)
+	} else if req.GroupId > 0 {
+		query = query.Where("id = ?", req.GroupId)
+	} else {
+		return nil, fmt.Errorf("either group_id or url_slug must be provided")
+	}
+
 	var group database.Group
-	if err := s.db.Preload("Participants").Preload("Expenses").Where("url_slug = ?", req.UrlSlug).First(&group).Error; err != nil {
+	if err := query.First(&group).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("group not found")
 		}
@@ -48,40 +64,162 @@ func (s *groupService) GetGroup(ctx context.Context, req *GetGroupRequest) (*Get
 	}, nil
 }
 
+// GetGroupMeta gets a group's own fields without its participants or expenses.
+// Input: GetGroupMetaRequest with UrlSlug
+// Output: GetGroupMetaResponse with the group
+// Description: Single query with no preloads, for clients that only need the name or currency
+func (s *groupService) GetGroupMeta(ctx context.Context, req *GetGroupMetaRequest) (*GetGroupMetaResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+	return &GetGroupMetaResponse{Group: GroupFromDB(group)}, nil
+}
+
+// GroupExists checks whether a URL slug still resolves to a group.
+// Input: GroupExistsRequest with UrlSlug
+// Output: GroupExistsResponse with Exists flag
+// Description: Uses a COUNT query without preloading participants or expenses
+func (s *groupService) GroupExists(ctx context.Context, req *GroupExistsRequest) (*GroupExistsResponse, error) {
+	var count int64
+	if err := s.db.Model(&database.Group{}).Where("url_slug = ?", req.UrlSlug).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check group: %v", err)
+	}
+	return &GroupExistsResponse{Exists: count > 0}, nil
+}
+
+// GetGroupVersion returns a cheap fingerprint of a group's current state for HTTP caching.
+// Input: GetGroupVersionRequest with UrlSlug
+// Output: GetGroupVersionResponse with an opaque version string
+// Description: Hashes the group's UpdatedAt with the latest participant, expense and payment timestamps.
+// Row counts are included too, so deleting an older row also changes the version
+func (s *groupService) GetGroupVersion(ctx context.Context, req *GetGroupVersionRequest) (*GetGroupVersionResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []interface{}{group.ID, group.UpdatedAt.UnixNano()}
+	for _, model := range []interface{}{&database.Participant{}, &database.Expense{}, &database.Payment{}} {
+		count, latest, err := latestChange(s.db, model, group.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group version: %v", err)
+		}
+		parts = append(parts, count, latest.UnixNano())
+	}
+
+	sum := sha1.Sum([]byte(fmt.Sprint(parts...)))
+	return &GetGroupVersionResponse{Version: hex.EncodeToString(sum[:8])}, nil
+}
+
+// latestChange returns how many rows of a model belong to a group and when the newest one changed
+func latestChange(db *gorm.DB, model interface{}, groupID uint) (int64, time.Time, error) {
+	var count int64
+	if err := db.Model(model).Where("group_id = ?", groupID).Count(&count).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var latest []time.Time
+	if err := db.Model(model).Where("group_id = ?", groupID).
+		Order("updated_at DESC").Limit(1).Pluck("updated_at", &latest).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+	if len(latest) == 0 {
+		return count, time.Time{}, nil
+	}
+	return count, latest[0], nil
+}
+
 // CreateGroup creates a new group with a unique URL slug and initial participants.
 // Input: CreateGroupRequest with Name and initial participants
 // Output: CreateGroupResponse with created group data
-// Description: Creates group, generates unique URL slug, and adds initial participants
+// Description: Creates group, generates unique URL slug, and adds initial participants in one transaction
 func (s *groupService) CreateGroup(ctx context.Context, req *CreateGroupRequest) (*CreateGroupResponse, error) {
-	// Generate URL slug
-	urlSlug, err := generateURLSlug()
+	description, err := normalizeGroupDescription(req.Description)
+	if err != nil {
+		return nil, err
+	}
+	amountDisplay, err := normalizeAmountDisplay(req.AmountDisplay)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate URL slug: %v", err)
+		return nil, err
+	}
+
+	// Normalize participant names before anything is written
+	participantNames := make([]string, len(req.ParticipantNames))
+	for i, rawName := range req.ParticipantNames {
+		name, err := normalizeParticipantName(rawName)
+		if err != nil {
+			return nil, err
+		}
+		participantNames[i] = name
 	}
 
-	// Create group
-	group := database.Group{
-		Name:     req.Name,
-		Currency: req.Currency,
-		URLSlug:  urlSlug,
+	slugStyle := req.SlugStyle
+	if slugStyle == "" {
+		slugStyle = SlugStyle()
+	}
+	if !validSlugStyle(slugStyle) {
+		return nil, fmt.Errorf("invalid slug style %q: must be %q or %q", slugStyle, SlugStyleHex, SlugStyleWords)
 	}
 
-	if err := s.db.Create(&group).Error; err != nil {
-		return nil, fmt.Errorf("failed to create group: %v", err)
+	editToken, editTokenHash, err := generateEditToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate edit token: %v", err)
 	}
 
-	// Create participants
+	// The unique index on url_slug decides whether a slug is free: checking first and inserting after
+	// races with concurrent creates, so the insert is attempted and retried with a new slug on collision
+	var group database.Group
 	var participants []database.Participant
-	for _, name := range req.ParticipantNames {
-		participant := database.Participant{
-			Name:    name,
-			GroupID: group.ID,
+	for attempt := 0; ; attempt++ {
+		if attempt == maxSlugAttempts {
+			return nil, fmt.Errorf("failed to generate URL slug: no unused slug found after %d attempts", maxSlugAttempts)
 		}
-		participants = append(participants, participant)
-	}
 
-	if err := s.db.Create(&participants).Error; err != nil {
-		return nil, fmt.Errorf("failed to create participants: %v", err)
+		urlSlug, err := generateSlug(slugStyle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate URL slug: %v", err)
+		}
+
+		group = database.Group{
+			Name:          req.Name,
+			Currency:      req.Currency,
+			Description:   description,
+			AmountDisplay: amountDisplay,
+			SimplifyDebts: true,
+			URLSlug:       urlSlug,
+			EditTokenHash: editTokenHash,
+		}
+		participants = nil
+
+		// Create the group and its participants together so a failed participant insert leaves no orphan group
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&group).Error; err != nil {
+				if isUniqueViolation(err) {
+					return errSlugTaken
+				}
+				return fmt.Errorf("failed to create group: %v", err)
+			}
+
+			for _, name := range participantNames {
+				participant := database.Participant{
+					Name:    name,
+					GroupID: group.ID,
+				}
+				participants = append(participants, participant)
+			}
+
+			if err := tx.Create(&participants).Error; err != nil {
+				return fmt.Errorf("failed to create participants: %v", err)
+			}
+			return nil
+		})
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errSlugTaken) {
+			return nil, err
+		}
 	}
 
 	// Convert to response types
@@ -93,6 +231,7 @@ func (s *groupService) CreateGroup(ctx context.Context, req *CreateGroupRequest)
 	return &CreateGroupResponse{
 		Group:        GroupFromDB(&group),
 		Participants: responseParticipants,
+		EditToken:    editToken,
 	}, nil
 }
 
@@ -102,12 +241,77 @@ func (s *groupService) UpdateGroup(ctx context.Context, req *UpdateGroupRequest)
 		return nil, fmt.Errorf("failed to find group: %v", err)
 	}
 
+	// Existing amounts were entered in the old currency, so it can only change on an empty ledger
+	if req.Currency != group.Currency {
+		var expenseCount int64
+		if err := s.db.Model(&database.Expense{}).Where("group_id = ?", group.ID).Count(&expenseCount).Error; err != nil {
+			return nil, fmt.Errorf("failed to count expenses: %v", err)
+		}
+		if expenseCount > 0 {
+			return nil, fmt.Errorf("cannot change currency: the group has %d expenses. Reset the ledger first", expenseCount)
+		}
+	}
+
 	// Update group
 	group.Name = req.Name
 	group.Currency = req.Currency
+	if req.Description != nil {
+		description, err := normalizeGroupDescription(*req.Description)
+		if err != nil {
+			return nil, err
+		}
+		group.Description = description
+	}
+	if req.AmountDisplay != nil {
+		amountDisplay, err := normalizeAmountDisplay(*req.AmountDisplay)
+		if err != nil {
+			return nil, err
+		}
+		group.AmountDisplay = amountDisplay
+	}
+	reshapeDebts := false
+	if req.BankerId != nil {
+		var bankerID *uint
+		if *req.BankerId != 0 {
+			if _, err := getGroupParticipant(s.db, group.ID, *req.BankerId); err != nil {
+				return nil, fmt.Errorf("invalid group: banker must be a participant of the group")
+			}
+			id := uint(*req.BankerId)
+			bankerID = &id
+		}
+		reshapeDebts = (bankerID == nil) != (group.BankerID == nil) || (bankerID != nil && *bankerID != *group.BankerID)
+		group.BankerID = bankerID
+	}
+	if req.RotateRemainder != nil {
+		group.RotateRemainder = *req.RotateRemainder
+	}
+	if req.SimplifyDebts != nil && *req.SimplifyDebts != group.SimplifyDebts {
+		group.SimplifyDebts = *req.SimplifyDebts
+		reshapeDebts = true
+	}
+	// Settling through a banker is a form of simplification, so it can't be combined with raw pairwise debts
+	if (req.BankerId != nil || req.SimplifyDebts != nil) && group.BankerID != nil && !group.SimplifyDebts {
+		return nil, fmt.Errorf("invalid group: a banker requires simplify_debts; remove the banker or turn simplification on")
+	}
 
-	if err := s.db.Save(&group).Error; err != nil {
-		return nil, fmt.Errorf("failed to update group: %v", err)
+	// A new banker or simplification setting reshapes every debt, so they are recalculated with the group
+	if reshapeDebts {
+		release := acquireRecalculationSlot()
+		defer release()
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&group).Error; err != nil {
+			return fmt.Errorf("failed to update group: %v", err)
+		}
+		if reshapeDebts {
+			if err := recalculateDebts(tx, group.ID); err != nil {
+				return fmt.Errorf("failed to recalculate debts: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &UpdateGroupResponse{
@@ -115,6 +319,901 @@ func (s *groupService) UpdateGroup(ctx context.Context, req *UpdateGroupRequest)
 	}, nil
 }
 
+// maxGroupDescriptionLength is the longest group description accepted, in characters
+const maxGroupDescriptionLength = 2000
+
+// normalizeGroupDescription trims surrounding whitespace from a group description.
+// Input: raw description, possibly empty
+// Output: trimmed description and error if it is longer than maxGroupDescriptionLength
+func normalizeGroupDescription(description string) (string, error) {
+	trimmed := strings.TrimSpace(description)
+	if utf8.RuneCountInString(trimmed) > maxGroupDescriptionLength {
+		return "", fmt.Errorf("invalid group: description cannot be longer than %d characters", maxGroupDescriptionLength)
+	}
+	return trimmed, nil
+}
+
+// GetGroupStatistics summarizes who paid for and who consumed a group's spending.
+// Input: GetGroupStatisticsRequest with UrlSlug
+// Output: GetGroupStatisticsResponse with group totals and per-participant totals and percentages
+// Description: Aggregates expense costs by payer and split amounts by participant in SQL;
+// percentages are 0 when there is nothing to divide by
+func (s *groupService) GetGroupStatistics(ctx context.Context, req *GetGroupStatisticsRequest) (*GetGroupStatisticsResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Order("id").Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	var paidRows []struct {
+		ParticipantID uint
+		Total         float64
+	}
+	if err := s.db.Model(&database.Expense{}).
+		Select("payer_id as participant_id, SUM(cost) as total").
+		Where("group_id = ?", group.ID).
+		Group("payer_id").
+		Scan(&paidRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate expenses: %v", err)
+	}
+
+	var consumedRows []struct {
+		ParticipantID uint
+		Total         float64
+	}
+	if err := s.db.Model(&database.Split{}).
+		Select("participant_id, SUM(split_amount) as total").
+		Where("group_id = ?", group.ID).
+		Group("participant_id").
+		Scan(&consumedRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate splits: %v", err)
+	}
+
+	var expenseCount int64
+	if err := s.db.Model(&database.Expense{}).Where("group_id = ?", group.ID).Count(&expenseCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count expenses: %v", err)
+	}
+
+	paid := make(map[uint]float64)
+	var totalSpent float64
+	for _, row := range paidRows {
+		paid[row.ParticipantID] = row.Total
+		totalSpent += row.Total
+	}
+	consumed := make(map[uint]float64)
+	var totalConsumed float64
+	for _, row := range consumedRows {
+		consumed[row.ParticipantID] = row.Total
+		totalConsumed += row.Total
+	}
+
+	stats := make([]*ParticipantStatistics, len(participants))
+	for i, p := range participants {
+		stats[i] = &ParticipantStatistics{
+			ParticipantId:     int32(p.ID),
+			Name:              p.Name,
+			TotalPaid:         roundToMinorUnits(paid[p.ID], group.Currency),
+			TotalConsumed:     roundToMinorUnits(consumed[p.ID], group.Currency),
+			PercentOfPaid:     percentOf(paid[p.ID], totalSpent),
+			PercentOfConsumed: percentOf(consumed[p.ID], totalConsumed),
+		}
+	}
+
+	return &GetGroupStatisticsResponse{
+		Currency:      group.Currency,
+		ExpenseCount:  expenseCount,
+		TotalSpent:    roundToMinorUnits(totalSpent, group.Currency),
+		TotalConsumed: roundToMinorUnits(totalConsumed, group.Currency),
+		Participants:  stats,
+	}, nil
+}
+
+// GetExpensesByPayer counts and totals the expenses each participant paid for.
+// Input: GetExpensesByPayerRequest with UrlSlug
+// Output: GetExpensesByPayerResponse with an entry per participant
+// Description: Aggregates expenses grouped by payer_id in SQL. Participants who never paid are included
+// with zeros; the list is ordered by total paid, highest first, then by participant ID
+func (s *groupService) GetExpensesByPayer(ctx context.Context, req *GetExpensesByPayerRequest) (*GetExpensesByPayerResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Order("id").Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	var rows []struct {
+		PayerID      uint
+		ExpenseCount int64
+		Total        float64
+	}
+	if err := s.db.Model(&database.Expense{}).
+		Select("payer_id, COUNT(*) as expense_count, SUM(cost) as total").
+		Where("group_id = ?", group.ID).
+		Group("payer_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate expenses: %v", err)
+	}
+
+	byPayer := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		byPayer[row.PayerID] = i
+	}
+
+	payers := make([]*PayerTotals, len(participants))
+	for i, p := range participants {
+		payers[i] = &PayerTotals{ParticipantId: int32(p.ID), Name: p.Name}
+		if j, ok := byPayer[p.ID]; ok {
+			payers[i].ExpenseCount = rows[j].ExpenseCount
+			payers[i].TotalPaid = roundToMinorUnits(rows[j].Total, group.Currency)
+		}
+	}
+	// Participants are already in ID order, so a stable sort keeps that as the tie-breaker
+	sort.SliceStable(payers, func(i, j int) bool {
+		return payers[i].TotalPaid > payers[j].TotalPaid
+	})
+
+	return &GetExpensesByPayerResponse{
+		Currency: group.Currency,
+		Payers:   payers,
+	}, nil
+}
+
+// GetSpendingTimeSeries totals a group's expenses per day, week or month, e.g. for a spending chart.
+// Input: GetSpendingTimeSeriesRequest with UrlSlug and Bucket
+// Output: GetSpendingTimeSeriesResponse with one bucket per period, oldest first
+// Description: Expenses are grouped by their creation time truncated to the period start (UTC) in SQL.
+// Periods without expenses between the first and the last one are included with a total of 0, so the
+// series has no gaps; a group without expenses gets no buckets
+func (s *groupService) GetSpendingTimeSeries(ctx context.Context, req *GetSpendingTimeSeriesRequest) (*GetSpendingTimeSeriesResponse, error) {
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = BucketMonth
+	}
+	periodExpr, err := periodStartExpr(s.db, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Period string
+		Total  float64
+	}
+	if err := s.db.Model(&database.Expense{}).
+		Select(periodExpr+" as period, SUM(cost) as total").
+		Where("group_id = ?", group.ID).
+		Group("period").
+		Order("period").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate expenses: %v", err)
+	}
+
+	totals := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		totals[row.Period] = row.Total
+	}
+
+	buckets := []*SpendingBucket{}
+	if len(rows) > 0 {
+		first, err := time.Parse("2006-01-02", rows[0].Period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse period %q: %v", rows[0].Period, err)
+		}
+		last, err := time.Parse("2006-01-02", rows[len(rows)-1].Period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse period %q: %v", rows[len(rows)-1].Period, err)
+		}
+		for period := first; !period.After(last); period = nextPeriod(period, bucket) {
+			key := period.Format("2006-01-02")
+			buckets = append(buckets, &SpendingBucket{
+				Period: key,
+				Total:  roundToMinorUnits(totals[key], group.Currency),
+			})
+		}
+	}
+
+	return &GetSpendingTimeSeriesResponse{
+		Currency: group.Currency,
+		Bucket:   bucket,
+		Buckets:  buckets,
+	}, nil
+}
+
+// periodStartExpr returns the SQL expression that turns expenses.created_at into the start of its period as YYYY-MM-DD.
+// Input: gorm.DB database connection (to pick the dialect) and bucket size
+// Output: SQL expression and error for an unknown bucket
+// Description: sqlite and postgres have no common date truncation function, so each gets its own
+// expression; both work in UTC and start weeks on Monday
+func periodStartExpr(db *gorm.DB, bucket string) (string, error) {
+	sqlite := db.Dialector.Name() == "sqlite"
+	switch bucket {
+	case BucketDay:
+		if sqlite {
+			return "date(created_at)", nil
+		}
+		return "to_char(date_trunc('day', created_at AT TIME ZONE 'UTC'), 'YYYY-MM-DD')", nil
+	case BucketWeek:
+		if sqlite {
+			// Move forward to Sunday, then back to that week's Monday
+			return "date(created_at, 'weekday 0', '-6 days')", nil
+		}
+		return "to_char(date_trunc('week', created_at AT TIME ZONE 'UTC'), 'YYYY-MM-DD')", nil
+	case BucketMonth:
+		if sqlite {
+			return "strftime('%Y-%m-01', created_at)", nil
+		}
+		return "to_char(date_trunc('month', created_at AT TIME ZONE 'UTC'), 'YYYY-MM-DD')", nil
+	}
+	return "", fmt.Errorf("invalid bucket %q: use %q, %q or %q", bucket, BucketDay, BucketWeek, BucketMonth)
+}
+
+// nextPeriod returns the start of the period after the one starting at period
+func nextPeriod(period time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketDay:
+		return period.AddDate(0, 0, 1)
+	case BucketWeek:
+		return period.AddDate(0, 0, 7)
+	default:
+		return period.AddDate(0, 1, 0)
+	}
+}
+
+// GetParticipantFairShare compares what a participant has paid so far with their fair share.
+// Input: GetParticipantFairShareRequest with UrlSlug and ParticipantId
+// Output: GetParticipantFairShareResponse with the group total, fair share, amount paid and difference
+// Description: The fair share is the sum of the participant's splits, so it follows the expenses they are
+// actually part of. Payments between participants settle debts and are not counted as spending
+func (s *groupService) GetParticipantFairShare(ctx context.Context, req *GetParticipantFairShareRequest) (*GetParticipantFairShareResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getGroupParticipant(s.db, group.ID, req.ParticipantId); err != nil {
+		return nil, err
+	}
+
+	var groupTotal, paid, fairShare float64
+	if err := s.db.Model(&database.Expense{}).
+		Select("COALESCE(SUM(cost), 0)").
+		Where("group_id = ?", group.ID).
+		Scan(&groupTotal).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate expenses: %v", err)
+	}
+	if err := s.db.Model(&database.Expense{}).
+		Select("COALESCE(SUM(cost), 0)").
+		Where("group_id = ? AND payer_id = ?", group.ID, req.ParticipantId).
+		Scan(&paid).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate paid expenses: %v", err)
+	}
+	if err := s.db.Model(&database.Split{}).
+		Select("COALESCE(SUM(split_amount), 0)").
+		Where("group_id = ? AND participant_id = ?", group.ID, req.ParticipantId).
+		Scan(&fairShare).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate splits: %v", err)
+	}
+
+	return &GetParticipantFairShareResponse{
+		Currency:   group.Currency,
+		GroupTotal: roundToMinorUnits(groupTotal, group.Currency),
+		FairShare:  roundToMinorUnits(fairShare, group.Currency),
+		Paid:       roundToMinorUnits(paid, group.Currency),
+		Difference: roundToMinorUnits(paid-fairShare, group.Currency),
+	}, nil
+}
+
+// GetParticipantSpendingSummary totals what a participant consumed and paid for within a date range.
+// Input: GetParticipantSpendingSummaryRequest with UrlSlug, ParticipantId and optional From/To bounds
+// Output: GetParticipantSpendingSummaryResponse with consumed, paid and net amounts
+// Description: Expenses count by their date (created_at), From inclusive and To exclusive. Like the fair share,
+// payments between participants settle debts and are not counted as spending
+func (s *groupService) GetParticipantSpendingSummary(ctx context.Context, req *GetParticipantSpendingSummaryRequest) (*GetParticipantSpendingSummaryResponse, error) {
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, fmt.Errorf("invalid range: from must be before to")
+	}
+
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getGroupParticipant(s.db, group.ID, req.ParticipantId); err != nil {
+		return nil, err
+	}
+
+	// Restricts a query to expenses dated within the range
+	inRange := func(query *gorm.DB) *gorm.DB {
+		if req.From != nil {
+			query = query.Where("expenses.created_at >= ?", *req.From)
+		}
+		if req.To != nil {
+			query = query.Where("expenses.created_at < ?", *req.To)
+		}
+		return query
+	}
+
+	var paid, consumed float64
+	if err := inRange(s.db.Model(&database.Expense{}).
+		Select("COALESCE(SUM(expenses.cost), 0)").
+		Where("expenses.group_id = ? AND expenses.payer_id = ?", group.ID, req.ParticipantId)).
+		Scan(&paid).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate paid expenses: %v", err)
+	}
+	if err := inRange(s.db.Model(&database.Split{}).
+		Select("COALESCE(SUM(splits.split_amount), 0)").
+		Joins("JOIN expenses ON splits.expense_id = expenses.id").
+		Where("splits.group_id = ? AND splits.participant_id = ?", group.ID, req.ParticipantId)).
+		Scan(&consumed).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate splits: %v", err)
+	}
+
+	return &GetParticipantSpendingSummaryResponse{
+		Currency: group.Currency,
+		From:     req.From,
+		To:       req.To,
+		Consumed: roundToMinorUnits(consumed, group.Currency),
+		Paid:     roundToMinorUnits(paid, group.Currency),
+		Net:      roundToMinorUnits(paid-consumed, group.Currency),
+	}, nil
+}
+
+// GetParticipantStatement assembles everything about one participant in a group for a "my page" view.
+// Input: GetParticipantStatementRequest with UrlSlug and ParticipantId
+// Output: GetParticipantStatementResponse with paid expenses, splits, payments and the net balance
+// Description: Every section is newest first and names are resolved with joins (deleted participants show as
+// deletedParticipantName). The net balance comes from CalculateBalances, so it matches the participants list
+func (s *groupService) GetParticipantStatement(ctx context.Context, req *GetParticipantStatementRequest) (*GetParticipantStatementResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, err := getGroupParticipant(s.db, group.ID, req.ParticipantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var expenses []database.Expense
+	if err := s.db.Where("group_id = ? AND payer_id = ?", group.ID, participant.ID).Order("created_at DESC, id DESC").Find(&expenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get paid expenses: %v", err)
+	}
+	paidExpenses := make([]*Expense, len(expenses))
+	var totalPaid float64
+	for i, e := range expenses {
+		paidExpenses[i] = ExpenseFromDB(&e)
+		totalPaid += e.Cost
+	}
+
+	var splits []*StatementSplit
+	err = s.db.Table("splits").
+		Select(`
+			expenses.id as expense_id,
+			expenses.name as expense_name,
+			expenses.payer_id,
+			COALESCE(payer.name, ?) as payer_name,
+			expenses.cost,
+			splits.split_amount,
+			expenses.created_at
+		`, deletedParticipantName).
+		Joins("JOIN expenses ON splits.expense_id = expenses.id").
+		Joins("LEFT JOIN participants as payer ON expenses.payer_id = payer.id").
+		Where("splits.group_id = ? AND splits.participant_id = ?", group.ID, participant.ID).
+		Order("expenses.created_at DESC, expenses.id DESC").
+		Scan(&splits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits: %v", err)
+	}
+	var totalShare float64
+	for _, split := range splits {
+		totalShare += split.SplitAmount
+	}
+	if splits == nil {
+		splits = []*StatementSplit{}
+	}
+
+	payments, err := participantPayments(s.db, group.ID, req.ParticipantId)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, _, err := CalculateBalances(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balances: %v", err)
+	}
+
+	return &GetParticipantStatementResponse{
+		Participant:   ParticipantFromDB(participant),
+		Currency:      group.Currency,
+		AmountDisplay: GroupFromDB(group).AmountDisplay,
+		PaidExpenses:  paidExpenses,
+		Splits:        splits,
+		Payments:      payments,
+		TotalPaid:     roundToMinorUnits(totalPaid, group.Currency),
+		TotalShare:    roundToMinorUnits(totalShare, group.Currency),
+		NetBalance:    roundToMinorUnits(balances[participant.ID], group.Currency),
+	}, nil
+}
+
+// GetGroupSummaryMarkdown renders a group's recap as a markdown document for sharing.
+// Input: GetGroupSummaryMarkdownRequest with UrlSlug
+// Output: GetGroupSummaryMarkdownResponse with the document
+// Description: Lists the participants, every expense with its payer and shares, the payment history
+// (both oldest first) and the stored debts as the final settlement, largest first. Amounts are formatted
+// with the group's currency and display mode, and names are escaped so they can't break the markdown
+func (s *groupService) GetGroupSummaryMarkdown(ctx context.Context, req *GetGroupSummaryMarkdownRequest) (*GetGroupSummaryMarkdownResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+	display := GroupFromDB(group).AmountDisplay
+	format := func(amount float64) string {
+		return FormatDisplayAmount(amount, group.Currency, display)
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Order("id").Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	names := make(map[uint]string, len(participants))
+	for _, p := range participants {
+		names[p.ID] = escapeMarkdown(p.Name)
+	}
+	name := func(id uint) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return escapeMarkdown(deletedParticipantName)
+	}
+
+	var expenses []database.Expense
+	if err := s.db.Where("group_id = ?", group.ID).Order("created_at, id").Find(&expenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expenses: %v", err)
+	}
+	splitsByExpense, err := loadSplitsByExpense(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits: %v", err)
+	}
+
+	var payments []database.Payment
+	if err := s.db.Where("group_id = ?", group.ID).Order("created_at, id").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payments: %v", err)
+	}
+
+	var debts []database.Debt
+	if err := s.db.Where("group_id = ? AND debt_amount > 0", group.ID).Order("debt_amount DESC, id").Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get debts: %v", err)
+	}
+
+	var md strings.Builder
+	fmt.Fprintf(&md, "# %s\n\n", escapeMarkdown(group.Name))
+	if group.Description != "" {
+		fmt.Fprintf(&md, "%s\n\n", escapeMarkdown(group.Description))
+	}
+	var totalSpent float64
+	for _, e := range expenses {
+		totalSpent += e.Cost
+	}
+	fmt.Fprintf(&md, "Total spent: %s across %d expenses (%s)\n\n", format(totalSpent), len(expenses), group.Currency)
+
+	md.WriteString("## Participants\n\n")
+	if len(participants) == 0 {
+		md.WriteString("No participants.\n")
+	}
+	for _, p := range participants {
+		fmt.Fprintf(&md, "- %s\n", names[p.ID])
+	}
+
+	md.WriteString("\n## Expenses\n\n")
+	if len(expenses) == 0 {
+		md.WriteString("No expenses yet.\n")
+	}
+	for _, e := range expenses {
+		title := escapeMarkdown(e.Name)
+		if e.Emoji != "" {
+			title = e.Emoji + " " + title
+		}
+		fmt.Fprintf(&md, "- %s: **%s** for %s, paid by %s", e.CreatedAt.UTC().Format("2006-01-02"), title, format(e.Cost), name(e.PayerID))
+		if e.IsShared != nil && !*e.IsShared {
+			md.WriteString(" (personal)\n")
+			continue
+		}
+		shares := make([]string, 0, len(splitsByExpense[e.ID]))
+		for _, split := range splitsByExpense[e.ID] {
+			shares = append(shares, fmt.Sprintf("%s %s", name(split.ParticipantID), format(split.SplitAmount)))
+		}
+		fmt.Fprintf(&md, "; shares: %s\n", strings.Join(shares, ", "))
+	}
+
+	md.WriteString("\n## Payments\n\n")
+	if len(payments) == 0 {
+		md.WriteString("No payments yet.\n")
+	}
+	for _, p := range payments {
+		fmt.Fprintf(&md, "- %s: %s paid %s %s", p.CreatedAt.UTC().Format("2006-01-02"), name(p.PayerID), name(p.PayeeID), format(p.Amount))
+		if p.Note != "" {
+			fmt.Fprintf(&md, " (%s)", escapeMarkdown(p.Note))
+		}
+		md.WriteString("\n")
+	}
+
+	md.WriteString("\n## Settlement\n\n")
+	if len(debts) == 0 {
+		md.WriteString("Everyone is settled up.\n")
+	}
+	for _, d := range debts {
+		fmt.Fprintf(&md, "- %s pays %s %s\n", name(d.DebtorID), name(d.LenderID), format(d.DebtAmount))
+	}
+
+	return &GetGroupSummaryMarkdownResponse{Markdown: md.String()}, nil
+}
+
+// markdownEscaper backslash-escapes the characters that would turn user text into markdown formatting
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]",
+	"<", "\\<", ">", "\\>", "#", "\\#", "|", "\\|", "\n", " ", "\r", "",
+)
+
+// escapeMarkdown makes user-entered text safe to embed in a markdown line
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// percentOf returns part as a percentage of total rounded to two decimals, or 0 when total is 0
+func percentOf(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(part/total*10000) / 100
+}
+
+// ResetGroup clears a group's ledger so it can start a new period.
+// Input: ResetGroupRequest with UrlSlug and EditToken
+// Output: ResetGroupResponse with the group and its participants
+// Description: Deletes all expenses, splits, debts and payments of the group in one transaction;
+// the group itself and its participants are kept. Groups with an edit token require it
+func (s *groupService) ResetGroup(ctx context.Context, req *ResetGroupRequest) (*ResetGroupResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireEditToken(group, req.EditToken); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", group.ID).Delete(&database.Split{}).Error; err != nil {
+			return fmt.Errorf("failed to delete splits: %v", err)
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&database.Expense{}).Error; err != nil {
+			return fmt.Errorf("failed to delete expenses: %v", err)
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&database.Debt{}).Error; err != nil {
+			return fmt.Errorf("failed to delete debts: %v", err)
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&database.Payment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete payments: %v", err)
+		}
+		return recordActivity(tx, group.ID, ActionLedgerReset, 0, "Reset the ledger")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	responseParticipants := make([]*Participant, len(participants))
+	for i, p := range participants {
+		responseParticipants[i] = ParticipantFromDB(&p)
+	}
+
+	return &ResetGroupResponse{
+		Group:        GroupFromDB(group),
+		Participants: responseParticipants,
+	}, nil
+}
+
+// MergeGroups moves one group's ledger into another, e.g. when two groups were created for the same trip.
+// Input: MergeGroupsRequest with SourceSlug, TargetSlug and both groups' edit tokens
+// Output: MergeGroupsResponse with the target group, its participants and counts of what was moved
+// Description: Source participants are matched to target participants by name (case-insensitive); missing
+// ones are created in the target. Expenses, splits and payments are moved and remapped to the target's
+// participants, the target's debts are recalculated, and the source is left empty and archived. Both groups
+// must use the same currency, and each group that has an edit token requires it. Everything happens in one transaction
+func (s *groupService) MergeGroups(ctx context.Context, req *MergeGroupsRequest) (*MergeGroupsResponse, error) {
+	if req.SourceSlug == "" {
+		return nil, fmt.Errorf("invalid merge: source group is required")
+	}
+	if req.SourceSlug == req.TargetSlug {
+		return nil, fmt.Errorf("invalid merge: cannot merge a group into itself")
+	}
+
+	target, err := getGroupBySlug(s.db, req.TargetSlug)
+	if err != nil {
+		return nil, err
+	}
+	source, err := getGroupBySlug(s.db, req.SourceSlug)
+	if err != nil {
+		return nil, fmt.Errorf("source %v", err)
+	}
+	if err := requireEditToken(target, req.EditToken); err != nil {
+		return nil, err
+	}
+	if err := requireEditToken(source, req.SourceEditToken); err != nil {
+		return nil, fmt.Errorf("source %v", err)
+	}
+	if err := ensureGroupActive(target); err != nil {
+		return nil, err
+	}
+	if source.Currency != target.Currency {
+		return nil, fmt.Errorf("invalid merge: source uses %s but target uses %s", source.Currency, target.Currency)
+	}
+
+	resp := &MergeGroupsResponse{}
+	release := acquireRecalculationSlot()
+	defer release()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var targetParticipants []database.Participant
+		if err := tx.Where("group_id = ?", target.ID).Order("id").Find(&targetParticipants).Error; err != nil {
+			return fmt.Errorf("failed to get participants: %v", err)
+		}
+		byName := make(map[string]uint)
+		for _, p := range targetParticipants {
+			key := strings.ToLower(strings.TrimSpace(p.Name))
+			if _, ok := byName[key]; !ok {
+				byName[key] = p.ID
+			}
+		}
+
+		var sourceParticipants []database.Participant
+		if err := tx.Where("group_id = ?", source.ID).Order("id").Find(&sourceParticipants).Error; err != nil {
+			return fmt.Errorf("failed to get source participants: %v", err)
+		}
+
+		// Map every source participant to a target participant, creating the ones the target lacks.
+		// New IDs never collide with source IDs, so remapping one participant can't clobber another
+		remap := make(map[uint]uint, len(sourceParticipants))
+		for _, p := range sourceParticipants {
+			key := strings.ToLower(strings.TrimSpace(p.Name))
+			if id, ok := byName[key]; ok {
+				remap[p.ID] = id
+				continue
+			}
+			created := database.Participant{Name: strings.TrimSpace(p.Name), GroupID: target.ID}
+			if err := tx.Create(&created).Error; err != nil {
+				return fmt.Errorf("failed to create participant: %v", err)
+			}
+			byName[key] = created.ID
+			remap[p.ID] = created.ID
+			resp.ParticipantsCreated++
+		}
+
+		for oldID, newID := range remap {
+			if err := tx.Model(&database.Expense{}).Where("group_id = ? AND payer_id = ?", source.ID, oldID).Update("payer_id", newID).Error; err != nil {
+				return fmt.Errorf("failed to move expenses: %v", err)
+			}
+			if err := tx.Model(&database.Split{}).Where("group_id = ? AND participant_id = ?", source.ID, oldID).Update("participant_id", newID).Error; err != nil {
+				return fmt.Errorf("failed to move splits: %v", err)
+			}
+			if err := tx.Model(&database.Payment{}).Where("group_id = ? AND payer_id = ?", source.ID, oldID).Update("payer_id", newID).Error; err != nil {
+				return fmt.Errorf("failed to move payments: %v", err)
+			}
+			if err := tx.Model(&database.Payment{}).Where("group_id = ? AND payee_id = ?", source.ID, oldID).Update("payee_id", newID).Error; err != nil {
+				return fmt.Errorf("failed to move payments: %v", err)
+			}
+		}
+
+		expenses := tx.Model(&database.Expense{}).Where("group_id = ?", source.ID).Update("group_id", target.ID)
+		if expenses.Error != nil {
+			return fmt.Errorf("failed to move expenses: %v", expenses.Error)
+		}
+		resp.ExpensesMoved = int32(expenses.RowsAffected)
+		if err := tx.Model(&database.Split{}).Where("group_id = ?", source.ID).Update("group_id", target.ID).Error; err != nil {
+			return fmt.Errorf("failed to move splits: %v", err)
+		}
+		payments := tx.Model(&database.Payment{}).Where("group_id = ?", source.ID).Update("group_id", target.ID)
+		if payments.Error != nil {
+			return fmt.Errorf("failed to move payments: %v", payments.Error)
+		}
+		resp.PaymentsMoved = int32(payments.RowsAffected)
+
+		if err := tx.Where("group_id = ?", source.ID).Delete(&database.Debt{}).Error; err != nil {
+			return fmt.Errorf("failed to delete source debts: %v", err)
+		}
+		if err := recalculateDebts(tx, target.ID); err != nil {
+			return fmt.Errorf("failed to recalculate debts: %v", err)
+		}
+
+		if err := tx.Model(source).Update("state", "archived").Error; err != nil {
+			return fmt.Errorf("failed to archive source group: %v", err)
+		}
+		description := fmt.Sprintf("Merged %q into this group (%d expenses, %d payments)", source.Name, resp.ExpensesMoved, resp.PaymentsMoved)
+		return recordActivity(tx, target.ID, ActionGroupMerged, source.ID, description)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", target.ID).Order("id").Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	resp.Group = GroupFromDB(target)
+	resp.Participants = make([]*Participant, len(participants))
+	for i, p := range participants {
+		resp.Participants[i] = ParticipantFromDB(&p)
+	}
+	return resp, nil
+}
+
+// GetGroupDiagnostics checks a group's data for inconsistencies.
+// Input: GetGroupDiagnosticsRequest with UrlSlug
+// Output: GetGroupDiagnosticsResponse listing every anomaly found (empty and Healthy when there are none)
+// Description: Read-only. Reports expenses whose splits don't add up to the cost, references to participants
+// outside the group, balances that don't add up to zero, stored debts that don't settle the balances, and
+// payments larger than the payer's debt to the payee just before the payment
+func (s *groupService) GetGroupDiagnostics(ctx context.Context, req *GetGroupDiagnosticsRequest) (*GetGroupDiagnosticsResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+	threshold := AmountThreshold(group.Currency)
+	anomalies := []*GroupAnomaly{}
+	report := func(kind, entityType string, entityID uint, amount float64, format string, args ...interface{}) {
+		anomalies = append(anomalies, &GroupAnomaly{
+			Kind:       kind,
+			EntityType: entityType,
+			EntityId:   int32(entityID),
+			Amount:     roundToMinorUnits(amount, group.Currency),
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+	inGroup := make(map[uint]bool)
+	for _, p := range participants {
+		inGroup[p.ID] = true
+	}
+
+	var expenses []database.Expense
+	if err := s.db.Where("group_id = ?", group.ID).Order("id").Find(&expenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expenses: %v", err)
+	}
+	splitsByExpense, err := loadSplitsByExpense(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits: %v", err)
+	}
+	for _, expense := range expenses {
+		if !inGroup[expense.PayerID] {
+			report(AnomalyMissingParticipant, "expense", expense.ID, 0,
+				"expense %d (%q) is paid by participant %d, who is not in the group", expense.ID, expense.Name, expense.PayerID)
+		}
+		var total float64
+		for _, split := range splitsByExpense[expense.ID] {
+			total += split.SplitAmount
+			if !inGroup[split.ParticipantID] {
+				report(AnomalyMissingParticipant, "split", split.ID, 0,
+					"split %d of expense %d refers to participant %d, who is not in the group", split.ID, expense.ID, split.ParticipantID)
+			}
+		}
+		if math.Abs(total-expense.Cost) > threshold {
+			report(AnomalySplitSumMismatch, "expense", expense.ID, expense.Cost-total,
+				"expense %d (%q) costs %.2f but its splits add up to %.2f", expense.ID, expense.Name, expense.Cost, total)
+		}
+	}
+
+	var payments []database.Payment
+	if err := s.db.Where("group_id = ?", group.ID).Order("created_at, id").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payments: %v", err)
+	}
+	bankerID, err := groupBanker(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get banker: %v", err)
+	}
+
+	// Replay the ledger in time order so each payment is checked against the debts just before it
+	// without recalculating the whole group once per payment
+	expensesByTime := make([]database.Expense, len(expenses))
+	copy(expensesByTime, expenses)
+	sort.SliceStable(expensesByTime, func(i, j int) bool {
+		return expensesByTime[i].CreatedAt.Before(expensesByTime[j].CreatedAt)
+	})
+	running := make(map[uint]float64, len(participants))
+	for _, p := range participants {
+		running[p.ID] = 0
+	}
+	nextExpense, nextPayment := 0, 0
+	for _, payment := range payments {
+		for ; nextExpense < len(expensesByTime) && expensesByTime[nextExpense].CreatedAt.Before(payment.CreatedAt); nextExpense++ {
+			expense := expensesByTime[nextExpense]
+			running[expense.PayerID] += expense.Cost
+			for _, split := range splitsByExpense[expense.ID] {
+				running[split.ParticipantID] -= split.SplitAmount
+			}
+		}
+		for ; nextPayment < len(payments) && payments[nextPayment].CreatedAt.Before(payment.CreatedAt); nextPayment++ {
+			running[payments[nextPayment].PayerID] += payments[nextPayment].Amount
+			running[payments[nextPayment].PayeeID] -= payments[nextPayment].Amount
+		}
+
+		if !inGroup[payment.PayerID] || !inGroup[payment.PayeeID] {
+			report(AnomalyMissingParticipant, "payment", payment.ID, 0,
+				"payment %d from participant %d to participant %d involves someone who is not in the group", payment.ID, payment.PayerID, payment.PayeeID)
+			continue
+		}
+		// Debts just before this payment, simplified as CalculateNetDebtsAsOf would; earlier payments already count
+		var before []database.Debt
+		if _, ok := running[bankerID]; ok {
+			before = bankerDebts(group.ID, bankerID, running, threshold)
+		} else {
+			before = simplifyBalances(group.ID, running, threshold)
+		}
+		var owed float64
+		for _, debt := range before {
+			if debt.DebtorID == payment.PayerID && debt.LenderID == payment.PayeeID {
+				owed += debt.DebtAmount
+			}
+		}
+		if payment.Amount-owed > threshold {
+			report(AnomalyOverpayment, "payment", payment.ID, payment.Amount-owed,
+				"payment %d of %.2f from participant %d to participant %d exceeds the %.2f they owed", payment.ID, payment.Amount, payment.PayerID, payment.PayeeID, owed)
+		}
+	}
+
+	balances, _, err := CalculateBalances(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balances: %v", err)
+	}
+	if err := CheckBalanceConservation(balances, group.Currency); err != nil {
+		var total float64
+		for _, balance := range balances {
+			total += balance
+		}
+		report(AnomalyUnbalancedLedger, "group", group.ID, total, "%v", err)
+	}
+
+	// Residual debts below the settled threshold are dropped on purpose, so allow for them
+	debtTolerance := math.Max(threshold, SettledDebtThreshold()/math.Pow10(MinorUnits(group.Currency)))
+	var debts []database.Debt
+	if err := s.db.Where("group_id = ?", group.ID).Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get debts: %v", err)
+	}
+	settled := make(map[uint]float64)
+	for _, debt := range debts {
+		settled[debt.LenderID] += debt.DebtAmount
+		settled[debt.DebtorID] -= debt.DebtAmount
+	}
+	for _, p := range participants {
+		if diff := balances[p.ID] - settled[p.ID]; math.Abs(diff) > debtTolerance {
+			report(AnomalyDebtMismatch, "participant", p.ID, diff,
+				"stored debts give %s a balance of %.2f, but expenses and payments give %.2f", p.Name, settled[p.ID], balances[p.ID])
+		}
+	}
+
+	return &GetGroupDiagnosticsResponse{
+		Currency:  group.Currency,
+		Healthy:   len(anomalies) == 0,
+		Anomalies: anomalies,
+	}, nil
+}
+
 // GetGroupParticipants retrieves participants for multiple groups by URL slug.
 // Input: GroupParticipantsRequest with list of group slugs
 // Output: GroupParticipantsResponse with participants for each group
@@ -167,6 +1266,104 @@ func (s *groupService) GetGroupParticipants(ctx context.Context, req *GroupParti
 	}, nil
 }
 
+// FindParticipant finds the participants with a given name across several groups.
+// Input: FindParticipantRequest with a name and list of group slugs
+// Output: FindParticipantResponse with one match per matching participant
+// Description: Matches names case-insensitively, ignoring surrounding whitespace, so a user who lost their
+// local participant IDs can reconnect. Matches follow the order of the slugs, then participant ID;
+// a group with several participants of that name yields several matches. Unknown slugs are skipped
+func (s *groupService) FindParticipant(ctx context.Context, req *FindParticipantRequest) (*FindParticipantResponse, error) {
+	matches := []*ParticipantMatch{}
+	name, err := normalizeParticipantName(req.Name)
+	if err != nil || len(req.GroupSlugs) == 0 {
+		return &FindParticipantResponse{Matches: matches}, nil
+	}
+
+	var groups []database.Group
+	if err := s.db.Where("url_slug IN ?", req.GroupSlugs).Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get groups: %v", err)
+	}
+	if len(groups) == 0 {
+		return &FindParticipantResponse{Matches: matches}, nil
+	}
+
+	groupIDs := make([]uint, len(groups))
+	for i, group := range groups {
+		groupIDs[i] = group.ID
+	}
+
+	var participants []database.Participant
+	if err := s.db.Where("group_id IN ? AND LOWER(name) = LOWER(?)", groupIDs, name).Order("id").Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	participantsByGroup := make(map[uint][]database.Participant)
+	for _, p := range participants {
+		participantsByGroup[p.GroupID] = append(participantsByGroup[p.GroupID], p)
+	}
+	groupsBySlug := make(map[string]database.Group)
+	for _, group := range groups {
+		groupsBySlug[group.URLSlug] = group
+	}
+
+	seen := make(map[string]bool)
+	for _, slug := range req.GroupSlugs {
+		group, ok := groupsBySlug[slug]
+		if !ok || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		for _, p := range participantsByGroup[group.ID] {
+			matches = append(matches, &ParticipantMatch{
+				GroupUrlSlug:    group.URLSlug,
+				GroupName:       group.Name,
+				ParticipantId:   int32(p.ID),
+				ParticipantName: p.Name,
+			})
+		}
+	}
+
+	return &FindParticipantResponse{Matches: matches}, nil
+}
+
+// getGroupBySlug looks up a group by its URL slug without preloading associations.
+// Input: gorm.DB database connection and URL slug
+// Output: database.Group and error ("group not found" when the slug does not resolve)
+func getGroupBySlug(db *gorm.DB, urlSlug string) (*database.Group, error) {
+	var group database.Group
+	if err := db.Where("url_slug = ?", urlSlug).First(&group).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %v", err)
+	}
+	return &group, nil
+}
+
+// getGroupByID looks up a group by its ID without preloading associations.
+// Input: gorm.DB database connection and group ID
+// Output: database.Group and error ("group not found" when no group has the ID)
+func getGroupByID(db *gorm.DB, groupID uint) (*database.Group, error) {
+	var group database.Group
+	if err := db.Where("id = ?", groupID).First(&group).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %v", err)
+	}
+	return &group, nil
+}
+
+// ensureGroupActive checks that a group accepts new payments and expenses.
+// Input: the group
+// Output: error when the group has been archived or settled
+func ensureGroupActive(group *database.Group) error {
+	if group.State != "" && group.State != "active" {
+		return fmt.Errorf("group is %s; reopen it before recording changes", group.State)
+	}
+	return nil
+}
+
 // generateURLSlug generates a unique 10-character hexadecimal URL slug for groups.
 // Input: none
 // Output: string URL slug and error
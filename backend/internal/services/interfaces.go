@@ -5,33 +5,93 @@ import "context"
 // GroupService interface
 type GroupService interface {
 	GetGroup(ctx context.Context, req *GetGroupRequest) (*GetGroupResponse, error)
+	GetGroupMeta(ctx context.Context, req *GetGroupMetaRequest) (*GetGroupMetaResponse, error)
+	GroupExists(ctx context.Context, req *GroupExistsRequest) (*GroupExistsResponse, error)
+	GetGroupVersion(ctx context.Context, req *GetGroupVersionRequest) (*GetGroupVersionResponse, error)
 	CreateGroup(ctx context.Context, req *CreateGroupRequest) (*CreateGroupResponse, error)
+	RotateEditToken(ctx context.Context, req *RotateEditTokenRequest) (*RotateEditTokenResponse, error)
 	UpdateGroup(ctx context.Context, req *UpdateGroupRequest) (*UpdateGroupResponse, error)
+	GetGroupStatistics(ctx context.Context, req *GetGroupStatisticsRequest) (*GetGroupStatisticsResponse, error)
+	GetExpensesByPayer(ctx context.Context, req *GetExpensesByPayerRequest) (*GetExpensesByPayerResponse, error)
+	GetGroupSummaryMarkdown(ctx context.Context, req *GetGroupSummaryMarkdownRequest) (*GetGroupSummaryMarkdownResponse, error)
+	GetSpendingTimeSeries(ctx context.Context, req *GetSpendingTimeSeriesRequest) (*GetSpendingTimeSeriesResponse, error)
+	GetParticipantFairShare(ctx context.Context, req *GetParticipantFairShareRequest) (*GetParticipantFairShareResponse, error)
+	GetParticipantSpendingSummary(ctx context.Context, req *GetParticipantSpendingSummaryRequest) (*GetParticipantSpendingSummaryResponse, error)
+	GetParticipantStatement(ctx context.Context, req *GetParticipantStatementRequest) (*GetParticipantStatementResponse, error)
+	ResetGroup(ctx context.Context, req *ResetGroupRequest) (*ResetGroupResponse, error)
+	MergeGroups(ctx context.Context, req *MergeGroupsRequest) (*MergeGroupsResponse, error)
+	GetGroupDiagnostics(ctx context.Context, req *GetGroupDiagnosticsRequest) (*GetGroupDiagnosticsResponse, error)
 	GetGroupParticipants(ctx context.Context, req *GroupParticipantsRequest) (*GroupParticipantsResponse, error)
+	FindParticipant(ctx context.Context, req *FindParticipantRequest) (*FindParticipantResponse, error)
 }
 
 // ParticipantService interface
 type ParticipantService interface {
 	AddParticipant(ctx context.Context, req *AddParticipantRequest) (*AddParticipantResponse, error)
+	AddParticipants(ctx context.Context, req *AddParticipantsRequest) (*AddParticipantsResponse, error)
+	ListParticipants(ctx context.Context, req *ListParticipantsRequest) (*ListParticipantsResponse, error)
+	VerifyParticipant(ctx context.Context, req *VerifyParticipantRequest) (*VerifyParticipantResponse, error)
 	UpdateParticipant(ctx context.Context, req *UpdateParticipantRequest) (*UpdateParticipantResponse, error)
 	DeleteParticipant(ctx context.Context, req *DeleteParticipantRequest) error
 }
 
+// PreferenceService interface
+type PreferenceService interface {
+	GetUserGroupPreferences(ctx context.Context, req *GetUserGroupPreferencesRequest) (*GetUserGroupPreferencesResponse, error)
+	SetUserGroupPreferences(ctx context.Context, req *SetUserGroupPreferencesRequest) (*SetUserGroupPreferencesResponse, error)
+}
+
+// AdminService interface
+type AdminService interface {
+	ListCurrencies(ctx context.Context, req *ListCurrenciesRequest) (*ListCurrenciesResponse, error)
+}
+
 // ExpenseService interface
 type ExpenseService interface {
+	GetExpenseDetail(ctx context.Context, req *GetExpenseDetailRequest) (*GetExpenseDetailResponse, error)
 	GetExpensesByGroup(ctx context.Context, req *GetExpensesByGroupRequest) (*GetExpensesByGroupResponse, error)
+	GetParticipantPaidExpenses(ctx context.Context, req *GetParticipantPaidExpensesRequest) (*GetParticipantPaidExpensesResponse, error)
 	GetExpenseWithSplits(ctx context.Context, req *GetExpenseWithSplitsRequest) (*GetExpenseWithSplitsResponse, error)
 	GetSplitsByGroup(ctx context.Context, req *GetSplitsByGroupRequest) (*GetSplitsByGroupResponse, error)
 	CreateExpense(ctx context.Context, req *CreateExpenseRequest) (*CreateExpenseResponse, error)
 	UpdateExpense(ctx context.Context, req *UpdateExpenseRequest) (*UpdateExpenseResponse, error)
+	ResplitExpense(ctx context.Context, req *ResplitExpenseRequest) (*ResplitExpenseResponse, error)
+	SetExpenseLocked(ctx context.Context, req *SetExpenseLockedRequest) (*SetExpenseLockedResponse, error)
 	DeleteExpense(ctx context.Context, req *DeleteExpenseRequest) error
+	DeleteExpenses(ctx context.Context, req *DeleteExpensesRequest) (*DeleteExpensesResponse, error)
+	ValidateSplit(ctx context.Context, req *ValidateSplitRequest) (*ValidateSplitResponse, error)
 }
 
 // DebtService interface
 type DebtService interface {
 	GetDebtsPageData(ctx context.Context, req *GetDebtsRequest) (*GetDebtsPageDataResponse, error)
+	GetDebtGraph(ctx context.Context, req *GetDebtGraphRequest) (*GetDebtGraphResponse, error)
+	GetSettlementComparison(ctx context.Context, req *GetSettlementComparisonRequest) (*GetSettlementComparisonResponse, error)
+	GetSettlementCircles(ctx context.Context, req *GetSettlementCirclesRequest) (*GetSettlementCirclesResponse, error)
+	GetDebtCycles(ctx context.Context, req *GetDebtCyclesRequest) (*GetDebtCyclesResponse, error)
+	GetDebtCount(ctx context.Context, req *GetDebtCountRequest) (*GetDebtCountResponse, error)
+	GetLargestDebt(ctx context.Context, req *GetLargestDebtRequest) (*GetLargestDebtResponse, error)
+	GetSettlementSteps(ctx context.Context, req *GetSettlementStepsRequest) (*GetSettlementStepsResponse, error)
+	GetComputedBalances(ctx context.Context, req *GetComputedBalancesRequest) (*GetComputedBalancesResponse, error)
+	GetDebtsAsOf(ctx context.Context, req *GetDebtsAsOfRequest) (*GetDebtsAsOfResponse, error)
 	CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResponse, error)
+	SettlePair(ctx context.Context, req *SettlePairRequest) (*SettlePairResponse, error)
+	PayMultipleDebts(ctx context.Context, req *PayMultipleDebtsRequest) (*PayMultipleDebtsResponse, error)
+	ProjectDebts(ctx context.Context, req *ProjectDebtsRequest) (*ProjectDebtsResponse, error)
 	GetPayments(ctx context.Context, req *GetPaymentsRequest) (*GetPaymentsResponse, error)
+	GetPaymentsPageData(ctx context.Context, req *GetPaymentsPageDataRequest) (*GetPaymentsPageDataResponse, error)
+	GetParticipantPayments(ctx context.Context, req *GetParticipantPaymentsRequest) (*GetParticipantPaymentsResponse, error)
+	GetParticipantOwedDebts(ctx context.Context, req *GetParticipantOwedDebtsRequest) (*GetParticipantOwedDebtsResponse, error)
+	GetParticipantSettlePlan(ctx context.Context, req *GetParticipantSettlePlanRequest) (*GetParticipantSettlePlanResponse, error)
+	GetParticipantBalance(ctx context.Context, req *GetParticipantBalanceRequest) (*GetParticipantBalanceResponse, error)
 	DeletePayment(ctx context.Context, req *DeletePaymentRequest) (*DeletePaymentResponse, error)
 	GetUserGroupsSummary(ctx context.Context, req *UserGroupsSummaryRequest) (*UserGroupsSummaryResponse, error)
+	GetCombinedSettlement(ctx context.Context, req *CombinedSettlementRequest) (*CombinedSettlementResponse, error)
+}
+
+// ActivityService interface
+type ActivityService interface {
+	GetGroupActivity(ctx context.Context, req *GetGroupActivityRequest) (*GetGroupActivityResponse, error)
+	GetUserGroupsActivity(ctx context.Context, req *UserGroupsActivityRequest) (*UserGroupsActivityResponse, error)
+	Undo(ctx context.Context, req *UndoRequest) (*UndoResponse, error)
 }
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"freesplit/internal/database"
 
@@ -26,8 +28,13 @@ func NewParticipantService(db *gorm.DB) ParticipantService {
 // Output: AddParticipantResponse with created participant
 // Description: Creates a new participant and associates them with the specified group
 func (s *participantService) AddParticipant(ctx context.Context, req *AddParticipantRequest) (*AddParticipantResponse, error) {
+	name, err := normalizeParticipantName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	participant := database.Participant{
-		Name:    req.Name,
+		Name:    name,
 		GroupID: uint(req.GroupId),
 	}
 
@@ -40,17 +47,143 @@ func (s *participantService) AddParticipant(ctx context.Context, req *AddPartici
 	}, nil
 }
 
+// ListParticipants lists a group's participants with their net balances.
+// Input: ListParticipantsRequest with UrlSlug and an optional Sort
+// Output: ListParticipantsResponse with participants and the group currency
+// Description: Balances are computed from expenses and payments with CalculateBalances. Sort "balance" puts
+// whoever is owed the most first (ties by ID); otherwise participants are ordered by ID
+func (s *participantService) ListParticipants(ctx context.Context, req *ListParticipantsRequest) (*ListParticipantsResponse, error) {
+	if req.Sort != "" && req.Sort != "balance" {
+		return nil, fmt.Errorf("invalid sort %q: use \"balance\" or leave it empty", req.Sort)
+	}
+
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, participants, err := CalculateBalances(s.db, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balances: %v", err)
+	}
+
+	result := make([]*ParticipantWithBalance, len(participants))
+	for i, p := range participants {
+		result[i] = &ParticipantWithBalance{
+			Id:         int32(p.ID),
+			Name:       p.Name,
+			GroupId:    int32(p.GroupID),
+			NetBalance: roundToMinorUnits(balances[p.ID], group.Currency),
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if req.Sort == "balance" && result[i].NetBalance != result[j].NetBalance {
+			return result[i].NetBalance > result[j].NetBalance
+		}
+		return result[i].Id < result[j].Id
+	})
+
+	return &ListParticipantsResponse{
+		Currency:     group.Currency,
+		Participants: result,
+	}, nil
+}
+
+// VerifyParticipant checks that a participant ID a client remembered still belongs to the group.
+// Input: VerifyParticipantRequest with UrlSlug and ParticipantId
+// Output: VerifyParticipantResponse with the participant's current data
+// Description: Returns "participant not found" when the participant was deleted or belongs to another group
+func (s *participantService) VerifyParticipant(ctx context.Context, req *VerifyParticipantRequest) (*VerifyParticipantResponse, error) {
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, err := getGroupParticipant(s.db, group.ID, req.ParticipantId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VerifyParticipantResponse{
+		Exists:      true,
+		Participant: ParticipantFromDB(participant),
+	}, nil
+}
+
+// AddParticipants creates several participants in a group at once.
+// Input: AddParticipantsRequest with UrlSlug and Names
+// Output: AddParticipantsResponse with created participants
+// Description: Rejects the whole batch if any name is empty or duplicates another name in the batch or group
+func (s *participantService) AddParticipants(ctx context.Context, req *AddParticipantsRequest) (*AddParticipantsResponse, error) {
+	if len(req.Names) == 0 {
+		return nil, fmt.Errorf("names list cannot be empty")
+	}
+
+	group, err := getGroupBySlug(s.db, req.UrlSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []database.Participant
+	if err := s.db.Where("group_id = ?", group.ID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	// Names are compared case-insensitively so "Alice" and "alice" count as the same person
+	seen := make(map[string]bool)
+	for _, p := range existing {
+		seen[strings.ToLower(p.Name)] = true
+	}
+
+	participants := make([]database.Participant, len(req.Names))
+	for i, rawName := range req.Names {
+		name, err := normalizeParticipantName(rawName)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(name)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate participant name: %s", name)
+		}
+		seen[key] = true
+		participants[i] = database.Participant{
+			Name:    name,
+			GroupID: group.ID,
+		}
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&participants).Error
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create participants: %v", err)
+	}
+
+	responseParticipants := make([]*Participant, len(participants))
+	for i, p := range participants {
+		responseParticipants[i] = ParticipantFromDB(&p)
+	}
+
+	return &AddParticipantsResponse{
+		Participants: responseParticipants,
+	}, nil
+}
+
 // UpdateParticipant updates an existing participant's information.
 // Input: UpdateParticipantRequest with ParticipantId and Name
 // Output: UpdateParticipantResponse with updated participant
 // Description: Updates participant name and returns the modified participant data
 func (s *participantService) UpdateParticipant(ctx context.Context, req *UpdateParticipantRequest) (*UpdateParticipantResponse, error) {
+	name, err := normalizeParticipantName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	var participant database.Participant
 	if err := s.db.First(&participant, req.ParticipantId).Error; err != nil {
 		return nil, fmt.Errorf("participant not found: %v", err)
 	}
 
-	participant.Name = req.Name
+	participant.Name = name
 	if err := s.db.Save(&participant).Error; err != nil {
 		return nil, fmt.Errorf("failed to update participant: %v", err)
 	}
@@ -74,6 +207,16 @@ func (s *participantService) DeleteParticipant(ctx context.Context, req *DeleteP
 		return fmt.Errorf("failed to find participant: %v", err)
 	}
 
+	// A group always keeps at least one participant
+	var participantCount int64
+	if err := s.db.Model(&database.Participant{}).Where("group_id = ?", participant.GroupID).Count(&participantCount).Error; err != nil {
+		return fmt.Errorf("failed to count group participants: %v", err)
+	}
+
+	if participantCount <= 1 {
+		return fmt.Errorf("cannot delete participant: they are the last participant in the group. Add someone else first")
+	}
+
 	// Check if participant has any active expenses as payer
 	var expenseCount int64
 	if err := s.db.Model(&database.Expense{}).Where("payer_id = ?", req.ParticipantId).Count(&expenseCount).Error; err != nil {
@@ -104,10 +247,39 @@ func (s *participantService) DeleteParticipant(ctx context.Context, req *DeleteP
 		return fmt.Errorf("cannot delete participant: they have %d active debts. Please settle these debts first", debtCount)
 	}
 
-	// Delete the participant
-	if err := s.db.Delete(&participant).Error; err != nil {
-		return fmt.Errorf("failed to delete participant: %v", err)
+	// Delete the participant, and stop routing debts through them if they were the banker
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&participant).Error; err != nil {
+			return fmt.Errorf("failed to delete participant: %v", err)
+		}
+		if err := tx.Model(&database.Group{}).Where("id = ? AND banker_id = ?", participant.GroupID, participant.ID).Update("banker_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to clear banker: %v", err)
+		}
+		return nil
+	})
+}
+
+// getGroupParticipant looks up a participant and checks that they belong to the given group.
+// Input: gorm.DB database connection, groupID and participantID
+// Output: database.Participant and error ("participant not found" when missing or in another group)
+func getGroupParticipant(db *gorm.DB, groupID uint, participantID int32) (*database.Participant, error) {
+	var participant database.Participant
+	if err := db.Where("id = ? AND group_id = ?", participantID, groupID).First(&participant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("participant not found")
+		}
+		return nil, fmt.Errorf("failed to get participant: %v", err)
 	}
+	return &participant, nil
+}
 
-	return nil
+// normalizeParticipantName trims surrounding whitespace from a participant name.
+// Input: raw participant name
+// Output: trimmed name and error if nothing is left after trimming
+func normalizeParticipantName(name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return "", fmt.Errorf("participant name cannot be empty")
+	}
+	return trimmed, nil
 }
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"freesplit/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Identity tokens are chosen by the client, so they must be long enough not to be guessed
+const (
+	minIdentityTokenLength = 16
+	maxIdentityTokenLength = 128
+)
+
+type preferenceService struct {
+	db *gorm.DB
+}
+
+// NewPreferenceService creates a new instance of the preference service with database connection.
+// Input: gorm.DB database connection
+// Output: PreferenceService interface implementation
+// Description: Initializes preference service with database dependency injection
+func NewPreferenceService(db *gorm.DB) PreferenceService {
+	return &preferenceService{db: db}
+}
+
+// GetUserGroupPreferences retrieves the group list preferences stored for an identity.
+// Input: GetUserGroupPreferencesRequest with IdentityToken
+// Output: GetUserGroupPreferencesResponse with preferences ordered by sort order, then slug
+// Description: An identity without stored preferences gets an empty list
+func (s *preferenceService) GetUserGroupPreferences(ctx context.Context, req *GetUserGroupPreferencesRequest) (*GetUserGroupPreferencesResponse, error) {
+	if err := validateIdentityToken(req.IdentityToken); err != nil {
+		return nil, err
+	}
+
+	preferences, err := listUserGroupPreferences(s.db, req.IdentityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetUserGroupPreferencesResponse{Preferences: preferences}, nil
+}
+
+// SetUserGroupPreferences stores pinned flags and sort order for some of an identity's groups.
+// Input: SetUserGroupPreferencesRequest with IdentityToken and one preference per group
+// Output: SetUserGroupPreferencesResponse with all of the identity's preferences after the update
+// Description: Each listed group's preference is created or replaced; groups not listed keep theirs.
+// Rejects the whole batch if any slug doesn't resolve to a group
+func (s *preferenceService) SetUserGroupPreferences(ctx context.Context, req *SetUserGroupPreferencesRequest) (*SetUserGroupPreferencesResponse, error) {
+	if err := validateIdentityToken(req.IdentityToken); err != nil {
+		return nil, err
+	}
+	if len(req.Preferences) == 0 {
+		return nil, fmt.Errorf("preferences list cannot be empty")
+	}
+
+	identityHash := hashEditToken(req.IdentityToken)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, preference := range req.Preferences {
+			if _, err := getGroupBySlug(tx, preference.GroupUrlSlug); err != nil {
+				return fmt.Errorf("%v: %s", err, preference.GroupUrlSlug)
+			}
+
+			var stored database.UserGroupPreference
+			err := tx.Where("identity_hash = ? AND group_slug = ?", identityHash, preference.GroupUrlSlug).First(&stored).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return fmt.Errorf("failed to get preference: %v", err)
+			}
+			stored.IdentityHash = identityHash
+			stored.GroupSlug = preference.GroupUrlSlug
+			stored.Pinned = preference.Pinned
+			stored.SortOrder = int(preference.SortOrder)
+			if err := tx.Save(&stored).Error; err != nil {
+				return fmt.Errorf("failed to save preference: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := listUserGroupPreferences(s.db, req.IdentityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetUserGroupPreferencesResponse{Preferences: preferences}, nil
+}
+
+// listUserGroupPreferences loads an identity's preferences, ordered by sort order, then slug
+func listUserGroupPreferences(db *gorm.DB, identityToken string) ([]*UserGroupPreference, error) {
+	var stored []database.UserGroupPreference
+	if err := db.Where("identity_hash = ?", hashEditToken(identityToken)).Order("sort_order, group_slug").Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %v", err)
+	}
+
+	preferences := make([]*UserGroupPreference, len(stored))
+	for i, p := range stored {
+		preferences[i] = &UserGroupPreference{
+			GroupUrlSlug: p.GroupSlug,
+			Pinned:       p.Pinned,
+			SortOrder:    int32(p.SortOrder),
+		}
+	}
+	return preferences, nil
+}
+
+// validateIdentityToken checks that a client-chosen identity token is usable as a key
+func validateIdentityToken(token string) error {
+	if len(token) < minIdentityTokenLength || len(token) > maxIdentityTokenLength {
+		return fmt.Errorf("invalid identity token: must be between %d and %d characters", minIdentityTokenLength, maxIdentityTokenLength)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"freesplit/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultMaxConcurrentRecalculations bounds how many debt recalculations run at once per process
+const defaultMaxConcurrentRecalculations = 8
+
+// Semaphore is a counting semaphore; Acquire blocks while all slots are taken.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates a semaphore with the given number of slots (at least one).
+func NewSemaphore(size int) *Semaphore {
+	if size < 1 {
+		size = 1
+	}
+	return &Semaphore{slots: make(chan struct{}, size)}
+}
+
+// Acquire takes a slot, waiting in line until one is free.
+func (s *Semaphore) Acquire() {
+	s.slots <- struct{}{}
+}
+
+// Release gives back a slot taken by Acquire.
+func (s *Semaphore) Release() {
+	<-s.slots
+}
+
+// Size returns the number of slots.
+func (s *Semaphore) Size() int {
+	return cap(s.slots)
+}
+
+var (
+	recalculationMu        sync.RWMutex
+	recalculationSemaphore = NewSemaphore(defaultMaxConcurrentRecalculations)
+)
+
+// SetMaxConcurrentRecalculations sets the process-wide limit on concurrent debt recalculations.
+// Meant to be called once at startup, before requests are served.
+func SetMaxConcurrentRecalculations(n int) {
+	recalculationMu.Lock()
+	defer recalculationMu.Unlock()
+	recalculationSemaphore = NewSemaphore(n)
+}
+
+// MaxConcurrentRecalculations returns the current process-wide recalculation limit.
+func MaxConcurrentRecalculations() int {
+	recalculationMu.RLock()
+	defer recalculationMu.RUnlock()
+	return recalculationSemaphore.Size()
+}
+
+// defaultSettledDebtThreshold is the default settled-debt threshold, in minor units of the group's currency
+const defaultSettledDebtThreshold = 1.0
+
+var settledDebtThreshold = defaultSettledDebtThreshold
+
+// SetSettledDebtThreshold sets how small, in minor units of the group's currency, a recalculated debt
+// must be to count as settled (1 → debts under one cent are dropped for USD). Zero keeps every debt.
+// Meant to be called once at startup, before requests are served.
+func SetSettledDebtThreshold(minorUnits float64) {
+	recalculationMu.Lock()
+	defer recalculationMu.Unlock()
+	settledDebtThreshold = minorUnits
+}
+
+// SettledDebtThreshold returns the current settled-debt threshold in minor units.
+func SettledDebtThreshold() float64 {
+	recalculationMu.RLock()
+	defer recalculationMu.RUnlock()
+	return settledDebtThreshold
+}
+
+// acquireRecalculationSlot waits for one of the MaxConcurrentRecalculations slots and returns the function that
+// gives it back. Callers take it before opening the transaction that recalculates debts, so queued requests
+// don't hold a database connection or row locks while they wait.
+func acquireRecalculationSlot() func() {
+	recalculationMu.RLock()
+	semaphore := recalculationSemaphore
+	recalculationMu.RUnlock()
+
+	semaphore.Acquire()
+	return semaphore.Release
+}
+
+// recalculateDebts rewrites the debts of a group inside the caller's transaction.
+// Input: gorm.DB transaction and groupID
+// Output: error if debt calculation fails
+// Description: Locks the group row first, so recalculations of the same group serialize: a second transaction
+// waits until the first commits and then calculates from its committed expenses and payments. Then replaces the
+// group's debts with freshly calculated ones: simplified by CalculateNetDebts, or the raw pairwise debts from
+// CalculateRawDebts when the group has simplification off. Callers hold a slot from acquireRecalculationSlot.
+// Debts smaller than SettledDebtThreshold are dropped, and logged as settled when the pair had a stored debt before.
+func recalculateDebts(tx *gorm.DB, groupID uint) error {
+	recalculationMu.RLock()
+	minorUnitsThreshold := settledDebtThreshold
+	recalculationMu.RUnlock()
+
+	// NO KEY UPDATE doesn't conflict with the key-share locks that inserting expenses and payments takes on the group
+	var locked []database.Group
+	if err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).Select("id").Where("id = ?", groupID).Find(&locked).Error; err != nil {
+		return fmt.Errorf("failed to lock group: %v", err)
+	}
+
+	simplify, err := groupSimplifiesDebts(tx, groupID)
+	if err != nil {
+		return err
+	}
+	var newDebts []database.Debt
+	if simplify {
+		newDebts, err = CalculateNetDebts(tx, groupID)
+	} else {
+		newDebts, err = CalculateRawDebts(tx, groupID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Float residue can leave debts worth less than a cent; treat those as settled instead of storing them
+	currency, err := groupCurrency(tx, groupID)
+	if err != nil {
+		return err
+	}
+	threshold := minorUnitsThreshold / math.Pow10(MinorUnits(currency))
+
+	// A residual comes from the expenses and payments, so every later recalculation rebuilds it. Only the one that
+	// drops a stored debt below the threshold logs it; later ones find no stored debt for the pair and stay quiet
+	var oldDebts []database.Debt
+	if err := tx.Where("group_id = ?", groupID).Find(&oldDebts).Error; err != nil {
+		return err
+	}
+	type debtPair struct{ debtor, lender uint }
+	stored := make(map[debtPair]bool, len(oldDebts))
+	for _, debt := range oldDebts {
+		stored[debtPair{debt.DebtorID, debt.LenderID}] = true
+	}
+
+	keptDebts := newDebts[:0]
+	for _, debt := range newDebts {
+		if debt.DebtAmount >= threshold {
+			keptDebts = append(keptDebts, debt)
+			continue
+		}
+		if !stored[debtPair{debt.DebtorID, debt.LenderID}] {
+			continue
+		}
+		if err := recordActivity(tx, groupID, ActionDebtSettled, 0, "Settled residual "+debtDescription(tx, &debt)); err != nil {
+			return err
+		}
+	}
+	newDebts = keptDebts
+
+	// Clear existing debts
+	if err := tx.Where("group_id = ?", groupID).Delete(&database.Debt{}).Error; err != nil {
+		return err
+	}
+
+	// Create new debts
+	for _, debt := range newDebts {
+		if err := tx.Create(&debt).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// debtDescription renders a debt for the activity log, falling back to a placeholder for deleted participants.
+func debtDescription(db *gorm.DB, debt *database.Debt) string {
+	var participants []database.Participant
+	db.Where("id IN ?", []uint{debt.DebtorID, debt.LenderID}).Find(&participants)
+
+	names := map[uint]string{debt.DebtorID: deletedParticipantName, debt.LenderID: deletedParticipantName}
+	for _, p := range participants {
+		names[p.ID] = p.Name
+	}
+	return fmt.Sprintf("debt of %.4f from %s to %s", debt.DebtAmount, names[debt.DebtorID], names[debt.LenderID])
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Slug styles for new group URLs
+const (
+	SlugStyleHex   = "hex"   // e.g. 3f9a0c51be
+	SlugStyleWords = "words" // e.g. brave-sunny-otter-48213
+)
+
+// maxSlugAttempts is how many slugs are tried before giving up on finding an unused one
+const maxSlugAttempts = 5
+
+var slugStyle atomic.Value
+
+func init() {
+	slugStyle.Store(SlugStyleHex)
+}
+
+// SetSlugStyle sets the slug style used for new groups that don't ask for one.
+// Meant to be called once at startup.
+func SetSlugStyle(style string) error {
+	if !validSlugStyle(style) {
+		return fmt.Errorf("invalid slug style %q: must be %q or %q", style, SlugStyleHex, SlugStyleWords)
+	}
+	slugStyle.Store(style)
+	return nil
+}
+
+// SlugStyle returns the slug style used for new groups by default.
+func SlugStyle() string {
+	return slugStyle.Load().(string)
+}
+
+func validSlugStyle(style string) bool {
+	return style == SlugStyleHex || style == SlugStyleWords
+}
+
+// The word lists have 256 entries each. Two different adjectives, a noun and a five-digit number give
+// 256 × 255 × 256 × 90,000 ≈ 2^40.5 word slugs, at least as many as the 16^10 = 2^40 hex slugs, so a word slug
+// is no easier to guess than a hex one and random picks rarely collide even with millions of groups
+var slugAdjectives = []string{
+	"able", "agile", "airy", "amber", "ample", "apt", "arctic", "ardent",
+	"artful", "astral", "august", "autumn", "avid", "azure", "balmy", "bashful",
+	"beaming", "blithe", "bold", "bouncy", "brave", "breezy", "bright", "brisk",
+	"bubbly", "busy", "calm", "candid", "capable", "carefree", "caring", "cheeky",
+	"cheerful", "cheery", "chief", "chill", "chipper", "chirpy", "civil", "classic",
+	"clean", "clear", "clever", "cloudy", "coastal", "cobalt", "comfy", "cool",
+	"copper", "cosmic", "cosy", "crafty", "creamy", "crisp", "cuddly", "curious",
+	"curly", "cute", "dainty", "dapper", "daring", "dashing", "dazzling", "dear",
+	"deft", "devoted", "dewy", "dizzy", "dreamy", "dusky", "dusty", "eager",
+	"early", "earnest", "easy", "elated", "elegant", "eminent", "epic", "even",
+	"exact", "fabled", "fair", "faithful", "famous", "fancy", "fearless", "feisty",
+	"festive", "fiery", "fine", "firm", "fleet", "fluffy", "flying", "fond",
+	"frank", "free", "fresh", "friendly", "frosty", "frugal", "funny", "fuzzy",
+	"gallant", "gentle", "giant", "giddy", "gifted", "gilded", "glad", "gleaming",
+	"glossy", "golden", "good", "graceful", "grand", "grateful", "green", "groovy",
+	"grown", "handy", "happy", "hardy", "hearty", "helpful", "heroic", "honest",
+	"hopeful", "humble", "icy", "ideal", "jade", "jaunty", "jazzy", "jolly",
+	"jovial", "joyful", "juicy", "keen", "kind", "kindly", "lavish", "leafy",
+	"level", "light", "lilac", "limber", "lively", "loyal", "lucid", "lucky",
+	"lunar", "lush", "magic", "majestic", "major", "mellow", "merry", "mighty",
+	"mild", "minty", "misty", "modern", "modest", "mossy", "musical", "mystic",
+	"narrow", "neat", "nifty", "nimble", "noble", "novel", "oaken", "ocean",
+	"open", "orderly", "organic", "patient", "peachy", "peppy", "perky", "placid",
+	"plucky", "plush", "polar", "polite", "precise", "prime", "prompt", "proper",
+	"proud", "quaint", "quick", "quiet", "quirky", "radiant", "rapid", "rare",
+	"ready", "regal", "rich", "robust", "rosy", "royal", "rustic", "rusty",
+	"sandy", "savvy", "scenic", "secret", "serene", "sharp", "shiny", "silent",
+	"silky", "silver", "simple", "sincere", "sleek", "sleepy", "smart", "smooth",
+	"snappy", "snowy", "snug", "soft", "solar", "solid", "sonic", "sparkly",
+	"speedy", "spicy", "spry", "steady", "stellar", "stoic", "stormy", "sturdy",
+	"sublime", "sunny", "super", "supreme", "sweet", "swift", "tame", "tender",
+	"thrifty", "tidy", "timely", "tiny", "wild", "witty", "zany", "zesty",
+}
+
+var slugNouns = []string{
+	"acacia", "acorn", "alpaca", "anchor", "apple", "apricot", "arrow", "aspen",
+	"aster", "atlas", "badger", "bagel", "bamboo", "banjo", "barley", "basil",
+	"beacon", "beaver", "beetle", "berry", "birch", "bison", "blossom", "bobcat",
+	"bonsai", "bramble", "breeze", "brook", "buffalo", "bunny", "butter", "cabin",
+	"cactus", "camel", "canary", "candle", "canoe", "canyon", "cargo", "carrot",
+	"castle", "cedar", "cello", "cheetah", "cherry", "chestnut", "cinder", "clover",
+	"cobra", "cocoa", "coconut", "comet", "compass", "condor", "cookie", "coral",
+	"cosmos", "cottage", "cougar", "coyote", "crane", "crater", "cricket", "crystal",
+	"cupcake", "cypress", "dahlia", "daisy", "dolphin", "donkey", "dove", "dragon",
+	"dune", "eagle", "echo", "eel", "elk", "ember", "emu", "falcon",
+	"fennel", "fern", "ferret", "fig", "finch", "fjord", "flamingo", "forest",
+	"fossil", "fox", "galaxy", "garden", "gazelle", "gecko", "geyser", "ginger",
+	"giraffe", "glacier", "gnome", "goose", "granite", "grape", "gull", "harbor",
+	"hawk", "hazel", "heather", "hedgehog", "heron", "hickory", "hippo", "honey",
+	"hornet", "husky", "ibis", "iceberg", "iguana", "island", "ivy", "jackal",
+	"jaguar", "jasmine", "jelly", "jungle", "juniper", "kayak", "kelp", "kestrel",
+	"kettle", "kiwi", "koala", "lagoon", "lantern", "lark", "laurel", "lemon",
+	"lemur", "lily", "lion", "llama", "lobster", "lotus", "lupine", "lynx",
+	"magnet", "magpie", "mango", "mantis", "maple", "marble", "marlin", "meadow",
+	"meerkat", "melon", "meteor", "mink", "mint", "mole", "moose", "moth",
+	"muffin", "narwhal", "nebula", "nectar", "newt", "nutmeg", "oak", "oasis",
+	"ocelot", "octopus", "olive", "onyx", "orca", "orchid", "osprey", "otter",
+	"owl", "oyster", "panda", "panther", "papaya", "parrot", "peach", "peanut",
+	"pebble", "pelican", "penguin", "pepper", "petal", "pigeon", "pine", "planet",
+	"plum", "pony", "poppy", "prairie", "puffin", "puma", "quail", "quartz",
+	"quokka", "rabbit", "raccoon", "radish", "rain", "raven", "reef", "river",
+	"robin", "rocket", "rose", "saffron", "sage", "salmon", "sardine", "seahorse",
+	"seal", "sequoia", "shark", "shell", "sierra", "sloth", "snail", "sparrow",
+	"spruce", "squid", "star", "stork", "summit", "swan", "tadpole", "tango",
+	"tapir", "thistle", "thunder", "tiger", "toucan", "trout", "tulip", "tundra",
+	"turtle", "valley", "violet", "volcano", "walnut", "walrus", "wasp", "willow",
+	"wolf", "wombat", "wren", "yak", "yeti", "yucca", "zebra", "zephyr",
+}
+
+// wordSlugNumbers is how many numbers can end a word slug: 10000 to 99999
+const wordSlugNumbers = 90000
+
+// WordSlugSpace returns how many different word slugs generateWordSlug can produce.
+func WordSlugSpace() *big.Int {
+	space := big.NewInt(int64(len(slugAdjectives)))
+	space.Mul(space, big.NewInt(int64(len(slugAdjectives)-1)))
+	space.Mul(space, big.NewInt(int64(len(slugNouns))))
+	return space.Mul(space, big.NewInt(wordSlugNumbers))
+}
+
+// generateWordSlug generates a pronounceable slug of the form adjective-adjective-noun-number.
+// Input: none
+// Output: string URL slug and error
+// Description: Picks two different adjectives, a noun and a five-digit number with crypto/rand; uniqueness is
+// checked by the caller
+func generateWordSlug() (string, error) {
+	first, err := randomIndex(len(slugAdjectives))
+	if err != nil {
+		return "", err
+	}
+	// Pick from the other adjectives by skipping over the first one
+	second, err := randomIndex(len(slugAdjectives) - 1)
+	if err != nil {
+		return "", err
+	}
+	if second >= first {
+		second++
+	}
+	noun, err := randomIndex(len(slugNouns))
+	if err != nil {
+		return "", err
+	}
+	number, err := randomIndex(wordSlugNumbers)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s-%s-%d", slugAdjectives[first], slugAdjectives[second], slugNouns[noun], number+10000), nil
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// generateSlug generates one candidate slug in the given style.
+// Input: slug style (empty for the configured default)
+// Output: string URL slug and error
+// Description: Does not check existing groups; callers insert the slug and rely on the unique index,
+// retrying with a new one when isUniqueViolation reports a collision
+func generateSlug(style string) (string, error) {
+	if style == "" {
+		style = SlugStyle()
+	}
+	if !validSlugStyle(style) {
+		return "", fmt.Errorf("invalid slug style %q: must be %q or %q", style, SlugStyleHex, SlugStyleWords)
+	}
+	if style == SlugStyleWords {
+		return generateWordSlug()
+	}
+	return generateURLSlug()
+}
+
+// errSlugTaken signals that an insert lost the race for its slug and should be retried with another one
+var errSlugTaken = errors.New("url slug already taken")
+
+// isUniqueViolation reports whether err comes from a unique constraint, as raised by postgres or sqlite
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "duplicate key value") || strings.Contains(msg, "UNIQUE constraint failed")
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"fmt"
+	"math"
+)
+
+// Split amounts entered by the client must add up to the cost within a tolerance, in minor units of the currency.
+// The default matches the frontend's one-cent check; a request may loosen or tighten it up to the maximum.
+const (
+	defaultSplitToleranceUnits = 1
+	maxSplitToleranceUnits     = 100
+)
+
+// splitOptions carries per-request settings for server-side split computation
+type splitOptions struct {
+	Currency               string   // Group currency; decides rounding and comparison precision
+	RemainderParticipantId int32    // Who absorbs leftover cents; 0 for the default rule
+	Tolerance              *float64 // How far client-entered split amounts may be off the cost; nil for the default
+}
+
+// applySplitType computes server-side split amounts for split types that need it.
+// Input: expense being saved, the splits submitted with it and per-request options
+// Output: warnings about client-sent amounts that were ignored, and error if the expense or its splits are inconsistent
+// Description: Validates the splits and cost breakdown and rewrites SplitAmount for computed split types.
+// For those types the server's computation wins: split amounts the client sent anyway are replaced, and
+// each one that was off by more than one minor unit is reported in a warning instead of failing the request
+func applySplitType(expense *Expense, splits []*Split, opts splitOptions) ([]string, error) {
+	// An expense nobody shares would credit the payer with no offsetting debtors
+	if len(splits) == 0 {
+		return nil, fmt.Errorf("invalid expense: at least one participant must share the expense")
+	}
+
+	if err := validateCostBreakdown(expense, opts.Currency); err != nil {
+		return nil, err
+	}
+
+	// Personal expenses already carry the payer's full-cost split from personalSplits
+	if !expense.Shared() {
+		return nil, nil
+	}
+
+	sent := make([]float64, len(splits))
+	for i, split := range splits {
+		sent[i] = split.SplitAmount
+	}
+
+	var err error
+	switch expense.SplitType {
+	case "equal", "equal_excluding_payer":
+		err = applyEqualSplit(expense, splits, opts)
+	case "adjustment":
+		err = applyAdjustmentSplit(expense, splits, opts)
+	case "weighted":
+		err = applyWeightedSplit(expense, splits, opts)
+	case "mixed":
+		err = applyMixedSplit(expense, splits, opts)
+	case "itemized":
+		// Itemized splits take the client's amounts as subtotal shares, so nothing is ignored
+		return nil, applyItemizedSplit(expense, splits, opts)
+	default:
+		return nil, reconcileSplitAmounts(expense, splits, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ignoredSplitAmountWarnings(expense.SplitType, sent, splits, opts.Currency), nil
+}
+
+// ignoredSplitAmountWarnings describes client-sent split amounts that a computed split type replaced.
+// Input: split type, the amounts as sent, the splits after computation and the currency
+// Output: one warning per split whose sent amount was non-zero and off by more than one minor unit
+func ignoredSplitAmountWarnings(splitType string, sent []float64, splits []*Split, currency string) []string {
+	var warnings []string
+	for i, split := range splits {
+		if sent[i] == 0 {
+			continue
+		}
+		diff := ToMinorUnits(sent[i], currency) - ToMinorUnits(split.SplitAmount, currency)
+		if diff > defaultSplitToleranceUnits || -diff > defaultSplitToleranceUnits {
+			warnings = append(warnings, fmt.Sprintf("split amount %.2f for participant %d was ignored: %q expenses are split by the server (%.2f)",
+				sent[i], split.ParticipantId, splitType, split.SplitAmount))
+		}
+	}
+	return warnings
+}
+
+// reconcileSplitAmounts checks client-entered split amounts against the cost.
+// Input: expense, its splits and options with the tolerance and remainder participant
+// Output: error if the tolerance is out of range or the amounts are further off than it allows
+// Description: A difference within the tolerance goes to the remainder participant (the last listed by default),
+// so the splits always add up to the cost exactly and the group's balances still reconcile
+func reconcileSplitAmounts(expense *Expense, splits []*Split, opts splitOptions) error {
+	allowed := int64(defaultSplitToleranceUnits)
+	if opts.Tolerance != nil {
+		allowed = ToMinorUnits(*opts.Tolerance, opts.Currency)
+		if *opts.Tolerance < 0 || allowed > maxSplitToleranceUnits {
+			return fmt.Errorf("invalid expense: split_tolerance must be between 0 and %.2f", FromMinorUnits(maxSplitToleranceUnits, opts.Currency))
+		}
+	}
+
+	var total int64
+	for _, split := range splits {
+		total += ToMinorUnits(split.SplitAmount, opts.Currency)
+	}
+	diff := ToMinorUnits(expense.Cost, opts.Currency) - total
+	if diff > allowed || -diff > allowed {
+		return fmt.Errorf("invalid expense: split amounts (%.2f) must add up to cost (%.2f) within %.2f",
+			FromMinorUnits(total, opts.Currency), expense.Cost, FromMinorUnits(allowed, opts.Currency))
+	}
+	if diff == 0 {
+		return nil
+	}
+
+	index, err := remainderIndex(splits, opts)
+	if err != nil {
+		return err
+	}
+	splits[index].SplitAmount = FromMinorUnits(ToMinorUnits(splits[index].SplitAmount, opts.Currency)+diff, opts.Currency)
+	return nil
+}
+
+// remainderIndex finds the split that absorbs rounding differences: the remainder participant's,
+// or the last one when none was chosen
+func remainderIndex(splits []*Split, opts splitOptions) (int, error) {
+	if opts.RemainderParticipantId == 0 {
+		return len(splits) - 1, nil
+	}
+	for i, split := range splits {
+		if split.ParticipantId == opts.RemainderParticipantId {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("invalid expense: remainder participant %d is not one of the split participants", opts.RemainderParticipantId)
+}
+
+// personalSplits builds the only split of a personal expense: the payer carries the full cost,
+// so the expense nets to zero in the debt calculation
+func personalSplits(expense *Expense) []*Split {
+	return []*Split{{
+		GroupId:       expense.GroupId,
+		ParticipantId: expense.PayerId,
+		SplitAmount:   expense.Cost,
+	}}
+}
+
+// splitsWithoutPayer drops the payer from the splits of an "equal_excluding_payer" expense.
+// Input: expense and the splits submitted with it
+// Output: the remaining splits, or an error if the payer was the only participant
+// Description: For purchases the payer made only for the others; the payer may be listed but never shares the cost
+func splitsWithoutPayer(expense *Expense, splits []*Split) ([]*Split, error) {
+	var others []*Split
+	for _, split := range splits {
+		if split.ParticipantId != expense.PayerId {
+			others = append(others, split)
+		}
+	}
+	if len(others) == 0 {
+		return nil, fmt.Errorf("invalid expense: an equal split excluding the payer needs at least one participant besides the payer")
+	}
+	return others, nil
+}
+
+// applyEqualSplit divides the cost equally among the split participants.
+// Input: expense, its splits and options naming the remainder participant
+// Output: error if the remainder participant is not one of the split participants
+// Description: Everyone gets the cost / n rounded down to the currency's minor unit; the leftover minor units go to
+// the remainder participant, or to the last listed participant by default (matching the frontend). Rounding down
+// keeps the leftover between zero and n-1 minor units, so no share goes negative however small the cost
+func applyEqualSplit(expense *Expense, splits []*Split, opts splitOptions) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	remainder, err := remainderIndex(splits, opts)
+	if err != nil {
+		return err
+	}
+
+	total := ToMinorUnits(expense.Cost, opts.Currency)
+	count := int64(len(splits))
+	share := total / count
+	for i, split := range splits {
+		if i == remainder {
+			split.SplitAmount = FromMinorUnits(total-share*(count-1), opts.Currency)
+		} else {
+			split.SplitAmount = FromMinorUnits(share, opts.Currency)
+		}
+	}
+
+	return nil
+}
+
+// applyAdjustmentSplit splits the cost equally after setting aside per-person adjustments.
+// Input: expense, its splits with optional Adjustment amounts and options naming the remainder participant
+// Output: error if a share would be negative or the shares don't add up to the cost
+// Description: Each share is (cost - sum of adjustments) / n plus that person's adjustment;
+// the equal part is rounded like an equal split
+/*
+
+Example: $65 dinner, Bob had a $5 dessert (adjustment +5)
+    Equal part: (65 - 5) / 3 = $20
+    Alice pays $20, Bob pays $25, Carol pays $20
+
+*/
+func applyAdjustmentSplit(expense *Expense, splits []*Split, opts splitOptions) error {
+	var adjustments float64
+	for _, split := range splits {
+		adjustments += split.Adjustment
+	}
+
+	base := &Expense{Cost: roundToMinorUnits(expense.Cost-adjustments, opts.Currency)}
+	if err := applyEqualSplit(base, splits, opts); err != nil {
+		return err
+	}
+
+	var total float64
+	for _, split := range splits {
+		split.SplitAmount = roundToMinorUnits(split.SplitAmount+split.Adjustment, opts.Currency)
+		if split.SplitAmount < 0 {
+			return fmt.Errorf("invalid expense: adjustments leave participant %d with a negative share", split.ParticipantId)
+		}
+		total += split.SplitAmount
+	}
+
+	if math.Abs(total-expense.Cost) > AmountThreshold(opts.Currency) {
+		return fmt.Errorf("invalid expense: adjusted shares (%.2f) must equal cost (%.2f)", total, expense.Cost)
+	}
+
+	return nil
+}
+
+// applyWeightedSplit divides the cost in proportion to each split's Weight.
+// Input: expense and its splits with Weight set, e.g. nights stayed
+// Output: error if a weight is negative or the weights add up to zero
+// Description: Each share is cost * weight / total weight; leftover minor units go to the largest
+// fractional parts, so the shares add up to the cost exactly
+/*
+
+Example: $600 cabin, Alice stays 2 nights, Bob 3, Carol 1
+    Alice pays $200, Bob pays $300, Carol pays $100
+
+*/
+func applyWeightedSplit(expense *Expense, splits []*Split, opts splitOptions) error {
+	weights := make([]float64, len(splits))
+	var totalWeight float64
+	for i, split := range splits {
+		if split.Weight < 0 {
+			return fmt.Errorf("invalid expense: weights cannot be negative")
+		}
+		weights[i] = split.Weight
+		totalWeight += split.Weight
+	}
+	if totalWeight <= 0 {
+		return fmt.Errorf("invalid expense: weighted split requires a positive total weight")
+	}
+
+	amounts := distributeProportionally(expense.Cost, weights, opts.Currency)
+	for i, split := range splits {
+		split.SplitAmount = amounts[i]
+	}
+
+	return nil
+}
+
+// applyMixedSplit charges some participants exact amounts and splits what is left equally among the rest.
+// Input: expense, its splits (SharesRemainder marks the equal ones, the others carry SplitAmount) and options
+// naming the remainder participant
+// Output: error if an exact amount is negative, the exact amounts exceed the cost, or they leave money nobody shares
+// Description: Exact amounts are rounded to the currency's minor unit and assigned first; the rest of the cost
+// is divided like an equal split among the participants that share the remainder
+/*
+
+Example: $100 dinner, Alice had a $30 set menu and Bob a $10 salad; Carol and Dave share the rest
+    Alice pays $30, Bob pays $10, Carol pays $30, Dave pays $30
+
+*/
+func applyMixedSplit(expense *Expense, splits []*Split, opts splitOptions) error {
+	var exact int64
+	var sharing []*Split
+	for _, split := range splits {
+		if split.SharesRemainder {
+			sharing = append(sharing, split)
+			continue
+		}
+		if split.SplitAmount < 0 {
+			return fmt.Errorf("invalid expense: exact amounts cannot be negative")
+		}
+		split.SplitAmount = roundToMinorUnits(split.SplitAmount, opts.Currency)
+		exact += ToMinorUnits(split.SplitAmount, opts.Currency)
+	}
+
+	remaining := ToMinorUnits(expense.Cost, opts.Currency) - exact
+	if remaining < 0 {
+		return fmt.Errorf("invalid expense: exact amounts (%.2f) exceed cost (%.2f)", FromMinorUnits(exact, opts.Currency), expense.Cost)
+	}
+	if len(sharing) == 0 {
+		if remaining != 0 {
+			return fmt.Errorf("invalid expense: exact amounts (%.2f) must add up to cost (%.2f) when nobody shares the remainder",
+				FromMinorUnits(exact, opts.Currency), expense.Cost)
+		}
+		return nil
+	}
+
+	return applyEqualSplit(&Expense{Cost: FromMinorUnits(remaining, opts.Currency)}, sharing, opts)
+}
+
+// validateCostBreakdown checks that subtotal, tax and tip add up to the expense cost.
+// An expense without any breakdown (all three zero) is always valid.
+func validateCostBreakdown(expense *Expense, currency string) error {
+	if expense.Subtotal < 0 || expense.Tax < 0 || expense.Tip < 0 {
+		return fmt.Errorf("invalid expense: subtotal, tax and tip cannot be negative")
+	}
+
+	if expense.Subtotal == 0 && expense.Tax == 0 && expense.Tip == 0 {
+		return nil
+	}
+
+	breakdown := expense.Subtotal + expense.Tax + expense.Tip
+	if math.Abs(breakdown-expense.Cost) > AmountThreshold(currency) {
+		return fmt.Errorf("invalid expense: subtotal + tax + tip (%.2f) must equal cost (%.2f)", breakdown, expense.Cost)
+	}
+
+	return nil
+}
+
+// applyItemizedSplit turns each participant's subtotal share into their final amount.
+// Input: expense with Subtotal, Tax and Tip set and splits whose SplitAmount is the subtotal share
+// Output: error if the subtotal shares don't add up to the subtotal
+// Description: Tax and tip are allocated proportionally to each participant's subtotal share
+/*
+
+Example: Subtotal $100, tax $10, tip $20 (cost $130)
+    Alice ordered $60, Bob ordered $40
+    Alice pays 60 + 60% of $30 = $78
+    Bob pays   40 + 40% of $30 = $52
+
+*/
+func applyItemizedSplit(expense *Expense, splits []*Split, opts splitOptions) error {
+	if expense.Subtotal <= 0 {
+		return fmt.Errorf("invalid expense: itemized split requires a positive subtotal")
+	}
+
+	shares := make([]float64, len(splits))
+	var sharesTotal float64
+	for i, split := range splits {
+		if split.SplitAmount < 0 {
+			return fmt.Errorf("invalid expense: split amounts cannot be negative")
+		}
+		shares[i] = split.SplitAmount
+		sharesTotal += split.SplitAmount
+	}
+
+	if math.Abs(sharesTotal-expense.Subtotal) > AmountThreshold(opts.Currency) {
+		return fmt.Errorf("invalid expense: subtotal shares (%.2f) must equal subtotal (%.2f)", sharesTotal, expense.Subtotal)
+	}
+
+	extras := distributeProportionally(expense.Tax+expense.Tip, shares, opts.Currency)
+	for i, split := range splits {
+		split.SplitAmount = roundToMinorUnits(shares[i]+extras[i], opts.Currency)
+	}
+
+	return nil
+}
+
+// distributeProportionally splits an amount across weights, working in whole minor units (cents).
+// Input: amount to distribute, non-negative weights and the currency
+// Output: per-weight amounts that add up exactly to the rounded amount
+// Description: Uses the largest remainder method so leftover cents go to the largest fractional parts
+func distributeProportionally(amount float64, weights []float64, currency string) []float64 {
+	result := make([]float64, len(weights))
+
+	var totalWeight float64
+	for _, w := range weights {
+		totalWeight += w
+	}
+	if totalWeight <= 0 {
+		return result
+	}
+
+	scale := math.Pow10(MinorUnits(currency))
+	totalCents := int64(math.Round(amount * scale))
+	cents := make([]int64, len(weights))
+	remainders := make([]float64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		exact := float64(totalCents) * w / totalWeight
+		cents[i] = int64(math.Floor(exact))
+		remainders[i] = exact - float64(cents[i])
+		allocated += cents[i]
+	}
+
+	// Hand out the leftover cents, largest remainder first (lowest index wins ties)
+	for leftover := totalCents - allocated; leftover > 0; leftover-- {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		cents[best]++
+		remainders[best] = -1
+	}
+
+	for i := range cents {
+		result[i] = float64(cents[i]) / scale
+	}
+	return result
+}
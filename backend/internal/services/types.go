@@ -9,16 +9,30 @@ import (
 type CreateGroupRequest struct {
 	Name             string   `json:"name"`
 	Currency         string   `json:"currency"`
+	Description      string   `json:"description,omitempty"`
+	AmountDisplay    string   `json:"amount_display,omitempty"` // "cents" (default) or "whole"
 	ParticipantNames []string `json:"participant_names"`
+	SlugStyle        string   `json:"slug_style,omitempty"` // "hex" or "words"; empty uses the server default
 }
 
 type CreateGroupResponse struct {
 	Group        *Group         `json:"group"`
 	Participants []*Participant `json:"participants"`
+	EditToken    string         `json:"edit_token"` // Only returned here and by RotateEditToken
+}
+
+type RotateEditTokenRequest struct {
+	UrlSlug   string `json:"url_slug"`
+	EditToken string `json:"edit_token"`
+}
+
+type RotateEditTokenResponse struct {
+	EditToken string `json:"edit_token"`
 }
 
 type GetGroupRequest struct {
-	UrlSlug string `json:"url_slug"`
+	UrlSlug string `json:"url_slug,omitempty"`
+	GroupId int32  `json:"group_id,omitempty"`
 }
 
 type GetGroupResponse struct {
@@ -27,15 +41,197 @@ type GetGroupResponse struct {
 }
 
 type UpdateGroupRequest struct {
-	Name          string `json:"name"`
-	Currency      string `json:"currency"`
-	ParticipantId int32  `json:"participant_id"`
+	Name            string  `json:"name"`
+	Currency        string  `json:"currency"`
+	Description     *string `json:"description,omitempty"`      // nil keeps the current description
+	AmountDisplay   *string `json:"amount_display,omitempty"`   // nil keeps the current display mode
+	BankerId        *int32  `json:"banker_id,omitempty"`        // nil keeps the current banker, 0 removes it
+	SimplifyDebts   *bool   `json:"simplify_debts,omitempty"`   // nil keeps the current setting
+	RotateRemainder *bool   `json:"rotate_remainder,omitempty"` // nil keeps the current setting
+	ParticipantId   int32   `json:"participant_id"`
 }
 
 type UpdateGroupResponse struct {
 	Group *Group `json:"group"`
 }
 
+type GetGroupMetaRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+type GetGroupMetaResponse struct {
+	Group *Group `json:"group"`
+}
+
+type GroupExistsRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+type GroupExistsResponse struct {
+	Exists bool `json:"exists"`
+}
+
+type GetGroupVersionRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+type GetGroupVersionResponse struct {
+	Version string `json:"version"` // Changes whenever the group, its participants, expenses or payments change
+}
+
+type GetGroupStatisticsRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// ParticipantStatistics is one participant's share of a group's spending
+type ParticipantStatistics struct {
+	ParticipantId     int32   `json:"participant_id"`
+	Name              string  `json:"name"`
+	TotalPaid         float64 `json:"total_paid"`          // Sum of expenses they paid for
+	TotalConsumed     float64 `json:"total_consumed"`      // Sum of their split amounts
+	PercentOfPaid     float64 `json:"percent_of_paid"`     // TotalPaid as a percentage of all spending
+	PercentOfConsumed float64 `json:"percent_of_consumed"` // TotalConsumed as a percentage of all consumption
+}
+
+type GetGroupStatisticsResponse struct {
+	Currency      string                   `json:"currency"`
+	ExpenseCount  int64                    `json:"expense_count"`
+	TotalSpent    float64                  `json:"total_spent"`
+	TotalConsumed float64                  `json:"total_consumed"`
+	Participants  []*ParticipantStatistics `json:"participants"`
+}
+
+type GetGroupSummaryMarkdownRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+type GetGroupSummaryMarkdownResponse struct {
+	Markdown string `json:"markdown"`
+}
+
+type GetExpensesByPayerRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// PayerTotals is how many of a group's expenses one participant fronted, and for how much
+type PayerTotals struct {
+	ParticipantId int32   `json:"participant_id"`
+	Name          string  `json:"name"`
+	ExpenseCount  int64   `json:"expense_count"`
+	TotalPaid     float64 `json:"total_paid"`
+}
+
+type GetExpensesByPayerResponse struct {
+	Currency string         `json:"currency"`
+	Payers   []*PayerTotals `json:"payers"` // Every participant, most paid first
+}
+
+// Spending time-series bucket sizes
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week" // Weeks start on Monday
+	BucketMonth = "month"
+)
+
+type GetSpendingTimeSeriesRequest struct {
+	UrlSlug string `json:"url_slug"`
+	Bucket  string `json:"bucket"` // BucketDay, BucketWeek or BucketMonth; empty means month
+}
+
+// SpendingBucket is the total cost of the expenses created within one period
+type SpendingBucket struct {
+	Period string  `json:"period"` // First day of the period, YYYY-MM-DD (UTC)
+	Total  float64 `json:"total"`
+}
+
+type GetSpendingTimeSeriesResponse struct {
+	Currency string            `json:"currency"`
+	Bucket   string            `json:"bucket"`
+	Buckets  []*SpendingBucket `json:"buckets"`
+}
+
+type GetParticipantFairShareRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+type GetParticipantFairShareResponse struct {
+	Currency   string  `json:"currency"`
+	GroupTotal float64 `json:"group_total"` // Sum of all the group's expenses
+	FairShare  float64 `json:"fair_share"`  // Sum of the participant's split amounts
+	Paid       float64 `json:"paid"`        // Sum of expenses the participant paid for
+	Difference float64 `json:"difference"`  // Paid - FairShare: positive means ahead, negative means behind
+}
+
+type GetParticipantSpendingSummaryRequest struct {
+	UrlSlug       string     `json:"url_slug"`
+	ParticipantId int32      `json:"participant_id"`
+	From          *time.Time `json:"from,omitempty"` // Inclusive; nil for no lower bound
+	To            *time.Time `json:"to,omitempty"`   // Exclusive; nil for no upper bound
+}
+
+type GetParticipantSpendingSummaryResponse struct {
+	Currency string     `json:"currency"`
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+	Consumed float64    `json:"consumed"` // Sum of the participant's split amounts
+	Paid     float64    `json:"paid"`     // Sum of expenses the participant paid for
+	Net      float64    `json:"net"`      // Paid - Consumed
+}
+
+type GetGroupDiagnosticsRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// Kinds of anomalies reported by GetGroupDiagnostics
+const (
+	AnomalySplitSumMismatch   = "split_sum_mismatch"  // An expense's splits don't add up to its cost
+	AnomalyMissingParticipant = "missing_participant" // An expense, split or payment refers to someone who is not in the group
+	AnomalyUnbalancedLedger   = "unbalanced_ledger"   // Net balances don't add up to zero
+	AnomalyDebtMismatch       = "debt_mismatch"       // Stored debts don't settle a participant's balance
+	AnomalyOverpayment        = "overpayment"         // A payment was larger than what the payer owed the payee at the time
+)
+
+// GroupAnomaly is one problem found in a group's data
+type GroupAnomaly struct {
+	Kind       string  `json:"kind"`
+	EntityType string  `json:"entity_type"` // "expense", "split", "payment", "participant" or "group"
+	EntityId   int32   `json:"entity_id"`
+	Amount     float64 `json:"amount,omitempty"` // How far off the numbers are, where that applies
+	Message    string  `json:"message"`
+}
+
+type GetGroupDiagnosticsResponse struct {
+	Currency  string          `json:"currency"`
+	Healthy   bool            `json:"healthy"`
+	Anomalies []*GroupAnomaly `json:"anomalies"`
+}
+
+type ResetGroupRequest struct {
+	UrlSlug   string `json:"url_slug"`
+	EditToken string `json:"-"` // Sent in the X-Edit-Token header
+}
+
+type ResetGroupResponse struct {
+	Group        *Group         `json:"group"`
+	Participants []*Participant `json:"participants"`
+}
+
+type MergeGroupsRequest struct {
+	SourceSlug      string `json:"source_slug"`
+	SourceEditToken string `json:"source_edit_token"`
+	TargetSlug      string `json:"-"`
+	EditToken       string `json:"-"` // The target's token, sent in the X-Edit-Token header
+}
+
+type MergeGroupsResponse struct {
+	Group               *Group         `json:"group"`
+	Participants        []*Participant `json:"participants"`
+	ParticipantsCreated int32          `json:"participants_created"`
+	ExpensesMoved       int32          `json:"expenses_moved"`
+	PaymentsMoved       int32          `json:"payments_moved"`
+}
+
 // Request and Response types for Participant operations
 type AddParticipantRequest struct {
 	Name    string `json:"name"`
@@ -46,6 +242,43 @@ type AddParticipantResponse struct {
 	Participant *Participant `json:"participant"`
 }
 
+type AddParticipantsRequest struct {
+	UrlSlug string   `json:"url_slug"`
+	Names   []string `json:"names"`
+}
+
+type AddParticipantsResponse struct {
+	Participants []*Participant `json:"participants"`
+}
+
+type ListParticipantsRequest struct {
+	UrlSlug string `json:"url_slug"`
+	Sort    string `json:"sort"` // "" orders by participant ID, "balance" by net balance, highest first
+}
+
+// ParticipantWithBalance is a participant with their live net balance
+type ParticipantWithBalance struct {
+	Id         int32   `json:"id"`
+	Name       string  `json:"name"`
+	GroupId    int32   `json:"group_id"`
+	NetBalance float64 `json:"net_balance"` // Positive: owed money, negative: owes money
+}
+
+type ListParticipantsResponse struct {
+	Currency     string                    `json:"currency"`
+	Participants []*ParticipantWithBalance `json:"participants"`
+}
+
+type VerifyParticipantRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+type VerifyParticipantResponse struct {
+	Exists      bool         `json:"exists"`
+	Participant *Participant `json:"participant"` // Current name, which may differ from what the client remembers
+}
+
 type UpdateParticipantRequest struct {
 	Name          string `json:"name"`
 	ParticipantId int32  `json:"participant_id"`
@@ -61,7 +294,8 @@ type DeleteParticipantRequest struct {
 
 // Request and Response types for Expense operations
 type GetExpensesByGroupRequest struct {
-	GroupId int32 `json:"group_id"`
+	GroupId       int32 `json:"group_id"`
+	IncludeSplits bool  `json:"include_splits"` // Embed each expense's splits in the response
 }
 
 type GetExpensesByGroupResponse struct {
@@ -69,13 +303,42 @@ type GetExpensesByGroupResponse struct {
 }
 
 type CreateExpenseRequest struct {
-	Expense *Expense `json:"expense"`
-	Splits  []*Split `json:"splits"`
+	Expense                *Expense `json:"expense"`
+	Splits                 []*Split `json:"splits"`
+	RemainderParticipantId int32    `json:"remainder_participant_id,omitempty"` // Who absorbs leftover cents
+	SplitTolerance         *float64 `json:"split_tolerance,omitempty"`          // Allowed gap between split amounts and cost; defaults to one minor unit
+	AllParticipants        bool     `json:"all_participants,omitempty"`         // Split equally among all current group members instead of listing Splits
 }
 
 type CreateExpenseResponse struct {
-	Expense *Expense `json:"expense"`
-	Splits  []*Split `json:"splits"`
+	Expense  *Expense `json:"expense"`
+	Splits   []*Split `json:"splits"`
+	Warnings []string `json:"warnings,omitempty"` // Client-sent split amounts the server ignored for a computed split type
+}
+
+type ValidateSplitRequest struct {
+	UrlSlug                string   `json:"url_slug"`
+	Cost                   float64  `json:"cost"`
+	Subtotal               float64  `json:"subtotal,omitempty"`
+	Tax                    float64  `json:"tax,omitempty"`
+	Tip                    float64  `json:"tip,omitempty"`
+	SplitType              string   `json:"split_type"`
+	PayerId                int32    `json:"payer_id,omitempty"` // Needed for "equal_excluding_payer"
+	Splits                 []*Split `json:"splits"`
+	RemainderParticipantId int32    `json:"remainder_participant_id,omitempty"`
+	SplitTolerance         *float64 `json:"split_tolerance,omitempty"`
+}
+
+// ComputedSplitAmount is what one participant would be charged if the expense were created
+type ComputedSplitAmount struct {
+	ParticipantId int32   `json:"participant_id"`
+	SplitAmount   float64 `json:"split_amount"`
+}
+
+type ValidateSplitResponse struct {
+	Valid           bool                   `json:"valid"`
+	Errors          []string               `json:"errors"`
+	ComputedAmounts []*ComputedSplitAmount `json:"computed_amounts"` // Empty when the split is invalid
 }
 
 type GetExpenseWithSplitsRequest struct {
@@ -83,8 +346,28 @@ type GetExpenseWithSplitsRequest struct {
 }
 
 type GetExpenseWithSplitsResponse struct {
-	Expense *Expense `json:"expense"`
-	Splits  []*Split `json:"splits"`
+	Expense   *Expense `json:"expense"`
+	PayerName string   `json:"payer_name"`
+	Splits    []*Split `json:"splits"`
+}
+
+type GetExpenseDetailRequest struct {
+	ExpenseId int32 `json:"expense_id"`
+}
+
+// ExpenseShare is one participant's share of an expense, with their name
+type ExpenseShare struct {
+	ParticipantId   int32   `json:"participant_id"`
+	ParticipantName string  `json:"participant_name"`
+	Amount          float64 `json:"amount"`
+}
+
+type GetExpenseDetailResponse struct {
+	Expense   *Expense        `json:"expense"`
+	PayerName string          `json:"payer_name"`
+	GroupName string          `json:"group_name"`
+	Currency  string          `json:"currency"`
+	Shares    []*ExpenseShare `json:"shares"`
 }
 
 type GetSplitsByGroupRequest struct {
@@ -107,19 +390,50 @@ type SplitWithNames struct {
 }
 
 type UpdateExpenseRequest struct {
-	Expense *Expense `json:"expense"`
-	Splits  []*Split `json:"splits"`
+	Expense                *Expense `json:"expense"`
+	Splits                 []*Split `json:"splits"`
+	RemainderParticipantId int32    `json:"remainder_participant_id,omitempty"` // Who absorbs leftover cents
+	SplitTolerance         *float64 `json:"split_tolerance,omitempty"`          // Allowed gap between split amounts and cost; defaults to one minor unit
 }
 
 type UpdateExpenseResponse struct {
+	Expense  *Expense `json:"expense"`
+	Splits   []*Split `json:"splits"`
+	Warnings []string `json:"warnings,omitempty"` // Client-sent split amounts the server ignored for a computed split type
+}
+
+type ResplitExpenseRequest struct {
+	ExpenseId      int32   `json:"expense_id"`
+	ParticipantIds []int32 `json:"participant_ids"`
+}
+
+type ResplitExpenseResponse struct {
 	Expense *Expense `json:"expense"`
 	Splits  []*Split `json:"splits"`
 }
 
+type SetExpenseLockedRequest struct {
+	ExpenseId int32 `json:"expense_id"`
+	Locked    bool  `json:"locked"`
+}
+
+type SetExpenseLockedResponse struct {
+	Expense *Expense `json:"expense"`
+}
+
 type DeleteExpenseRequest struct {
 	ExpenseId int32 `json:"expense_id"`
 }
 
+type DeleteExpensesRequest struct {
+	UrlSlug    string  `json:"url_slug"`
+	ExpenseIds []int32 `json:"expense_ids"`
+}
+
+type DeleteExpensesResponse struct {
+	DeletedCount int32 `json:"deleted_count"`
+}
+
 // Request and Response types for Debt operations
 type GetDebtsRequest struct {
 	GroupId int32  `json:"group_id,omitempty"`
@@ -128,11 +442,13 @@ type GetDebtsRequest struct {
 
 // Optimized debt data for the debts page
 type DebtPageData struct {
-	Id         int32   `json:"id"`
-	DebtAmount float64 `json:"debt_amount"`
-	DebtorName string  `json:"debtor_name"`
-	LenderName string  `json:"lender_name"`
-	Currency   string  `json:"currency"`
+	Id         int32     `json:"id"`
+	DebtAmount float64   `json:"debt_amount"`
+	DebtorName string    `json:"debtor_name"`
+	LenderName string    `json:"lender_name"`
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type GetDebtsPageDataResponse struct {
@@ -149,6 +465,202 @@ type CreatePaymentResponse struct {
 	Debt *Debt `json:"debt"`
 }
 
+type SettlePairRequest struct {
+	UrlSlug string  `json:"url_slug"`
+	PayerId int32   `json:"payer_id"`
+	PayeeId int32   `json:"payee_id"`
+	Amount  float64 `json:"amount"`
+}
+
+type SettlePairResponse struct {
+	Payment *Payment `json:"payment"`
+	Debt    *Debt    `json:"debt"` // Remaining debt between the pair, nil once fully settled
+}
+
+type GetDebtGraphRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// DebtGraphNode is a participant in the debt graph
+type DebtGraphNode struct {
+	ParticipantId int32   `json:"participant_id"`
+	Name          string  `json:"name"`
+	NetBalance    float64 `json:"net_balance"` // Positive: owed money, negative: owes money
+}
+
+// DebtGraphEdge is a simplified debt, pointing from debtor to lender
+type DebtGraphEdge struct {
+	DebtId     int32   `json:"debt_id"`
+	FromId     int32   `json:"from_id"`
+	FromName   string  `json:"from_name"`
+	ToId       int32   `json:"to_id"`
+	ToName     string  `json:"to_name"`
+	DebtAmount float64 `json:"debt_amount"`
+}
+
+type GetDebtGraphResponse struct {
+	Currency string           `json:"currency"`
+	Nodes    []*DebtGraphNode `json:"nodes"`
+	Edges    []*DebtGraphEdge `json:"edges"`
+}
+
+type GetSettlementComparisonRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// SettlementTransfer is one payment needed to settle up, from debtor to lender
+type SettlementTransfer struct {
+	FromId   int32   `json:"from_id"`
+	FromName string  `json:"from_name"`
+	ToId     int32   `json:"to_id"`
+	ToName   string  `json:"to_name"`
+	Amount   float64 `json:"amount"`
+}
+
+type GetSettlementComparisonResponse struct {
+	Currency            string                `json:"currency"`
+	RawDebtCount        int32                 `json:"raw_debt_count"`
+	SimplifiedDebtCount int32                 `json:"simplified_debt_count"`
+	TransactionsSaved   int32                 `json:"transactions_saved"`
+	RawDebts            []*SettlementTransfer `json:"raw_debts"`
+	SimplifiedDebts     []*SettlementTransfer `json:"simplified_debts"`
+}
+
+type GetSettlementCirclesRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// SettlementCircle is a set of participants whose balances cancel out, so they settle only among themselves
+type SettlementCircle struct {
+	ParticipantIds   []int32               `json:"participant_ids"`
+	ParticipantNames []string              `json:"participant_names"`
+	Transfers        []*SettlementTransfer `json:"transfers"`
+}
+
+type GetSettlementCirclesResponse struct {
+	Currency            string              `json:"currency"`
+	Circles             []*SettlementCircle `json:"circles"`
+	TransferCount       int32               `json:"transfer_count"`
+	GreedyTransferCount int32               `json:"greedy_transfer_count"` // Transfers the usual simplification needs for the same balances
+}
+
+type GetDebtCyclesRequest struct {
+	UrlSlug  string `json:"url_slug"`
+	Collapse bool   `json:"collapse"` // Net the cycles out of the returned debts
+}
+
+// DebtCycle is a chain of pairwise debts that leads back to where it started, e.g. A owes B, B owes C and C owes A
+type DebtCycle struct {
+	ParticipantIds   []int32  `json:"participant_ids"` // Each owes the next; the last owes the first
+	ParticipantNames []string `json:"participant_names"`
+	Amount           float64  `json:"amount"` // What netting the cycle out takes off every debt on it
+}
+
+type GetDebtCyclesResponse struct {
+	Currency  string                `json:"currency"`
+	Cycles    []*DebtCycle          `json:"cycles"`
+	Collapsed bool                  `json:"collapsed"`
+	Debts     []*SettlementTransfer `json:"debts"` // Pairwise debts, with the cycles netted out when collapsed
+}
+
+type GetDebtCountRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+type GetDebtCountResponse struct {
+	Count       int64   `json:"count"`
+	TotalAmount float64 `json:"total_amount"`
+	Currency    string  `json:"currency"`
+}
+
+type GetLargestDebtRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+type GetLargestDebtResponse struct {
+	DebtId     int32   `json:"debt_id"`
+	DebtorId   int32   `json:"debtor_id"`
+	DebtorName string  `json:"debtor_name"`
+	LenderId   int32   `json:"lender_id"`
+	LenderName string  `json:"lender_name"`
+	Amount     float64 `json:"amount"` // Still outstanding; stored debts are already net of payments
+	Currency   string  `json:"currency"`
+}
+
+type GetSettlementStepsRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// SettlementStep is one numbered instruction for settling up
+type SettlementStep struct {
+	Step        int32   `json:"step"`
+	FromId      int32   `json:"from_id"`
+	FromName    string  `json:"from_name"`
+	ToId        int32   `json:"to_id"`
+	ToName      string  `json:"to_name"`
+	Amount      float64 `json:"amount"`
+	Instruction string  `json:"instruction"` // e.g. "Charlie pays Alice $12.00"
+}
+
+type GetSettlementStepsResponse struct {
+	Currency string            `json:"currency"`
+	Steps    []*SettlementStep `json:"steps"`
+}
+
+type GetComputedBalancesRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// ComputedBalance is a participant's live net balance: positive when owed money, negative when owing
+type ComputedBalance struct {
+	ParticipantId int32   `json:"participant_id"`
+	Name          string  `json:"name"`
+	NetBalance    float64 `json:"net_balance"`
+}
+
+type GetComputedBalancesResponse struct {
+	Currency string             `json:"currency"`
+	Balances []*ComputedBalance `json:"balances"`
+}
+
+type GetDebtsAsOfRequest struct {
+	UrlSlug string    `json:"url_slug"`
+	AsOf    time.Time `json:"as_of"`
+}
+
+type GetDebtsAsOfResponse struct {
+	Currency string                `json:"currency"`
+	AsOf     time.Time             `json:"as_of"`
+	Debts    []*SettlementTransfer `json:"debts"`
+}
+
+// DebtPaymentItem is one payment against an existing debt in a batch
+type DebtPaymentItem struct {
+	DebtId int32   `json:"debt_id"`
+	Amount float64 `json:"amount"`
+}
+
+type PayMultipleDebtsRequest struct {
+	UrlSlug  string             `json:"url_slug"`
+	Payments []*DebtPaymentItem `json:"payments"`
+	Note     string             `json:"note"` // Optional, recorded on every payment, e.g. "settled after Italy trip"
+}
+
+type PayMultipleDebtsResponse struct {
+	Payments []*Payment `json:"payments"` // Payments recorded, in request order
+	Debts    []*Debt    `json:"debts"`    // All debts of the group after recalculation
+}
+
+type ProjectDebtsRequest struct {
+	UrlSlug  string             `json:"url_slug"`
+	Payments []*DebtPaymentItem `json:"payments"` // Hypothetical payments; none of them is recorded
+}
+
+type ProjectDebtsResponse struct {
+	Currency string                `json:"currency"`
+	Debts    []*SettlementTransfer `json:"debts"` // Simplified debts as they would be after the payments, largest first
+}
+
 type DeletePaymentRequest struct {
 	PaymentId int32 `json:"payment_id"`
 }
@@ -163,6 +675,177 @@ type GetPaymentsResponse struct {
 	Payments []*Payment `json:"payments"`
 }
 
+type GetPaymentsPageDataRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+// PaymentWithNames is a payment with payer and payee names resolved
+type PaymentWithNames struct {
+	Id        int32     `json:"id"`
+	PayerId   int32     `json:"payer_id"`
+	PayerName string    `json:"payer_name"`
+	PayeeId   int32     `json:"payee_id"`
+	PayeeName string    `json:"payee_name"`
+	Amount    float64   `json:"amount"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetPaymentsPageDataResponse struct {
+	Payments      []*PaymentWithNames `json:"payments"`
+	Currency      string              `json:"currency"`
+	AmountDisplay string              `json:"amount_display"` // The group's display mode, for formatting the amounts
+}
+
+type GetParticipantPaymentsRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+// ParticipantPayment is a payment seen from one participant's side
+type ParticipantPayment struct {
+	Id              int32     `json:"id"`
+	Direction       string    `json:"direction"` // "sent" or "received"
+	CounterpartId   int32     `json:"counterpart_id"`
+	CounterpartName string    `json:"counterpart_name"`
+	Amount          float64   `json:"amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type GetParticipantPaymentsResponse struct {
+	Payments []*ParticipantPayment `json:"payments"`
+	Currency string                `json:"currency"`
+}
+
+type GetParticipantOwedDebtsRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+// OwedDebt is a debt the participant still has to pay to a lender
+type OwedDebt struct {
+	Id         int32   `json:"id"`
+	LenderId   int32   `json:"lender_id"`
+	LenderName string  `json:"lender_name"`
+	DebtAmount float64 `json:"debt_amount"` // Remaining amount; payments already recorded are netted out
+}
+
+type GetParticipantOwedDebtsResponse struct {
+	Debts    []*OwedDebt `json:"debts"`
+	Total    float64     `json:"total"` // Sum of DebtAmount: everything the participant still owes
+	Currency string      `json:"currency"`
+}
+
+type GetParticipantSettlePlanRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+// SettlePlanPayment is one payment the participant makes to clear their debts
+type SettlePlanPayment struct {
+	PayeeId   int32   `json:"payee_id"`
+	PayeeName string  `json:"payee_name"`
+	Amount    float64 `json:"amount"`
+}
+
+type GetParticipantSettlePlanResponse struct {
+	Payments []*SettlePlanPayment `json:"payments"`
+	Total    float64              `json:"total"` // Sum of the payments: everything the participant owes
+	Currency string               `json:"currency"`
+}
+
+type GetParticipantStatementRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+// StatementSplit is the participant's share of one expense, with the expense and payer resolved
+type StatementSplit struct {
+	ExpenseId   int32     `json:"expense_id"`
+	ExpenseName string    `json:"expense_name"`
+	PayerId     int32     `json:"payer_id"`
+	PayerName   string    `json:"payer_name"`
+	Cost        float64   `json:"cost"`         // Full cost of the expense
+	SplitAmount float64   `json:"split_amount"` // The participant's share of it
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type GetParticipantStatementResponse struct {
+	Participant   *Participant          `json:"participant"`
+	Currency      string                `json:"currency"`
+	AmountDisplay string                `json:"amount_display"` // The group's display mode, for formatting the amounts
+	PaidExpenses  []*Expense            `json:"paid_expenses"`  // Expenses the participant paid for
+	Splits        []*StatementSplit     `json:"splits"`         // Expenses the participant shares in
+	Payments      []*ParticipantPayment `json:"payments"`       // Payments sent or received
+	TotalPaid     float64               `json:"total_paid"`
+	TotalShare    float64               `json:"total_share"`
+	NetBalance    float64               `json:"net_balance"` // Positive means the participant is owed money
+}
+
+type GetParticipantPaidExpensesRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+type GetParticipantPaidExpensesResponse struct {
+	Expenses []*Expense `json:"expenses"`
+	Total    float64    `json:"total"`
+	Currency string     `json:"currency"`
+}
+
+type GetParticipantBalanceRequest struct {
+	UrlSlug       string `json:"url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+}
+
+// BalanceEntry is one current debt seen from a participant's side
+type BalanceEntry struct {
+	ParticipantId   int32   `json:"participant_id"`
+	ParticipantName string  `json:"participant_name"`
+	Amount          float64 `json:"amount"`
+}
+
+type GetParticipantBalanceResponse struct {
+	NetBalance float64         `json:"net_balance"` // Positive: owed money, negative: owes money
+	Currency   string          `json:"currency"`
+	Owes       []*BalanceEntry `json:"owes"`    // People this participant owes
+	OwedBy     []*BalanceEntry `json:"owed_by"` // People who owe this participant
+}
+
+// Request and Response types for Activity operations
+type GetGroupActivityRequest struct {
+	UrlSlug string `json:"url_slug"`
+	Limit   int32  `json:"limit"`
+}
+
+type GetGroupActivityResponse struct {
+	Activities []*Activity `json:"activities"`
+}
+
+type UserGroupsActivityRequest struct {
+	GroupSlugs []string `json:"group_slugs"`
+	Limit      int32    `json:"limit"`
+}
+
+type UserGroupsActivityResponse struct {
+	Activities []*UserGroupActivity `json:"activities"`
+}
+
+// UserGroupActivity is an activity entry labelled with the group it belongs to
+type UserGroupActivity struct {
+	Activity
+	GroupUrlSlug string `json:"group_url_slug"`
+	GroupName    string `json:"group_name"`
+}
+
+type UndoRequest struct {
+	UrlSlug string `json:"url_slug"`
+}
+
+type UndoResponse struct {
+	Activity *Activity `json:"activity"` // The entry that was undone
+}
+
 // User Groups API types
 type UserGroupRequest struct {
 	GroupUrlSlug        string `json:"group_url_slug"`
@@ -171,7 +854,8 @@ type UserGroupRequest struct {
 }
 
 type UserGroupsSummaryRequest struct {
-	Groups []*UserGroupRequest `json:"groups"`
+	Groups        []*UserGroupRequest `json:"groups"`
+	IdentityToken string              `json:"-"` // Sent in X-Identity-Token; merges in that identity's pinned flags and sort order
 }
 
 type UserGroupSummary struct {
@@ -179,10 +863,78 @@ type UserGroupSummary struct {
 	GroupName    string  `json:"group_name"`
 	Currency     string  `json:"currency"`
 	NetBalance   float64 `json:"net_balance"`
+	Pinned       bool    `json:"pinned"`
+	SortOrder    int32   `json:"sort_order"`
+	Error        string  `json:"error,omitempty"` // Set when the balance couldn't be computed, e.g. the participant isn't in the group
+}
+
+// UserGroupPreference is how an identity wants one group shown in its group list
+type UserGroupPreference struct {
+	GroupUrlSlug string `json:"group_url_slug"`
+	Pinned       bool   `json:"pinned"`
+	SortOrder    int32  `json:"sort_order"`
+}
+
+type GetUserGroupPreferencesRequest struct {
+	IdentityToken string `json:"-"` // Sent in X-Identity-Token
+}
+
+type GetUserGroupPreferencesResponse struct {
+	Preferences []*UserGroupPreference `json:"preferences"`
+}
+
+type SetUserGroupPreferencesRequest struct {
+	IdentityToken string                 `json:"-"` // Sent in X-Identity-Token
+	Preferences   []*UserGroupPreference `json:"preferences"`
+}
+
+type SetUserGroupPreferencesResponse struct {
+	Preferences []*UserGroupPreference `json:"preferences"` // All of the identity's preferences after the update
+}
+
+// CurrencyTotal aggregates a user's balances across all their groups in one currency
+type CurrencyTotal struct {
+	Currency   string  `json:"currency"`
+	OwedToUser float64 `json:"owed_to_user"` // Sum of positive net balances
+	UserOwes   float64 `json:"user_owes"`    // Sum of negative net balances, as a positive amount
+	NetBalance float64 `json:"net_balance"`
 }
 
 type UserGroupsSummaryResponse struct {
 	Groups []*UserGroupSummary `json:"groups"`
+	Totals []*CurrencyTotal    `json:"totals"`
+}
+
+type CombinedSettlementRequest struct {
+	GroupSlugs []string            `json:"group_slugs"`
+	Aliases    []*ParticipantAlias `json:"aliases,omitempty"` // Overrides matching by name for the participants listed
+}
+
+// ParticipantAlias says which person a participant of one group is, when their names differ between groups
+type ParticipantAlias struct {
+	GroupUrlSlug  string `json:"group_url_slug"`
+	ParticipantId int32  `json:"participant_id"`
+	Name          string `json:"name"` // The person's name across all groups
+}
+
+// CombinedPosition is one person's net balance summed over all combined groups
+type CombinedPosition struct {
+	Name       string   `json:"name"`
+	NetBalance float64  `json:"net_balance"` // Positive means they are owed money
+	GroupSlugs []string `json:"group_slugs"` // Groups the person appears in
+}
+
+type CombinedSettlementResponse struct {
+	Currency  string              `json:"currency"`
+	People    []*CombinedPosition `json:"people"`
+	Transfers []*CombinedTransfer `json:"transfers"`
+}
+
+// CombinedTransfer is one payment of the combined settlement, between people rather than group participants
+type CombinedTransfer struct {
+	FromName string  `json:"from_name"`
+	ToName   string  `json:"to_name"`
+	Amount   float64 `json:"amount"`
 }
 
 type GroupParticipantsRequest struct {
@@ -198,13 +950,48 @@ type GroupParticipants struct {
 	Participants []*Participant `json:"participants"`
 }
 
+type FindParticipantRequest struct {
+	Name       string   `json:"name"`
+	GroupSlugs []string `json:"group_slugs"`
+}
+
+type FindParticipantResponse struct {
+	Matches []*ParticipantMatch `json:"matches"`
+}
+
+// ParticipantMatch is a participant whose name matched, with the group it belongs to
+type ParticipantMatch struct {
+	GroupUrlSlug    string `json:"group_url_slug"`
+	GroupName       string `json:"group_name"`
+	ParticipantId   int32  `json:"participant_id"`
+	ParticipantName string `json:"participant_name"`
+}
+
+// Request and Response types for admin operations
+type ListCurrenciesRequest struct{}
+
+// CurrencyUsage is how many groups use one currency
+type CurrencyUsage struct {
+	Currency   string `json:"currency"`
+	GroupCount int64  `json:"group_count"`
+}
+
+type ListCurrenciesResponse struct {
+	Currencies []*CurrencyUsage `json:"currencies"`
+}
+
 // Data types
 type Group struct {
-	Id        int32     `json:"id"`
-	Name      string    `json:"name"`
-	Currency  string    `json:"currency"`
-	UrlSlug   string    `json:"url_slug"`
-	CreatedAt time.Time `json:"created_at"`
+	Id              int32     `json:"id"`
+	Name            string    `json:"name"`
+	Currency        string    `json:"currency"`
+	Description     string    `json:"description"`
+	AmountDisplay   string    `json:"amount_display"`      // How amounts are formatted for people: "cents" or "whole"
+	BankerId        int32     `json:"banker_id,omitempty"` // Participant everyone settles with; 0 when debts are simplified normally
+	SimplifyDebts   bool      `json:"simplify_debts"`      // False stores raw pairwise debts instead of simplified ones
+	RotateRemainder bool      `json:"rotate_remainder"`    // True hands rounding remainders to each participant in turn
+	UrlSlug         string    `json:"url_slug"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 type Participant struct {
@@ -217,27 +1004,45 @@ type Expense struct {
 	Id        int32     `json:"id"`
 	Name      string    `json:"name"`
 	Cost      float64   `json:"cost"`
+	Subtotal  float64   `json:"subtotal"`
+	Tax       float64   `json:"tax"`
+	Tip       float64   `json:"tip"`
 	Emoji     string    `json:"emoji"`
 	PayerId   int32     `json:"payer_id"`
 	SplitType string    `json:"split_type"`
+	IsShared  *bool     `json:"is_shared,omitempty"` // Defaults to true when omitted from a request
+	Locked    bool      `json:"locked"`              // Read-only here; change it with SetExpenseLocked
 	GroupId   int32     `json:"group_id"`
 	CreatedAt time.Time `json:"created_at"`
+	Splits    []*Split  `json:"splits,omitempty"` // Only set when splits were requested
+}
+
+// Shared reports whether the expense is split with others; personal expenses are carried by the payer alone
+func (e *Expense) Shared() bool {
+	return e.IsShared == nil || *e.IsShared
 }
 
 type Split struct {
-	Id            int32   `json:"id"`
-	GroupId       int32   `json:"group_id"`
-	ExpenseId     int32   `json:"expense_id"`
-	ParticipantId int32   `json:"participant_id"`
-	SplitAmount   float64 `json:"split_amount"`
+	Id              int32   `json:"id"`
+	GroupId         int32   `json:"group_id"`
+	ExpenseId       int32   `json:"expense_id"`
+	ParticipantId   int32   `json:"participant_id"`
+	SplitAmount     float64 `json:"split_amount"`
+	Adjustment      float64 `json:"adjustment,omitempty"`       // "adjustment" splits only: added on top of the equal share
+	Weight          float64 `json:"weight,omitempty"`           // "weighted" splits only: units such as nights stayed
+	SharesRemainder bool    `json:"shares_remainder,omitempty"` // "mixed" splits only: shares the cost left after the exact amounts
+	// Only filled in where names are resolved, e.g. when fetching a single expense
+	ParticipantName string `json:"participant_name,omitempty"`
 }
 
 type Debt struct {
-	Id         int32   `json:"id"`
-	GroupId    int32   `json:"group_id"`
-	LenderId   int32   `json:"lender_id"`
-	DebtorId   int32   `json:"debtor_id"`
-	DebtAmount float64 `json:"debt_amount"`
+	Id         int32     `json:"id"`
+	GroupId    int32     `json:"group_id"`
+	LenderId   int32     `json:"lender_id"`
+	DebtorId   int32     `json:"debtor_id"`
+	DebtAmount float64   `json:"debt_amount"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type Payment struct {
@@ -246,17 +1051,41 @@ type Payment struct {
 	PayerId   int32     `json:"payer_id"`
 	PayeeId   int32     `json:"payee_id"`
 	Amount    float64   `json:"amount"`
+	Note      string    `json:"note,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type Activity struct {
+	Id          int32     `json:"id"`
+	GroupId     int32     `json:"group_id"`
+	Action      string    `json:"action"`
+	EntityId    int32     `json:"entity_id"`
+	Description string    `json:"description"`
+	Undone      bool      `json:"undone"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // Conversion functions from database models to service types
 func GroupFromDB(dbGroup *database.Group) *Group {
+	amountDisplay := dbGroup.AmountDisplay
+	if amountDisplay == "" {
+		amountDisplay = AmountDisplayCents
+	}
+	var bankerID int32
+	if dbGroup.BankerID != nil {
+		bankerID = int32(*dbGroup.BankerID)
+	}
 	return &Group{
-		Id:        int32(dbGroup.ID),
-		Name:      dbGroup.Name,
-		Currency:  dbGroup.Currency,
-		UrlSlug:   dbGroup.URLSlug,
-		CreatedAt: dbGroup.CreatedAt,
+		Id:              int32(dbGroup.ID),
+		Name:            dbGroup.Name,
+		Currency:        dbGroup.Currency,
+		Description:     dbGroup.Description,
+		AmountDisplay:   amountDisplay,
+		BankerId:        bankerID,
+		SimplifyDebts:   dbGroup.SimplifyDebts,
+		RotateRemainder: dbGroup.RotateRemainder,
+		UrlSlug:         dbGroup.URLSlug,
+		CreatedAt:       dbGroup.CreatedAt,
 	}
 }
 
@@ -269,25 +1098,46 @@ func ParticipantFromDB(dbParticipant *database.Participant) *Participant {
 }
 
 func ExpenseFromDB(dbExpense *database.Expense) *Expense {
+	isShared := dbExpense.IsShared == nil || *dbExpense.IsShared
 	return &Expense{
 		Id:        int32(dbExpense.ID),
 		Name:      dbExpense.Name,
 		Cost:      dbExpense.Cost,
+		Subtotal:  dbExpense.Subtotal,
+		Tax:       dbExpense.Tax,
+		Tip:       dbExpense.Tip,
 		Emoji:     dbExpense.Emoji,
 		PayerId:   int32(dbExpense.PayerID),
 		SplitType: dbExpense.SplitType,
+		IsShared:  &isShared,
+		Locked:    dbExpense.Locked,
 		GroupId:   int32(dbExpense.GroupID),
 		CreatedAt: dbExpense.CreatedAt,
 	}
 }
 
+func ActivityFromDB(dbActivity *database.Activity) *Activity {
+	return &Activity{
+		Id:          int32(dbActivity.ID),
+		GroupId:     int32(dbActivity.GroupID),
+		Action:      dbActivity.Action,
+		EntityId:    int32(dbActivity.EntityID),
+		Description: dbActivity.Description,
+		Undone:      dbActivity.Undone,
+		CreatedAt:   dbActivity.CreatedAt,
+	}
+}
+
 func SplitFromDB(dbSplit *database.Split) *Split {
 	return &Split{
-		Id:            int32(dbSplit.ID),
-		GroupId:       int32(dbSplit.GroupID),
-		ExpenseId:     int32(dbSplit.ExpenseID),
-		ParticipantId: int32(dbSplit.ParticipantID),
-		SplitAmount:   dbSplit.SplitAmount,
+		Id:              int32(dbSplit.ID),
+		GroupId:         int32(dbSplit.GroupID),
+		ExpenseId:       int32(dbSplit.ExpenseID),
+		ParticipantId:   int32(dbSplit.ParticipantID),
+		SplitAmount:     dbSplit.SplitAmount,
+		Adjustment:      dbSplit.Adjustment,
+		Weight:          dbSplit.Weight,
+		SharesRemainder: dbSplit.SharesRemainder,
 	}
 }
 
@@ -298,5 +1148,19 @@ func DebtFromDB(dbDebt *database.Debt) *Debt {
 		LenderId:   int32(dbDebt.LenderID),
 		DebtorId:   int32(dbDebt.DebtorID),
 		DebtAmount: dbDebt.DebtAmount,
+		CreatedAt:  dbDebt.CreatedAt,
+		UpdatedAt:  dbDebt.UpdatedAt,
+	}
+}
+
+func PaymentFromDB(dbPayment *database.Payment) *Payment {
+	return &Payment{
+		Id:        int32(dbPayment.ID),
+		GroupId:   int32(dbPayment.GroupID),
+		PayerId:   int32(dbPayment.PayerID),
+		PayeeId:   int32(dbPayment.PayeeID),
+		Amount:    dbPayment.Amount,
+		Note:      dbPayment.Note,
+		CreatedAt: dbPayment.CreatedAt,
 	}
 }
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"freesplit/internal/database"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndo_RemovesLastCreatedExpenseAndRestoresDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewActivityService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID)
+
+	var debtsBefore []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debtsBefore)
+
+	created := seedEqualExpense(t, db, group.ID, bob.ID, 100, alice.ID, bob.ID)
+
+	// Act
+	resp, err := service.Undo(context.Background(), &services.UndoRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, services.ActionExpenseCreated, resp.Activity.Action)
+	assert.Equal(t, created.Expense.Id, resp.Activity.EntityId)
+	assert.True(t, resp.Activity.Undone)
+
+	var debtsAfter []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debtsAfter)
+	assert.Len(t, debtsAfter, len(debtsBefore))
+	assert.Equal(t, debtsBefore[0].DebtorID, debtsAfter[0].DebtorID)
+	assert.Equal(t, debtsBefore[0].DebtAmount, debtsAfter[0].DebtAmount)
+
+	var splitCount int64
+	db.Model(&database.Split{}).Where("expense_id = ?", created.Expense.Id).Count(&splitCount)
+	assert.Zero(t, splitCount)
+}
+
+func TestUndo_ReturnsErrorWhenNothingToUndo(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewActivityService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip"})
+
+	// Act
+	_, err := service.Undo(context.Background(), &services.UndoRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.EqualError(t, err, "nothing to undo")
+}
+
+func TestGetGroupActivity_ListsNewestFirst(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewActivityService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID)
+
+	var debt database.Debt
+	db.Where("group_id = ?", group.ID).First(&debt)
+	_, err := services.NewDebtService(db).CreatePayment(context.Background(), &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 5})
+	assert.NoError(t, err)
+
+	// Act
+	resp, err := service.GetGroupActivity(context.Background(), &services.GetGroupActivityRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Activities, 2)
+	assert.Equal(t, services.ActionPaymentCreated, resp.Activities[0].Action)
+	assert.Equal(t, "Recorded payment of 5.00 from Bob to Alice", resp.Activities[0].Description)
+	assert.Equal(t, services.ActionExpenseCreated, resp.Activities[1].Action)
+}
+
+func TestGetUserGroupsActivity_MergesGroupsNewestFirst(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewActivityService(db)
+	trip := database.Group{Name: "Trip", URLSlug: "trip"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat"}
+	db.Create(&trip)
+	db.Create(&flat)
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	db.Create(&database.Activity{GroupID: trip.ID, Action: services.ActionExpenseCreated, Description: "trip 1", CreatedAt: start})
+	db.Create(&database.Activity{GroupID: flat.ID, Action: services.ActionExpenseCreated, Description: "flat 1", CreatedAt: start.Add(time.Hour)})
+	db.Create(&database.Activity{GroupID: trip.ID, Action: services.ActionPaymentCreated, Description: "trip 2", CreatedAt: start.Add(2 * time.Hour)})
+	db.Create(&database.Activity{GroupID: flat.ID, Action: services.ActionExpenseDeleted, Description: "flat 2", CreatedAt: start.Add(3 * time.Hour)})
+
+	// Act
+	resp, err := service.GetUserGroupsActivity(context.Background(), &services.UserGroupsActivityRequest{
+		GroupSlugs: []string{"trip", "flat", "missing"},
+		Limit:      3,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Activities, 3)
+	assert.Equal(t, "flat 2", resp.Activities[0].Description)
+	assert.Equal(t, "flat", resp.Activities[0].GroupUrlSlug)
+	assert.Equal(t, "Flat", resp.Activities[0].GroupName)
+	assert.Equal(t, "trip 2", resp.Activities[1].Description)
+	assert.Equal(t, "trip", resp.Activities[1].GroupUrlSlug)
+	assert.Equal(t, "flat 1", resp.Activities[2].Description)
+}
+
+func TestGetUserGroupsActivity_ReturnsEmptyFeedForUnknownGroups(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewActivityService(db)
+
+	// Act
+	resp, err := service.GetUserGroupsActivity(context.Background(), &services.UserGroupsActivityRequest{GroupSlugs: []string{"gone"}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Activities)
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"freesplit/internal/database"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCurrencies_CountsGroupsPerCurrency(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewAdminService(db)
+	db.Create(&database.Group{Name: "Paris", URLSlug: "paris", Currency: "EUR"})
+	db.Create(&database.Group{Name: "Rome", URLSlug: "rome", Currency: "EUR"})
+	db.Create(&database.Group{Name: "Berlin", URLSlug: "berlin", Currency: "EUR", State: "archived"})
+	db.Create(&database.Group{Name: "NYC", URLSlug: "nyc", Currency: "USD"})
+
+	// Act
+	resp, err := service.ListCurrencies(context.Background(), &services.ListCurrenciesRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []*services.CurrencyUsage{
+		{Currency: "EUR", GroupCount: 3},
+		{Currency: "USD", GroupCount: 1},
+	}, resp.Currencies)
+}
+
+func TestListCurrencies_ReturnsEmptyListWithoutGroups(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewAdminService(db)
+
+	// Act
+	resp, err := service.ListCurrencies(context.Background(), &services.ListCurrenciesRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.Currencies)
+	assert.Empty(t, resp.Currencies)
+}
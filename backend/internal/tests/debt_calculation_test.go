@@ -0,0 +1,297 @@
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"freesplit/internal/database"
+	"freesplit/internal/logger"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestAmountThreshold_IsHalfTheCurrencyMinorUnit(t *testing.T) {
+	assert.Equal(t, 0.5, services.AmountThreshold("JPY"))
+	assert.Equal(t, 0.005, services.AmountThreshold("USD"))
+	assert.InDelta(t, 0.0005, services.AmountThreshold("KWD"), 1e-12)
+	assert.Equal(t, 0.005, services.AmountThreshold(""))
+}
+
+func TestMinorUnits_RoundTripThreeDecimalAndLargeAmounts(t *testing.T) {
+	assert.Equal(t, int64(1235), services.ToMinorUnits(1.235, "KWD"))
+	assert.Equal(t, 1.235, services.FromMinorUnits(1235, "KWD"))
+	assert.Equal(t, int64(1234), services.ToMinorUnits(12.34, "USD"))
+	assert.Equal(t, int64(1500), services.ToMinorUnits(1500, "JPY"))
+
+	large := 250000000.125
+	assert.Equal(t, large, services.FromMinorUnits(services.ToMinorUnits(large, "KWD"), "KWD"))
+}
+
+// seedSmallBalanceGroup creates a group where Bob owes Alice 0.4 in the given currency
+func seedSmallBalanceGroup(t *testing.T, db *gorm.DB, currency string) database.Group {
+	group := database.Group{Name: "Trip", URLSlug: "trip-" + currency, Currency: currency}
+	assert.NoError(t, db.Create(&group).Error)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	expense := database.Expense{Name: "Gum", Cost: 0.4, Emoji: "🍬", PayerID: alice.ID, GroupID: group.ID, SplitType: "amount"}
+	assert.NoError(t, db.Create(&expense).Error)
+	db.Create(&database.Split{GroupID: group.ID, ExpenseID: expense.ID, ParticipantID: bob.ID, SplitAmount: 0.4})
+
+	return group
+}
+
+func TestFormatAmount_UsesCurrencyDecimalsAndSymbol(t *testing.T) {
+	assert.Equal(t, "$12.00", services.FormatAmount(12, "USD"))
+	assert.Equal(t, "¥1500", services.FormatAmount(1500, "JPY"))
+	assert.Equal(t, "1.235 KWD", services.FormatAmount(1.235, "KWD"))
+	assert.Equal(t, "2.50 CHF", services.FormatAmount(2.5, "chf"))
+	assert.Equal(t, "-€3.10", services.FormatAmount(-3.1, "EUR"))
+}
+
+func TestFormatDisplayAmount_WholeRoundsHalvesAwayFromZero(t *testing.T) {
+	assert.Equal(t, "$11", services.FormatDisplayAmount(10.50, "USD", services.AmountDisplayWhole))
+	assert.Equal(t, "$10", services.FormatDisplayAmount(10.49, "USD", services.AmountDisplayWhole))
+	assert.Equal(t, "-€3", services.FormatDisplayAmount(-2.5, "EUR", services.AmountDisplayWhole))
+	assert.Equal(t, "$10.50", services.FormatDisplayAmount(10.50, "USD", services.AmountDisplayCents))
+}
+
+func TestCalculateNetDebts_IgnoresSubUnitBalanceInZeroDecimalCurrency(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := seedSmallBalanceGroup(t, db, "JPY")
+
+	// Act
+	debts, err := services.CalculateNetDebts(db, group.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, debts)
+}
+
+func TestCalculateNetDebts_KeepsSameBalanceInTwoDecimalCurrency(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := seedSmallBalanceGroup(t, db, "USD")
+
+	// Act
+	debts, err := services.CalculateNetDebts(db, group.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, debts, 1)
+	assert.InDelta(t, 0.4, debts[0].DebtAmount, 1e-9)
+}
+
+func TestCreateExpense_EqualSplitRoundsToWholeYen(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := database.Group{Name: "Tokyo", URLSlug: "tokyo", Currency: "JPY"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+
+	// Act
+	resp := seedEqualExpense(t, db, group.ID, alice.ID, 1000, alice.ID, bob.ID, carol.ID)
+
+	// Assert
+	assert.Len(t, resp.Splits, 3)
+	assert.Equal(t, 333.0, resp.Splits[0].SplitAmount)
+	assert.Equal(t, 333.0, resp.Splits[1].SplitAmount)
+	assert.Equal(t, 334.0, resp.Splits[2].SplitAmount)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestCheckDebtCountInvariant_RejectsMoreThanParticipantsMinusOne(t *testing.T) {
+	debts := []database.Debt{{LenderID: 1, DebtorID: 2}, {LenderID: 1, DebtorID: 3}}
+
+	assert.NoError(t, services.CheckDebtCountInvariant(debts, 3))
+	assert.Error(t, services.CheckDebtCountInvariant(debts, 2))
+	assert.NoError(t, services.CheckDebtCountInvariant(nil, 0))
+}
+
+func TestCheckBalanceConservation_RejectsBalancesThatDontCancelOut(t *testing.T) {
+	assert.NoError(t, services.CheckBalanceConservation(map[uint]float64{1: 12, 2: 6, 3: -18}, "USD"))
+	assert.NoError(t, services.CheckBalanceConservation(map[uint]float64{1: 10.004, 2: -10}, "USD"))
+	assert.Error(t, services.CheckBalanceConservation(map[uint]float64{1: 12, 2: 6, 3: -17.99}, "USD"))
+	assert.NoError(t, services.CheckBalanceConservation(nil, "USD"))
+}
+
+func TestCalculateNetDebts_NeverProducesMoreThanParticipantsMinusOneDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	rng := rand.New(rand.NewSource(42))
+	// Debug level also enables the runtime invariant check inside CalculateNetDebts
+	previousLevel := logger.Default().Level()
+	logger.SetLevel(logger.LevelDebug)
+	defer logger.SetLevel(previousLevel)
+
+	for iteration := 0; iteration < 50; iteration++ {
+		group := database.Group{Name: "Random", URLSlug: fmt.Sprintf("random-%d", iteration), Currency: "USD"}
+		assert.NoError(t, db.Create(&group).Error)
+
+		participantCount := 2 + rng.Intn(7)
+		participants := make([]database.Participant, participantCount)
+		for i := range participants {
+			participants[i] = database.Participant{Name: fmt.Sprintf("P%d", i), GroupID: group.ID}
+			assert.NoError(t, db.Create(&participants[i]).Error)
+		}
+
+		// Every expense's splits add up exactly to its cost, so the group is balanced
+		expenseCount := 1 + rng.Intn(10)
+		for e := 0; e < expenseCount; e++ {
+			payer := participants[rng.Intn(participantCount)]
+			shares := make([]int, participantCount)
+			totalCents := 0
+			for i := range shares {
+				if rng.Intn(3) > 0 {
+					shares[i] = rng.Intn(10000)
+					totalCents += shares[i]
+				}
+			}
+			if totalCents == 0 {
+				continue
+			}
+
+			expense := database.Expense{Name: "Random", Cost: float64(totalCents) / 100, Emoji: "🎲", PayerID: payer.ID, GroupID: group.ID, SplitType: "amount"}
+			assert.NoError(t, db.Create(&expense).Error)
+			for i, cents := range shares {
+				if cents == 0 {
+					continue
+				}
+				db.Create(&database.Split{GroupID: group.ID, ExpenseID: expense.ID, ParticipantID: participants[i].ID, SplitAmount: float64(cents) / 100})
+			}
+		}
+
+		// Act
+		debts, err := services.CalculateNetDebts(db, group.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, services.CheckDebtCountInvariant(debts, participantCount), "iteration %d", iteration)
+		balances, _, err := services.CalculateBalances(db, group.ID)
+		assert.NoError(t, err)
+		assert.NoError(t, services.CheckBalanceConservation(balances, group.Currency), "iteration %d", iteration)
+	}
+}
+
+func TestCalculateNetDebts_MatchesLargestBalancesFirst(t *testing.T) {
+	// Arrange: matching the small creditor with the big debtor first would need three debts
+	// (Sam <- Dana 5, Carl <- Dana 5, Carl <- Tom 5); largest-first needs only two
+	db := setupTestDB()
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	sam := database.Participant{Name: "Sam", GroupID: group.ID}
+	dana := database.Participant{Name: "Dana", GroupID: group.ID}
+	carl := database.Participant{Name: "Carl", GroupID: group.ID}
+	tom := database.Participant{Name: "Tom", GroupID: group.ID}
+	for _, p := range []*database.Participant{&sam, &dana, &carl, &tom} {
+		db.Create(p)
+	}
+
+	big := database.Expense{Name: "Hotel", Cost: 10, Emoji: "🏨", PayerID: carl.ID, GroupID: group.ID, SplitType: "amount"}
+	small := database.Expense{Name: "Taxi", Cost: 5, Emoji: "🚕", PayerID: sam.ID, GroupID: group.ID, SplitType: "amount"}
+	db.Create(&big)
+	db.Create(&small)
+	db.Create(&database.Split{GroupID: group.ID, ExpenseID: big.ID, ParticipantID: dana.ID, SplitAmount: 10})
+	db.Create(&database.Split{GroupID: group.ID, ExpenseID: small.ID, ParticipantID: tom.ID, SplitAmount: 5})
+
+	// Act: repeat to make sure the result doesn't depend on map iteration order
+	for i := 0; i < 20; i++ {
+		debts, err := services.CalculateNetDebts(db, group.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, debts, 2)
+		assert.Equal(t, carl.ID, debts[0].LenderID)
+		assert.Equal(t, dana.ID, debts[0].DebtorID)
+		assert.Equal(t, 10.0, debts[0].DebtAmount)
+		assert.Equal(t, sam.ID, debts[1].LenderID)
+		assert.Equal(t, tom.ID, debts[1].DebtorID)
+		assert.Equal(t, 5.0, debts[1].DebtAmount)
+	}
+}
+
+// countSplitQueries registers a callback counting SELECTs against the splits table
+func countSplitQueries(t *testing.T, db *gorm.DB) *int {
+	count := 0
+	err := db.Callback().Query().After("gorm:query").Register("test:count_split_queries", func(tx *gorm.DB) {
+		if tx.Statement.Table == "splits" {
+			count++
+		}
+	})
+	assert.NoError(t, err)
+	return &count
+}
+
+func TestCalculateNetDebts_LoadsSplitsInOneQuery(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	for i := 0; i < 25; i++ {
+		seedEqualExpense(t, db, group.ID, alice.ID, 10, alice.ID, bob.ID)
+	}
+	splitQueries := countSplitQueries(t, db)
+
+	// Act
+	debts, err := services.CalculateNetDebts(db, group.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *splitQueries)
+	assert.Len(t, debts, 1)
+	assert.Equal(t, 125.0, debts[0].DebtAmount)
+}
+
+func BenchmarkCalculateNetDebts(b *testing.B) {
+	db := setupTestDB()
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	participants := make([]database.Participant, 20)
+	for i := range participants {
+		participants[i] = database.Participant{Name: fmt.Sprintf("P%d", i), GroupID: group.ID}
+		db.Create(&participants[i])
+	}
+	for e := 0; e < 200; e++ {
+		expense := database.Expense{Name: "Expense", Cost: 200, Emoji: "💸", PayerID: participants[e%len(participants)].ID, GroupID: group.ID, SplitType: "amount"}
+		db.Create(&expense)
+		splits := make([]database.Split, len(participants))
+		for i, p := range participants {
+			splits[i] = database.Split{GroupID: group.ID, ExpenseID: expense.ID, ParticipantID: p.ID, SplitAmount: 10}
+		}
+		db.Create(&splits)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := services.CalculateNetDebts(db, group.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSettlementCircles_FindsMostZeroSumCircles(t *testing.T) {
+	// Arrange: 1 and 2 cancel out, as do 3, 4 and 5; 6 only rounds to zero
+	balances := map[uint]float64{1: 7.5, 2: -7.5, 3: 10, 4: -4, 5: -6, 6: 0.001}
+
+	// Act
+	circles := services.SettlementCircles(balances, "USD")
+
+	// Assert
+	assert.Equal(t, [][]uint{{1, 2}, {3, 4, 5}}, circles)
+}
@@ -2,7 +2,9 @@ package tests
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"freesplit/internal/database"
 	"freesplit/internal/services"
@@ -20,7 +22,7 @@ func setupTestDB() *gorm.DB {
 	}
 
 	// Auto-migrate the database
-	db.AutoMigrate(&database.Group{}, &database.Participant{}, &database.Expense{}, &database.Split{}, &database.Debt{}, &database.Payment{})
+	database.Migrate(db)
 
 	return db
 }
@@ -52,7 +54,7 @@ func TestGetDebts_ReturnsUnpaidDebtsForGroup(t *testing.T) {
 	req := &services.GetDebtsRequest{GroupId: int32(group.ID)}
 
 	// Act
-	result, err := service.GetDebts(ctx, req)
+	result, err := service.GetDebtsPageData(ctx, req)
 
 	// Assert
 	assert.NoError(t, err)
@@ -96,7 +98,7 @@ func TestGetDebts_ReturnsAllDebts(t *testing.T) {
 	req := &services.GetDebtsRequest{GroupId: int32(group.ID)}
 
 	// Act
-	result, err := service.GetDebts(ctx, req)
+	result, err := service.GetDebtsPageData(ctx, req)
 
 	// Assert
 	assert.NoError(t, err)
@@ -127,10 +129,10 @@ func TestUpdateDebtPaidAmount_UpdatesDebtWithValidAmount(t *testing.T) {
 	}
 	db.Create(&debt)
 
-	req := &services.UpdateDebtPaidAmountRequest{DebtId: int32(debt.ID), PaidAmount: 50.0}
+	req := &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 50.0}
 
 	// Act
-	result, err := service.UpdateDebtPaidAmount(ctx, req)
+	result, err := service.CreatePayment(ctx, req)
 
 	// Assert
 	assert.NoError(t, err)
@@ -147,10 +149,10 @@ func TestUpdateDebtPaidAmount_ReturnsErrorForInvalidDebtId(t *testing.T) {
 	db := setupTestDB()
 	service := services.NewDebtService(db)
 	ctx := context.Background()
-	req := &services.UpdateDebtPaidAmountRequest{DebtId: 0, PaidAmount: 50.0}
+	req := &services.CreatePaymentRequest{DebtId: 0, PaidAmount: 50.0}
 
 	// Act
-	result, err := service.UpdateDebtPaidAmount(ctx, req)
+	result, err := service.CreatePayment(ctx, req)
 
 	// Assert
 	assert.Error(t, err)
@@ -163,10 +165,10 @@ func TestUpdateDebtPaidAmount_ReturnsErrorForNegativePaidAmount(t *testing.T) {
 	db := setupTestDB()
 	service := services.NewDebtService(db)
 	ctx := context.Background()
-	req := &services.UpdateDebtPaidAmountRequest{DebtId: 1, PaidAmount: -10.0}
+	req := &services.CreatePaymentRequest{DebtId: 1, PaidAmount: -10.0}
 
 	// Act
-	result, err := service.UpdateDebtPaidAmount(ctx, req)
+	result, err := service.CreatePayment(ctx, req)
 
 	// Assert
 	assert.Error(t, err)
@@ -174,15 +176,40 @@ func TestUpdateDebtPaidAmount_ReturnsErrorForNegativePaidAmount(t *testing.T) {
 	assert.Contains(t, err.Error(), "paid amount cannot be negative")
 }
 
+func TestUpdateDebtPaidAmount_ReturnsErrorForZeroPaidAmount(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID)
+	var debt database.Debt
+	db.Where("group_id = ?", group.ID).First(&debt)
+
+	// Act
+	result, err := service.CreatePayment(context.Background(), &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 0})
+
+	// Assert
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "paid amount must be positive")
+	var payments int64
+	db.Model(&database.Payment{}).Count(&payments)
+	assert.Equal(t, int64(0), payments)
+}
+
 func TestUpdateDebtPaidAmount_ReturnsErrorWhenDebtNotFound(t *testing.T) {
 	// Arrange
 	db := setupTestDB()
 	service := services.NewDebtService(db)
 	ctx := context.Background()
-	req := &services.UpdateDebtPaidAmountRequest{DebtId: 999, PaidAmount: 50.0}
+	req := &services.CreatePaymentRequest{DebtId: 999, PaidAmount: 50.0}
 
 	// Act
-	result, err := service.UpdateDebtPaidAmount(ctx, req)
+	result, err := service.CreatePayment(ctx, req)
 
 	// Assert
 	assert.Error(t, err)
@@ -213,10 +240,10 @@ func TestUpdateDebtPaidAmount_ReturnsErrorWhenPaidAmountExceedsDebtAmount(t *tes
 	}
 	db.Create(&debt)
 
-	req := &services.UpdateDebtPaidAmountRequest{DebtId: int32(debt.ID), PaidAmount: 150.0}
+	req := &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 150.0}
 
 	// Act
-	result, err := service.UpdateDebtPaidAmount(ctx, req)
+	result, err := service.CreatePayment(ctx, req)
 
 	// Assert
 	assert.Error(t, err)
@@ -257,10 +284,10 @@ func TestUpdateDebtPaidAmount_RecordsPaymentWhenAmountIncreases(t *testing.T) {
 	}
 	db.Create(&previousPayment)
 
-	req := &services.UpdateDebtPaidAmountRequest{DebtId: int32(debt.ID), PaidAmount: 50.0}
+	req := &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 50.0}
 
 	// Act
-	result, err := service.UpdateDebtPaidAmount(ctx, req)
+	result, err := service.CreatePayment(ctx, req)
 
 	// Assert
 	assert.NoError(t, err)
@@ -307,10 +334,10 @@ func TestUpdateDebtPaidAmount_DoesNotRecordPaymentWhenAmountDecreases(t *testing
 	}
 	db.Create(&previousPayment)
 
-	req := &services.UpdateDebtPaidAmountRequest{DebtId: int32(debt.ID), PaidAmount: 25.0}
+	req := &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 25.0}
 
 	// Act
-	result, err := service.UpdateDebtPaidAmount(ctx, req)
+	result, err := service.CreatePayment(ctx, req)
 
 	// Assert
 	assert.NoError(t, err)
@@ -323,3 +350,1155 @@ func TestUpdateDebtPaidAmount_DoesNotRecordPaymentWhenAmountDecreases(t *testing
 		Select("COALESCE(SUM(amount), 0)").Scan(&totalPaid)
 	assert.Equal(t, 75.0, totalPaid)
 }
+
+func TestGetParticipantPayments_ReturnsSentAndReceivedPayments(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 20.0})
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: alice.ID, PayeeID: charlie.ID, Amount: 5.0})
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: charlie.ID, Amount: 7.0})
+
+	req := &services.GetParticipantPaymentsRequest{UrlSlug: group.URLSlug, ParticipantId: int32(alice.ID)}
+
+	// Act
+	result, err := service.GetParticipantPayments(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", result.Currency)
+	assert.Equal(t, 2, len(result.Payments))
+
+	byDirection := make(map[string]*services.ParticipantPayment)
+	for _, p := range result.Payments {
+		byDirection[p.Direction] = p
+	}
+	assert.Equal(t, "Bob", byDirection["received"].CounterpartName)
+	assert.Equal(t, 20.0, byDirection["received"].Amount)
+	assert.Equal(t, "Charlie", byDirection["sent"].CounterpartName)
+	assert.Equal(t, 5.0, byDirection["sent"].Amount)
+}
+
+func TestGetParticipantOwedDebts_ExcludesDebtsOwedToParticipant(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	db.Create(&dave)
+	// Bob owes Alice and Dave, and is owed by Carol
+	db.Create(&database.Debt{GroupID: group.ID, LenderID: alice.ID, DebtorID: bob.ID, DebtAmount: 12.5})
+	db.Create(&database.Debt{GroupID: group.ID, LenderID: dave.ID, DebtorID: bob.ID, DebtAmount: 30})
+	db.Create(&database.Debt{GroupID: group.ID, LenderID: bob.ID, DebtorID: carol.ID, DebtAmount: 20})
+
+	// Act
+	resp, err := service.GetParticipantOwedDebts(context.Background(), &services.GetParticipantOwedDebtsRequest{
+		UrlSlug:       "trip",
+		ParticipantId: int32(bob.ID),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Debts, 2)
+	assert.Equal(t, "Dave", resp.Debts[0].LenderName)
+	assert.Equal(t, 30.0, resp.Debts[0].DebtAmount)
+	assert.Equal(t, "Alice", resp.Debts[1].LenderName)
+	assert.Equal(t, 12.5, resp.Debts[1].DebtAmount)
+	assert.Equal(t, 42.5, resp.Total)
+	for _, d := range resp.Debts {
+		assert.NotEqual(t, int32(bob.ID), d.LenderId)
+	}
+}
+
+func TestGetParticipantSettlePlan_SumsToParticipantsTotalOwed(t *testing.T) {
+	// Arrange: Alice pays 90 for Alice, Bob and Carol; Dave pays 40 for Carol and Dave
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	for _, p := range []*database.Participant{&alice, &bob, &carol, &dave} {
+		db.Create(p)
+	}
+	seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID, carol.ID)
+	seedEqualExpense(t, db, group.ID, dave.ID, 40, carol.ID, dave.ID)
+
+	// Act: Bob owes 30 in total, to both Alice and Dave once the debts are simplified
+	plan, err := service.GetParticipantSettlePlan(context.Background(), &services.GetParticipantSettlePlanRequest{
+		UrlSlug:       "trip",
+		ParticipantId: int32(bob.ID),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", plan.Currency)
+	assert.Len(t, plan.Payments, 2)
+	var sum float64
+	for _, payment := range plan.Payments {
+		assert.NotEqual(t, int32(bob.ID), payment.PayeeId)
+		sum += payment.Amount
+	}
+	balances, _, err := services.CalculateBalances(db, group.ID)
+	assert.NoError(t, err)
+	assert.InDelta(t, -balances[bob.ID], plan.Total, 0.001)
+	assert.InDelta(t, plan.Total, sum, 0.001)
+	assert.Equal(t, 30.0, plan.Total)
+}
+
+func TestGetParticipantPayments_ReturnsErrorForParticipantInAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	otherGroup := database.Group{Name: "Other Group", URLSlug: "other-group"}
+	db.Create(&group)
+	db.Create(&otherGroup)
+
+	outsider := database.Participant{Name: "Dave", GroupID: otherGroup.ID}
+	db.Create(&outsider)
+
+	req := &services.GetParticipantPaymentsRequest{UrlSlug: group.URLSlug, ParticipantId: int32(outsider.ID)}
+
+	// Act
+	result, err := service.GetParticipantPayments(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "participant not found")
+}
+
+// seedEqualExpense creates an expense through the expense service so debts are recalculated
+func seedEqualExpense(t *testing.T, db *gorm.DB, groupID uint, payerID uint, cost float64, participantIDs ...uint) *services.CreateExpenseResponse {
+	splits := make([]*services.Split, len(participantIDs))
+	for i, id := range participantIDs {
+		splits[i] = &services.Split{
+			GroupId:       int32(groupID),
+			ParticipantId: int32(id),
+			SplitAmount:   cost / float64(len(participantIDs)),
+		}
+	}
+
+	resp, err := services.NewExpenseService(db).CreateExpense(context.Background(), &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Expense",
+			Cost:      cost,
+			PayerId:   int32(payerID),
+			SplitType: "equal",
+			GroupId:   int32(groupID),
+		},
+		Splits: splits,
+	})
+	if err != nil {
+		t.Fatalf("failed to seed expense: %v", err)
+	}
+	return resp
+}
+
+// assertMoneyConserved checks a group's books after a recalculation: the net balances add up to zero and the
+// stored debts settle every participant's balance, both within the currency threshold
+func assertMoneyConserved(t *testing.T, db *gorm.DB, groupID uint) {
+	t.Helper()
+
+	var group database.Group
+	if err := db.First(&group, groupID).Error; err != nil {
+		t.Fatalf("failed to load group: %v", err)
+	}
+	balances, _, err := services.CalculateBalances(db, groupID)
+	if err != nil {
+		t.Fatalf("failed to calculate balances: %v", err)
+	}
+	assert.NoError(t, services.CheckBalanceConservation(balances, group.Currency))
+
+	var debts []database.Debt
+	db.Where("group_id = ?", groupID).Find(&debts)
+	settled := make(map[uint]float64)
+	for _, debt := range debts {
+		settled[debt.LenderID] += debt.DebtAmount
+		settled[debt.DebtorID] -= debt.DebtAmount
+	}
+	for participantID, balance := range balances {
+		assert.InDelta(t, balance, settled[participantID], services.AmountThreshold(group.Currency), "participant %d", participantID)
+	}
+}
+
+func TestSettlePair_SettlesOnlyThePairAndLeavesOtherDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	seedEqualExpense(t, db, group.ID, alice.ID, 60.0, alice.ID, bob.ID, charlie.ID)
+
+	req := &services.SettlePairRequest{UrlSlug: group.URLSlug, PayerId: int32(bob.ID), PayeeId: int32(alice.ID), Amount: 20.0}
+
+	// Act
+	result, err := service.SettlePair(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, result.Debt)
+	assert.Equal(t, 20.0, result.Payment.Amount)
+
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debts)
+	assert.Equal(t, 1, len(debts))
+	assert.Equal(t, charlie.ID, debts[0].DebtorID)
+	assert.Equal(t, alice.ID, debts[0].LenderID)
+	assert.Equal(t, 20.0, debts[0].DebtAmount)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestSettlePair_ReturnsErrorWhenAmountExceedsDebt(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	seedEqualExpense(t, db, group.ID, alice.ID, 40.0, alice.ID, bob.ID)
+
+	req := &services.SettlePairRequest{UrlSlug: group.URLSlug, PayerId: int32(bob.ID), PayeeId: int32(alice.ID), Amount: 25.0}
+
+	// Act
+	result, err := service.SettlePair(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "cannot exceed debt amount")
+
+	var count int64
+	db.Model(&database.Payment{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestCreatePayment_ReturnsDebtWithTimestamps(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	seedEqualExpense(t, db, group.ID, alice.ID, 40.0, alice.ID, bob.ID)
+
+	var debt database.Debt
+	db.Where("group_id = ?", group.ID).First(&debt)
+
+	// Act
+	result, err := service.CreatePayment(ctx, &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 5.0})
+	pageData, pageErr := service.GetDebtsPageData(ctx, &services.GetDebtsRequest{UrlSlug: group.URLSlug})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, pageErr)
+	assert.False(t, result.Debt.CreatedAt.IsZero())
+	assert.False(t, result.Debt.UpdatedAt.IsZero())
+	assert.Equal(t, 1, len(pageData.Debts))
+	assert.False(t, pageData.Debts[0].CreatedAt.IsZero())
+}
+
+func TestCreatePayment_ReturnsErrorForArchivedGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	seedEqualExpense(t, db, group.ID, alice.ID, 40.0, alice.ID, bob.ID)
+	db.Model(&group).Update("state", "archived")
+
+	var debt database.Debt
+	db.Where("group_id = ?", group.ID).First(&debt)
+
+	// Act
+	result, err := service.CreatePayment(ctx, &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 10.0})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "group is archived")
+
+	var count int64
+	db.Model(&database.Payment{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestGetCombinedSettlement_NetsOverlappingParticipantsAcrossGroups(t *testing.T) {
+	// Arrange: in "trip" Alice pays 40 for Alice and Bob; in "flat" Carol pays 30 for alice, Robert and Carol
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	trip := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat", Currency: "USD"}
+	db.Create(&trip)
+	db.Create(&flat)
+	tripAlice := database.Participant{Name: "Alice", GroupID: trip.ID}
+	tripBob := database.Participant{Name: "Bob", GroupID: trip.ID}
+	flatAlice := database.Participant{Name: " alice ", GroupID: flat.ID}
+	flatRobert := database.Participant{Name: "Robert", GroupID: flat.ID}
+	flatCarol := database.Participant{Name: "Carol", GroupID: flat.ID}
+	for _, p := range []*database.Participant{&tripAlice, &tripBob, &flatAlice, &flatRobert, &flatCarol} {
+		db.Create(p)
+	}
+	seedEqualExpense(t, db, trip.ID, tripAlice.ID, 40, tripAlice.ID, tripBob.ID)
+	seedEqualExpense(t, db, flat.ID, flatCarol.ID, 30, flatAlice.ID, flatRobert.ID, flatCarol.ID)
+
+	// Act
+	resp, err := service.GetCombinedSettlement(context.Background(), &services.CombinedSettlementRequest{
+		GroupSlugs: []string{"trip", "flat"},
+		Aliases:    []*services.ParticipantAlias{{GroupUrlSlug: "flat", ParticipantId: int32(flatRobert.ID), Name: "Bob"}},
+	})
+
+	// Assert: Alice +20 - 10, Bob -20 - 10, Carol +20
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.Len(t, resp.People, 3)
+	assert.Equal(t, "Alice", resp.People[0].Name)
+	assert.Equal(t, 10.0, resp.People[0].NetBalance)
+	assert.Equal(t, []string{"trip", "flat"}, resp.People[0].GroupSlugs)
+	assert.Equal(t, "Bob", resp.People[1].Name)
+	assert.Equal(t, -30.0, resp.People[1].NetBalance)
+	assert.Equal(t, "Carol", resp.People[2].Name)
+	assert.Equal(t, 20.0, resp.People[2].NetBalance)
+
+	assert.Equal(t, []*services.CombinedTransfer{
+		{FromName: "Bob", ToName: "Carol", Amount: 20},
+		{FromName: "Bob", ToName: "Alice", Amount: 10},
+	}, resp.Transfers)
+}
+
+func TestGetCombinedSettlement_RejectsMixedCurrencies(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	db.Create(&database.Group{Name: "NYC", URLSlug: "nyc", Currency: "USD"})
+	db.Create(&database.Group{Name: "Paris", URLSlug: "paris", Currency: "EUR"})
+
+	// Act
+	resp, err := service.GetCombinedSettlement(context.Background(), &services.CombinedSettlementRequest{
+		GroupSlugs: []string{"nyc", "paris"},
+	})
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "invalid combined settlement: groups use different currencies")
+}
+
+func TestGetUserGroupsSummary_TotalsPerCurrency(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+
+	usdTrip := database.Group{Name: "NYC", URLSlug: "nyc", Currency: "USD"}
+	usdFlat := database.Group{Name: "Flat", URLSlug: "flat", Currency: "USD"}
+	eurTrip := database.Group{Name: "Paris", URLSlug: "paris", Currency: "EUR"}
+	db.Create(&usdTrip)
+	db.Create(&usdFlat)
+	db.Create(&eurTrip)
+
+	me := make(map[uint]database.Participant)
+	for _, group := range []database.Group{usdTrip, usdFlat, eurTrip} {
+		user := database.Participant{Name: "Me", GroupID: group.ID}
+		friend := database.Participant{Name: "Friend", GroupID: group.ID}
+		db.Create(&user)
+		db.Create(&friend)
+		me[group.ID] = user
+		if group.ID == usdFlat.ID {
+			seedEqualExpense(t, db, group.ID, friend.ID, 30, user.ID, friend.ID) // I owe 15
+		} else {
+			seedEqualExpense(t, db, group.ID, user.ID, 20, user.ID, friend.ID) // I'm owed 10
+		}
+	}
+
+	req := &services.UserGroupsSummaryRequest{Groups: []*services.UserGroupRequest{
+		{GroupUrlSlug: "nyc", UserParticipantId: int32(me[usdTrip.ID].ID)},
+		{GroupUrlSlug: "flat", UserParticipantId: int32(me[usdFlat.ID].ID)},
+		{GroupUrlSlug: "paris", UserParticipantId: int32(me[eurTrip.ID].ID)},
+	}}
+
+	// Act
+	resp, err := service.GetUserGroupsSummary(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Groups, 3)
+	assert.Len(t, resp.Totals, 2)
+
+	assert.Equal(t, "USD", resp.Totals[0].Currency)
+	assert.Equal(t, 10.0, resp.Totals[0].OwedToUser)
+	assert.Equal(t, 15.0, resp.Totals[0].UserOwes)
+	assert.Equal(t, -5.0, resp.Totals[0].NetBalance)
+
+	assert.Equal(t, "EUR", resp.Totals[1].Currency)
+	assert.Equal(t, 10.0, resp.Totals[1].OwedToUser)
+	assert.Equal(t, 0.0, resp.Totals[1].UserOwes)
+	assert.Equal(t, 10.0, resp.Totals[1].NetBalance)
+}
+
+func TestGetUserGroupsSummary_FlagsParticipantFromAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	trip := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat", Currency: "USD"}
+	db.Create(&trip)
+	db.Create(&flat)
+	alice := database.Participant{Name: "Alice", GroupID: trip.ID}
+	bob := database.Participant{Name: "Bob", GroupID: trip.ID}
+	flatmate := database.Participant{Name: "Alice", GroupID: flat.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&flatmate)
+	seedEqualExpense(t, db, trip.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	req := &services.UserGroupsSummaryRequest{Groups: []*services.UserGroupRequest{
+		{GroupUrlSlug: "trip", UserParticipantId: int32(alice.ID)},
+		{GroupUrlSlug: "flat", UserParticipantId: int32(alice.ID)}, // Alice's ID from the trip
+	}}
+
+	// Act
+	resp, err := service.GetUserGroupsSummary(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Groups, 2)
+	assert.Empty(t, resp.Groups[0].Error)
+	assert.Equal(t, 10.0, resp.Groups[0].NetBalance)
+	assert.Contains(t, resp.Groups[1].Error, "not found in this group")
+	assert.Zero(t, resp.Groups[1].NetBalance)
+	assert.Len(t, resp.Totals, 1)
+	assert.Equal(t, 10.0, resp.Totals[0].NetBalance)
+}
+
+func TestGetParticipantBalance_MatchesCurrentDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID, carol.ID)
+
+	// Act
+	aliceBalance, err := service.GetParticipantBalance(context.Background(), &services.GetParticipantBalanceRequest{UrlSlug: "trip", ParticipantId: int32(alice.ID)})
+	assert.NoError(t, err)
+	bobBalance, err := service.GetParticipantBalance(context.Background(), &services.GetParticipantBalanceRequest{UrlSlug: "trip", ParticipantId: int32(bob.ID)})
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 60.0, aliceBalance.NetBalance)
+	assert.Equal(t, "USD", aliceBalance.Currency)
+	assert.Empty(t, aliceBalance.Owes)
+	assert.Len(t, aliceBalance.OwedBy, 2)
+
+	assert.Equal(t, -30.0, bobBalance.NetBalance)
+	assert.Len(t, bobBalance.Owes, 1)
+	assert.Equal(t, int32(alice.ID), bobBalance.Owes[0].ParticipantId)
+	assert.Equal(t, "Alice", bobBalance.Owes[0].ParticipantName)
+	assert.Equal(t, 30.0, bobBalance.Owes[0].Amount)
+	assert.Empty(t, bobBalance.OwedBy)
+}
+
+func TestGetDebtsPageData_KeepsDebtsReferencingDeletedParticipant(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&database.Debt{GroupID: group.ID, LenderID: alice.ID, DebtorID: 9999, DebtAmount: 25})
+
+	// Act
+	resp, err := service.GetDebtsPageData(context.Background(), &services.GetDebtsRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Debts, 1)
+	assert.Equal(t, "(deleted)", resp.Debts[0].DebtorName)
+	assert.Equal(t, "Alice", resp.Debts[0].LenderName)
+	assert.Equal(t, 25.0, resp.Debts[0].DebtAmount)
+}
+
+func TestPayMultipleDebts_ReducesEveryPaidDebt(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID, carol.ID)
+
+	var bobDebt, carolDebt database.Debt
+	db.Where("debtor_id = ?", bob.ID).First(&bobDebt)
+	db.Where("debtor_id = ?", carol.ID).First(&carolDebt)
+
+	// Act
+	resp, err := service.PayMultipleDebts(context.Background(), &services.PayMultipleDebtsRequest{
+		UrlSlug: "trip",
+		Payments: []*services.DebtPaymentItem{
+			{DebtId: int32(bobDebt.ID), Amount: 10},
+			{DebtId: int32(carolDebt.ID), Amount: 30},
+		},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Payments, 2)
+	assert.Len(t, resp.Debts, 1)
+	assert.Equal(t, int32(bob.ID), resp.Debts[0].DebtorId)
+	assert.Equal(t, 20.0, resp.Debts[0].DebtAmount)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestProjectDebts_ReflectsPaymentWithoutWritingAnything(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID, carol.ID)
+
+	var bobDebt database.Debt
+	db.Where("debtor_id = ?", bob.ID).First(&bobDebt)
+	var debtsBefore []database.Debt
+	db.Order("id").Find(&debtsBefore)
+	var activitiesBefore int64
+	db.Model(&database.Activity{}).Count(&activitiesBefore)
+
+	// Act
+	resp, err := service.ProjectDebts(context.Background(), &services.ProjectDebtsRequest{
+		UrlSlug:  "trip",
+		Payments: []*services.DebtPaymentItem{{DebtId: int32(bobDebt.ID), Amount: 25}},
+	})
+
+	// Assert: Bob would owe 5 and Carol still 30
+	assert.NoError(t, err)
+	assert.Equal(t, []*services.SettlementTransfer{
+		{FromId: int32(carol.ID), FromName: "Carol", ToId: int32(alice.ID), ToName: "Alice", Amount: 30},
+		{FromId: int32(bob.ID), FromName: "Bob", ToId: int32(alice.ID), ToName: "Alice", Amount: 5},
+	}, resp.Debts)
+
+	var payments, activitiesAfter int64
+	db.Model(&database.Payment{}).Count(&payments)
+	db.Model(&database.Activity{}).Count(&activitiesAfter)
+	assert.Zero(t, payments)
+	assert.Equal(t, activitiesBefore, activitiesAfter)
+	var debtsAfter []database.Debt
+	db.Order("id").Find(&debtsAfter)
+	assert.Equal(t, debtsBefore, debtsAfter)
+}
+
+func TestPayMultipleDebts_RecordsNothingWhenOneItemIsInvalid(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID)
+
+	var debt database.Debt
+	db.Where("debtor_id = ?", bob.ID).First(&debt)
+
+	// Act
+	_, err := service.PayMultipleDebts(context.Background(), &services.PayMultipleDebtsRequest{
+		UrlSlug: "trip",
+		Payments: []*services.DebtPaymentItem{
+			{DebtId: int32(debt.ID), Amount: 5},
+			{DebtId: int32(debt.ID) + 100, Amount: 5},
+		},
+	})
+
+	// Assert
+	assert.Error(t, err)
+	var count int64
+	db.Model(&database.Payment{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestPayMultipleDebts_RecordsNoteOnEveryPayment(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID, carol.ID)
+
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debts)
+	items := make([]*services.DebtPaymentItem, len(debts))
+	for i, d := range debts {
+		items[i] = &services.DebtPaymentItem{DebtId: int32(d.ID), Amount: d.DebtAmount}
+	}
+
+	// Act
+	resp, err := service.PayMultipleDebts(context.Background(), &services.PayMultipleDebtsRequest{
+		UrlSlug:  "trip",
+		Payments: items,
+		Note:     "  settled after Italy trip ",
+	})
+	_, longErr := service.PayMultipleDebts(context.Background(), &services.PayMultipleDebtsRequest{
+		UrlSlug:  "trip",
+		Payments: items,
+		Note:     strings.Repeat("a", 501),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Payments, 2)
+	for _, p := range resp.Payments {
+		assert.Equal(t, "settled after Italy trip", p.Note)
+	}
+	var stored []database.Payment
+	db.Where("group_id = ?", group.ID).Find(&stored)
+	assert.Len(t, stored, 2)
+	for _, p := range stored {
+		assert.Equal(t, "settled after Italy trip", p.Note)
+	}
+	assert.Empty(t, resp.Debts)
+	assert.ErrorContains(t, longErr, "invalid note")
+}
+
+func TestGetDebtGraph_HasOneEdgePerSimplifiedDebt(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID, carol.ID)
+
+	var debtCount int64
+	db.Model(&database.Debt{}).Where("group_id = ?", group.ID).Count(&debtCount)
+
+	// Act
+	resp, err := service.GetDebtGraph(context.Background(), &services.GetDebtGraphRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.Len(t, resp.Nodes, 3)
+	assert.Len(t, resp.Edges, int(debtCount))
+	assert.Equal(t, 60.0, resp.Nodes[0].NetBalance)
+	for _, edge := range resp.Edges {
+		assert.Equal(t, int32(alice.ID), edge.ToId)
+		assert.Equal(t, "Alice", edge.ToName)
+	}
+}
+
+func TestSettlePair_RejectsReversedDirection(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID) // Bob owes Alice 20
+
+	// Act
+	_, err := service.SettlePair(context.Background(), &services.SettlePairRequest{
+		UrlSlug: "trip", PayerId: int32(alice.ID), PayeeId: int32(bob.ID), Amount: 10,
+	})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "owes participant")
+
+	var paymentCount int64
+	db.Model(&database.Payment{}).Count(&paymentCount)
+	assert.Zero(t, paymentCount)
+}
+
+func TestSettlePair_RejectsParticipantFromAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	other := database.Group{Name: "Other", URLSlug: "other", Currency: "USD"}
+	db.Create(&group)
+	db.Create(&other)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	stranger := database.Participant{Name: "Stranger", GroupID: other.ID}
+	db.Create(&alice)
+	db.Create(&stranger)
+
+	// Act
+	_, err := service.SettlePair(context.Background(), &services.SettlePairRequest{
+		UrlSlug: "trip", PayerId: int32(stranger.ID), PayeeId: int32(alice.ID), Amount: 10,
+	})
+
+	// Assert
+	assert.EqualError(t, err, "participant not found")
+}
+
+func TestGetPaymentsPageData_ResolvesNamesAndCurrency(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "EUR"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID)
+	_, err := service.SettlePair(context.Background(), &services.SettlePairRequest{
+		UrlSlug: "trip", PayerId: int32(bob.ID), PayeeId: int32(alice.ID), Amount: 15,
+	})
+	assert.NoError(t, err)
+
+	// Act
+	resp, err := service.GetPaymentsPageData(context.Background(), &services.GetPaymentsPageDataRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", resp.Currency)
+	assert.Len(t, resp.Payments, 1)
+	assert.Equal(t, "Bob", resp.Payments[0].PayerName)
+	assert.Equal(t, "Alice", resp.Payments[0].PayeeName)
+	assert.Equal(t, 15.0, resp.Payments[0].Amount)
+}
+
+func TestGetSettlementComparison_ShowsTransactionsSavedBySimplification(t *testing.T) {
+	// Arrange: a chain where Alice owes Bob, Bob owes Carol and Carol owes Dave 10 each
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	for _, p := range []*database.Participant{&alice, &bob, &carol, &dave} {
+		db.Create(p)
+	}
+	for _, link := range []struct{ payer, debtor uint }{{bob.ID, alice.ID}, {carol.ID, bob.ID}, {dave.ID, carol.ID}} {
+		expense := database.Expense{Name: "Link", Cost: 10, Emoji: "🔗", PayerID: link.payer, GroupID: group.ID, SplitType: "amount"}
+		db.Create(&expense)
+		db.Create(&database.Split{GroupID: group.ID, ExpenseID: expense.ID, ParticipantID: link.debtor, SplitAmount: 10})
+	}
+
+	// Act
+	resp, err := service.GetSettlementComparison(context.Background(), &services.GetSettlementComparisonRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), resp.RawDebtCount)
+	assert.Equal(t, int32(1), resp.SimplifiedDebtCount)
+	assert.Equal(t, int32(2), resp.TransactionsSaved)
+	assert.Len(t, resp.RawDebts, 3)
+	assert.Equal(t, "Alice", resp.RawDebts[0].FromName)
+	assert.Equal(t, "Bob", resp.RawDebts[0].ToName)
+	assert.Len(t, resp.SimplifiedDebts, 1)
+	assert.Equal(t, int32(alice.ID), resp.SimplifiedDebts[0].FromId)
+	assert.Equal(t, int32(dave.ID), resp.SimplifiedDebts[0].ToId)
+	assert.Equal(t, 10.0, resp.SimplifiedDebts[0].Amount)
+}
+
+func TestGetSettlementCircles_KeepsIndependentCirclesApart(t *testing.T) {
+	// Arrange: Alice is owed 6 by Dave and Erin, Bob is owed 4 by Carol
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	erin := database.Participant{Name: "Erin", GroupID: group.ID}
+	for _, p := range []*database.Participant{&alice, &bob, &carol, &dave, &erin} {
+		db.Create(p)
+	}
+	seedEqualExpense(t, db, group.ID, alice.ID, 6, dave.ID, erin.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 4, carol.ID)
+
+	// Act
+	resp, err := service.GetSettlementCircles(context.Background(), &services.GetSettlementCirclesRequest{UrlSlug: "trip"})
+
+	// Assert: the greedy plan matches Carol with Alice first and leaves Dave paying both Alice and Bob
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), resp.GreedyTransferCount)
+	assert.Equal(t, int32(3), resp.TransferCount)
+	assert.Len(t, resp.Circles, 2)
+	assert.Equal(t, []string{"Alice", "Dave", "Erin"}, resp.Circles[0].ParticipantNames)
+	assert.Equal(t, []*services.SettlementTransfer{
+		{FromId: int32(dave.ID), FromName: "Dave", ToId: int32(alice.ID), ToName: "Alice", Amount: 3},
+		{FromId: int32(erin.ID), FromName: "Erin", ToId: int32(alice.ID), ToName: "Alice", Amount: 3},
+	}, resp.Circles[0].Transfers)
+	assert.Equal(t, []string{"Bob", "Carol"}, resp.Circles[1].ParticipantNames)
+	assert.Equal(t, []*services.SettlementTransfer{
+		{FromId: int32(carol.ID), FromName: "Carol", ToId: int32(bob.ID), ToName: "Bob", Amount: 4},
+	}, resp.Circles[1].Transfers)
+}
+
+func TestGetDebtCycles_CollapsesThreeCycleOfPairwiseDebts(t *testing.T) {
+	// Arrange: Alice owes Bob 10, Bob owes Carol 10.30 and Carol owes Alice 10.10
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	for _, p := range []*database.Participant{&alice, &bob, &carol} {
+		db.Create(p)
+	}
+	seedEqualExpense(t, db, group.ID, bob.ID, 10, alice.ID)
+	seedEqualExpense(t, db, group.ID, carol.ID, 10.30, bob.ID)
+	seedEqualExpense(t, db, group.ID, alice.ID, 10.10, carol.ID)
+
+	// Act
+	detected, detectErr := service.GetDebtCycles(context.Background(), &services.GetDebtCyclesRequest{UrlSlug: "trip"})
+	collapsed, collapseErr := service.GetDebtCycles(context.Background(), &services.GetDebtCyclesRequest{UrlSlug: "trip", Collapse: true})
+
+	// Assert
+	assert.NoError(t, detectErr)
+	assert.False(t, detected.Collapsed)
+	assert.Len(t, detected.Debts, 3)
+	assert.Len(t, detected.Cycles, 1)
+	assert.Equal(t, []string{"Alice", "Bob", "Carol"}, detected.Cycles[0].ParticipantNames)
+	assert.Equal(t, 10.0, detected.Cycles[0].Amount)
+
+	assert.NoError(t, collapseErr)
+	assert.True(t, collapsed.Collapsed)
+	assert.Len(t, collapsed.Debts, 2)
+	assert.Equal(t, int32(bob.ID), collapsed.Debts[0].FromId)
+	assert.Equal(t, int32(carol.ID), collapsed.Debts[0].ToId)
+	assert.Equal(t, 0.30, collapsed.Debts[0].Amount)
+	assert.Equal(t, int32(carol.ID), collapsed.Debts[1].FromId)
+	assert.Equal(t, int32(alice.ID), collapsed.Debts[1].ToId)
+	assert.Equal(t, 0.10, collapsed.Debts[1].Amount)
+}
+
+func TestCollapseDebtCycles_LeavesAcyclicDebtsUntouched(t *testing.T) {
+	// Arrange: a chain with no way back to its start
+	debts := []database.Debt{
+		{DebtorID: 1, LenderID: 2, DebtAmount: 10},
+		{DebtorID: 2, LenderID: 3, DebtAmount: 5},
+		{DebtorID: 1, LenderID: 3, DebtAmount: 2},
+	}
+
+	// Act
+	remaining, cycles := services.CollapseDebtCycles(debts, "USD")
+
+	// Assert
+	assert.Empty(t, cycles)
+	assert.Equal(t, debts, remaining)
+}
+
+func TestCalculateRawDebts_NetsOpposingDebtsAndPayments(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID) // Bob owes Alice 15
+	seedEqualExpense(t, db, group.ID, bob.ID, 10, alice.ID, bob.ID)   // Alice owes Bob 5
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 4})
+
+	// Act
+	debts, err := services.CalculateRawDebts(db, group.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, debts, 1)
+	assert.Equal(t, bob.ID, debts[0].DebtorID)
+	assert.Equal(t, alice.ID, debts[0].LenderID)
+	assert.Equal(t, 6.0, debts[0].DebtAmount)
+}
+
+func TestGetDebtCount_MatchesCurrentDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, charlie.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 24, alice.ID, bob.ID, charlie.ID)
+
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debts)
+	var total float64
+	for _, debt := range debts {
+		total += debt.DebtAmount
+	}
+
+	// Act
+	resp, err := service.GetDebtCount(context.Background(), &services.GetDebtCountRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(debts)), resp.Count)
+	assert.Equal(t, int64(2), resp.Count)
+	assert.InDelta(t, total, resp.TotalAmount, 0.001)
+	assert.Equal(t, 18.0, resp.TotalAmount)
+	assert.Equal(t, "USD", resp.Currency)
+}
+
+func TestGetDebtCount_ReturnsErrorForUnknownGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+
+	// Act
+	resp, err := service.GetDebtCount(context.Background(), &services.GetDebtCountRequest{UrlSlug: "missing"})
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "group not found")
+}
+
+func TestGetLargestDebt_ReturnsDebtWithMostOwed(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	db.Create(&dave)
+	seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID, carol.ID, dave.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 20, bob.ID, dave.ID)
+
+	// Act
+	resp, err := service.GetLargestDebt(context.Background(), &services.GetLargestDebtRequest{UrlSlug: "trip"})
+
+	// Assert: Bob owes Alice 12.50 and Carol 22.50, but Dave owes her 32.50
+	assert.NoError(t, err)
+	var count int64
+	db.Model(&database.Debt{}).Where("group_id = ?", group.ID).Count(&count)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, int32(dave.ID), resp.DebtorId)
+	assert.Equal(t, "Dave", resp.DebtorName)
+	assert.Equal(t, int32(alice.ID), resp.LenderId)
+	assert.Equal(t, "Alice", resp.LenderName)
+	assert.Equal(t, 32.5, resp.Amount)
+	assert.Equal(t, "USD", resp.Currency)
+}
+
+func TestGetLargestDebt_ReturnsNilWithoutDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"})
+
+	// Act
+	resp, err := service.GetLargestDebt(context.Background(), &services.GetLargestDebtRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestGetSettlementSteps_MatchesThreePersonExample(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, charlie.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 24, alice.ID, bob.ID, charlie.ID)
+
+	// Act
+	resp, err := service.GetSettlementSteps(context.Background(), &services.GetSettlementStepsRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.Len(t, resp.Steps, 2)
+	assert.Equal(t, int32(1), resp.Steps[0].Step)
+	assert.Equal(t, "Charlie pays Alice $12.00", resp.Steps[0].Instruction)
+	assert.Equal(t, 12.0, resp.Steps[0].Amount)
+	assert.Equal(t, int32(2), resp.Steps[1].Step)
+	assert.Equal(t, "Charlie pays Bob $6.00", resp.Steps[1].Instruction)
+	assert.Equal(t, int32(bob.ID), resp.Steps[1].ToId)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestGetComputedBalances_DoesNotModifyDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, charlie.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 24, alice.ID, bob.ID, charlie.ID)
+
+	var before []database.Debt
+	db.Where("group_id = ?", group.ID).Order("id").Find(&before)
+	var writes int
+	countWrite := func(*gorm.DB) { writes++ }
+	db.Callback().Create().Before("gorm:create").Register("test:count_creates", countWrite)
+	db.Callback().Update().Before("gorm:update").Register("test:count_updates", countWrite)
+	db.Callback().Delete().Before("gorm:delete").Register("test:count_deletes", countWrite)
+
+	// Act
+	resp, err := service.GetComputedBalances(context.Background(), &services.GetComputedBalancesRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 0, writes)
+	assert.Len(t, resp.Balances, 3)
+	assert.Equal(t, 12.0, resp.Balances[0].NetBalance)
+	assert.Equal(t, 6.0, resp.Balances[1].NetBalance)
+	assert.Equal(t, -18.0, resp.Balances[2].NetBalance)
+
+	var after []database.Debt
+	db.Where("group_id = ?", group.ID).Order("id").Find(&after)
+	assert.Equal(t, before, after)
+}
+
+func TestGetDebtsAsOf_ExcludesLaterExpenses(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	dinner := seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, charlie.ID)
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	db.Model(&database.Expense{}).Where("id = ?", dinner.Expense.Id).Update("created_at", lastWeek)
+	seedEqualExpense(t, db, group.ID, bob.ID, 24, alice.ID, bob.ID, charlie.ID)
+
+	// Act
+	past, err := service.GetDebtsAsOf(context.Background(), &services.GetDebtsAsOfRequest{UrlSlug: "trip", AsOf: lastWeek.Add(time.Hour)})
+	assert.NoError(t, err)
+	current, err := service.GetDebtsAsOf(context.Background(), &services.GetDebtsAsOfRequest{UrlSlug: "trip", AsOf: time.Now().Add(time.Hour)})
+
+	// Assert
+	assert.NoError(t, err)
+
+	// Only the dinner counts last week: Bob and Charlie each owe Alice 10
+	assert.Len(t, past.Debts, 2)
+	for _, debt := range past.Debts {
+		assert.Equal(t, "Alice", debt.ToName)
+		assert.Equal(t, 10.0, debt.Amount)
+	}
+
+	// Including the gas expense, Charlie owes Alice 12 and Bob 6
+	assert.Len(t, current.Debts, 2)
+	assert.Equal(t, "Charlie", current.Debts[0].FromName)
+	assert.Equal(t, 12.0, current.Debts[0].Amount)
+	assert.Equal(t, 6.0, current.Debts[1].Amount)
+}
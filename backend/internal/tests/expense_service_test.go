@@ -0,0 +1,1645 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"freesplit/internal/database"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCreateExpense_ItemizedSplitAllocatesTaxAndTipProportionally(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Dinner",
+			Cost:      130.0,
+			Subtotal:  100.0,
+			Tax:       10.0,
+			Tip:       20.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "itemized",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID), SplitAmount: 60.0},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), SplitAmount: 40.0},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 78.0, result.Splits[0].SplitAmount)
+	assert.Equal(t, 52.0, result.Splits[1].SplitAmount)
+	assert.Equal(t, 20.0, result.Expense.Tip)
+
+	var debt database.Debt
+	db.Where("group_id = ?", group.ID).First(&debt)
+	assert.Equal(t, bob.ID, debt.DebtorID)
+	assert.Equal(t, 52.0, debt.DebtAmount)
+}
+
+func TestCreateExpense_ReturnsErrorWhenBreakdownDoesNotMatchCost(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Dinner",
+			Cost:      120.0,
+			Subtotal:  100.0,
+			Tax:       10.0,
+			Tip:       20.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "itemized",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID), SplitAmount: 100.0},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "must equal cost")
+}
+
+func TestCreateExpense_EqualSplitGivesRemainderToChosenParticipant(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Snacks",
+			Cost:      10.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(charlie.ID)},
+		},
+		RemainderParticipantId: int32(bob.ID),
+	}
+
+	// Act
+	result, err := service.CreateExpense(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3.33, result.Splits[0].SplitAmount)
+	assert.Equal(t, 3.34, result.Splits[1].SplitAmount)
+	assert.Equal(t, 3.33, result.Splits[2].SplitAmount)
+}
+
+func TestCreateExpense_EqualSplitGivesRemainderToLastParticipantByDefault(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Snacks",
+			Cost:      10.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(charlie.ID)},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3.33, result.Splits[0].SplitAmount)
+	assert.Equal(t, 3.33, result.Splits[1].SplitAmount)
+	assert.Equal(t, 3.34, result.Splits[2].SplitAmount)
+}
+
+// createEqualExpenseAmongMany creates an equal-split expense paid by the first of n new participants and shared by all of them
+func createEqualExpenseAmongMany(t *testing.T, cost float64, n int) *services.CreateExpenseResponse {
+	db := setupTestDB()
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	splits := make([]*services.Split, n)
+	for i := range splits {
+		participant := database.Participant{Name: fmt.Sprintf("Person %d", i+1), GroupID: group.ID}
+		db.Create(&participant)
+		splits[i] = &services.Split{GroupId: int32(group.ID), ParticipantId: int32(participant.ID)}
+	}
+
+	resp, err := services.NewExpenseService(db).CreateExpense(context.Background(), &services.CreateExpenseRequest{
+		Expense: &services.Expense{Name: "Coffee", Cost: cost, PayerId: splits[0].ParticipantId, SplitType: "equal", GroupId: int32(group.ID)},
+		Splits:  splits,
+	})
+	assert.NoError(t, err)
+	return resp
+}
+
+func TestCreateExpense_EqualSplitOfFewerCentsThanParticipantsStaysNonNegative(t *testing.T) {
+	// Act: 13 cents among 8 people
+	result := createEqualExpenseAmongMany(t, 0.13, 8)
+
+	// Assert: seven people pay one cent and the last absorbs the six leftover cents
+	for _, split := range result.Splits[:7] {
+		assert.Equal(t, 0.01, split.SplitAmount)
+	}
+	assert.Equal(t, 0.06, result.Splits[7].SplitAmount)
+}
+
+func TestCreateExpense_EqualSplitAmongManyParticipantsKeepsLeftoverBelowOneCentEach(t *testing.T) {
+	// Act: 20.00 among 30 people is 0.666… each
+	result := createEqualExpenseAmongMany(t, 20, 30)
+
+	// Assert
+	var total int64
+	for _, split := range result.Splits[:29] {
+		assert.Equal(t, 0.66, split.SplitAmount)
+		total += services.ToMinorUnits(split.SplitAmount, "USD")
+	}
+	last := result.Splits[29].SplitAmount
+	total += services.ToMinorUnits(last, "USD")
+	assert.Equal(t, 0.86, last)
+	assert.Less(t, services.ToMinorUnits(last, "USD")-66, int64(30))
+	assert.Equal(t, int64(2000), total)
+}
+
+// Server computation wins for "equal" expenses: conflicting client amounts are ignored and reported, not rejected
+func TestCreateExpense_EqualSplitIgnoresConflictingClientAmountsWithWarning(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Dinner",
+			Cost:      90.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID), SplitAmount: 30},  // matches, no warning
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), SplitAmount: 40},    // conflicts
+			{GroupId: int32(group.ID), ParticipantId: int32(charlie.ID), SplitAmount: 0}, // not sent
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	for _, split := range result.Splits {
+		assert.Equal(t, 30.0, split.SplitAmount)
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf(`split amount 40.00 for participant %d was ignored: "equal" expenses are split by the server (30.00)`, bob.ID),
+	}, result.Warnings)
+}
+
+func TestCreateExpense_EqualSplitExcludingPayerChargesOnlyOthers(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Gifts",
+			Cost:      30.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal_excluding_payer",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(charlie.ID)},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result.Splits, 2)
+	assert.Equal(t, int32(bob.ID), result.Splits[0].ParticipantId)
+	assert.Equal(t, 15.0, result.Splits[0].SplitAmount)
+	assert.Equal(t, int32(charlie.ID), result.Splits[1].ParticipantId)
+	assert.Equal(t, 15.0, result.Splits[1].SplitAmount)
+
+	// Alice is credited the full cost
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debts)
+	assert.Len(t, debts, 2)
+	var owedToAlice float64
+	for _, debt := range debts {
+		assert.Equal(t, alice.ID, debt.LenderID)
+		owedToAlice += debt.DebtAmount
+	}
+	assert.Equal(t, 30.0, owedToAlice)
+}
+
+func TestCreateExpense_EqualSplitExcludingPayerRejectsPayerAsOnlyParticipant(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Gifts",
+			Cost:      30.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal_excluding_payer",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "needs at least one participant besides the payer")
+}
+
+func TestCreateExpense_ReturnsErrorWhenRemainderParticipantIsNotSplit(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Snacks",
+			Cost:      10.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID)},
+		},
+		RemainderParticipantId: int32(charlie.ID),
+	}
+
+	// Act
+	result, err := service.CreateExpense(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "is not one of the split participants")
+}
+
+func TestCreateExpense_RotatesRemainderAcrossExpenses(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD", RotateRemainder: true}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	// Act: 10.00 never divides evenly by three, so every expense leaves a cent over
+	var recipients []int32
+	for i := 0; i < 4; i++ {
+		created := seedEqualExpense(t, db, group.ID, alice.ID, 10, alice.ID, bob.ID, charlie.ID)
+		for _, split := range created.Splits {
+			if split.SplitAmount == 3.34 {
+				recipients = append(recipients, split.ParticipantId)
+			}
+		}
+	}
+
+	// Assert
+	assert.Equal(t, []int32{int32(alice.ID), int32(bob.ID), int32(charlie.ID), int32(alice.ID)}, recipients)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestCreateExpense_ReturnsErrorForEmptySplits(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Taxi",
+			Cost:      25.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "amount",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{},
+	}
+
+	// Act
+	_, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one participant")
+
+	var count int64
+	db.Model(&database.Expense{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestCreateExpense_ReturnsGroupNotFoundForUnknownGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Taxi",
+			Cost:      25.0,
+			PayerId:   1,
+			SplitType: "equal",
+			GroupId:   999,
+		},
+		Splits: []*services.Split{{GroupId: 999, ParticipantId: 1}},
+	}
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "group not found")
+
+	var expenses, splits int64
+	db.Model(&database.Expense{}).Count(&expenses)
+	db.Model(&database.Split{}).Count(&splits)
+	assert.Zero(t, expenses)
+	assert.Zero(t, splits)
+}
+
+func TestCreateExpense_AllParticipantsSplitsEquallyAcrossMembers(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:    "Groceries",
+			Cost:    30.0,
+			PayerId: int32(alice.ID),
+			GroupId: int32(group.ID),
+		},
+		AllParticipants: true,
+	}
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "equal", result.Expense.SplitType)
+	assert.Len(t, result.Splits, 3)
+	for i, participant := range []database.Participant{alice, bob, charlie} {
+		assert.Equal(t, int32(participant.ID), result.Splits[i].ParticipantId)
+		assert.Equal(t, 10.0, result.Splits[i].SplitAmount)
+	}
+}
+
+func TestCreateExpense_AllParticipantsRejectsExplicitSplitsAndNonEqualTypes(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	create := func(splitType string, splits []*services.Split) error {
+		_, err := service.CreateExpense(context.Background(), &services.CreateExpenseRequest{
+			Expense: &services.Expense{
+				Name:      "Taxi",
+				Cost:      25.0,
+				PayerId:   int32(alice.ID),
+				SplitType: splitType,
+				GroupId:   int32(group.ID),
+			},
+			Splits:          splits,
+			AllParticipants: true,
+		})
+		return err
+	}
+
+	// Act
+	withSplits := create("equal", []*services.Split{{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)}})
+	weighted := create("weighted", nil)
+
+	// Assert
+	assert.ErrorContains(t, withSplits, "either splits or all_participants")
+	assert.ErrorContains(t, weighted, "all_participants only works with")
+	var count int64
+	db.Model(&database.Expense{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestGetExpensesByGroup_EmbedsSplitsOnlyWhenRequested(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	// Act
+	withSplits, err := service.GetExpensesByGroup(context.Background(), &services.GetExpensesByGroupRequest{GroupId: int32(group.ID), IncludeSplits: true})
+	assert.NoError(t, err)
+	withoutSplits, err := service.GetExpensesByGroup(context.Background(), &services.GetExpensesByGroupRequest{GroupId: int32(group.ID)})
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Len(t, withSplits.Expenses, 1)
+	assert.Len(t, withSplits.Expenses[0].Splits, 2)
+	assert.Equal(t, 10.0, withSplits.Expenses[0].Splits[0].SplitAmount)
+
+	assert.Len(t, withoutSplits.Expenses, 1)
+	assert.Nil(t, withoutSplits.Expenses[0].Splits)
+}
+
+func TestCreateExpense_RejectsInvalidNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		expenseName string
+		wantErr     string
+	}{
+		{"empty", "", "name cannot be empty"},
+		{"whitespace only", "   \t ", "name cannot be empty"},
+		{"over length", strings.Repeat("a", services.MaxExpenseNameLength()+1), "cannot be longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB()
+			service := services.NewExpenseService(db)
+			group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+			db.Create(&group)
+			alice := database.Participant{Name: "Alice", GroupID: group.ID}
+			db.Create(&alice)
+
+			req := &services.CreateExpenseRequest{
+				Expense: &services.Expense{
+					Name:      tt.expenseName,
+					Cost:      10,
+					PayerId:   int32(alice.ID),
+					SplitType: "equal",
+					GroupId:   int32(group.ID),
+				},
+				Splits: []*services.Split{{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)}},
+			}
+
+			// Act
+			_, err := service.CreateExpense(context.Background(), req)
+
+			// Assert
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestUpdateExpense_TrimsAndValidatesName(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	created := seedEqualExpense(t, db, group.ID, alice.ID, 10, alice.ID)
+
+	update := func(name string) (*services.UpdateExpenseResponse, error) {
+		expense := *created.Expense
+		expense.Name = name
+		return service.UpdateExpense(context.Background(), &services.UpdateExpenseRequest{
+			Expense: &expense,
+			Splits:  []*services.Split{{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)}},
+		})
+	}
+
+	// Act
+	_, emptyErr := update("  ")
+	resp, err := update("  Lunch  ")
+
+	// Assert
+	assert.Error(t, emptyErr)
+	assert.NoError(t, err)
+	assert.Equal(t, "Lunch", resp.Expense.Name)
+}
+
+func TestCreateExpense_AdjustmentSplitAddsAdjustmentToEqualShare(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Dinner",
+			Cost:      65.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "adjustment",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), Adjustment: 5.0},
+			{GroupId: int32(group.ID), ParticipantId: int32(carol.ID)},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, result.Splits[0].SplitAmount)
+	assert.Equal(t, 25.0, result.Splits[1].SplitAmount)
+	assert.Equal(t, 5.0, result.Splits[1].Adjustment)
+	assert.Equal(t, 20.0, result.Splits[2].SplitAmount)
+}
+
+func TestCreateExpense_AdjustmentSplitRejectsNegativeShare(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Coffee",
+			Cost:      10.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "adjustment",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), Adjustment: 15.0},
+		},
+	}
+
+	// Act
+	_, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "negative share")
+}
+
+func TestCreateExpense_WeightedSplitDividesCostByNights(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Cabin",
+			Cost:      100.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "weighted",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID), Weight: 2},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), Weight: 3},
+			{GroupId: int32(group.ID), ParticipantId: int32(carol.ID), Weight: 1},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 33.33, result.Splits[0].SplitAmount)
+	assert.Equal(t, 50.0, result.Splits[1].SplitAmount)
+	assert.Equal(t, 16.67, result.Splits[2].SplitAmount)
+	assert.Equal(t, 3.0, result.Splits[1].Weight)
+
+	var stored []database.Split
+	db.Where("expense_id = ?", result.Expense.Id).Order("id").Find(&stored)
+	assert.Equal(t, 2.0, stored[0].Weight)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestCreateExpense_WeightedSplitRejectsInvalidWeights(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	newRequest := func(aliceWeight, bobWeight float64) *services.CreateExpenseRequest {
+		return &services.CreateExpenseRequest{
+			Expense: &services.Expense{
+				Name:      "Cabin",
+				Cost:      100.0,
+				PayerId:   int32(alice.ID),
+				SplitType: "weighted",
+				GroupId:   int32(group.ID),
+			},
+			Splits: []*services.Split{
+				{GroupId: int32(group.ID), ParticipantId: int32(alice.ID), Weight: aliceWeight},
+				{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), Weight: bobWeight},
+			},
+		}
+	}
+
+	// Act
+	_, negativeErr := service.CreateExpense(context.Background(), newRequest(3, -1))
+	_, zeroErr := service.CreateExpense(context.Background(), newRequest(0, 0))
+
+	// Assert
+	assert.Error(t, negativeErr)
+	assert.Contains(t, negativeErr.Error(), "weights cannot be negative")
+	assert.Error(t, zeroErr)
+	assert.Contains(t, zeroErr.Error(), "positive total weight")
+}
+
+func TestCreateExpense_MixedSplitSharesWhatExactAmountsLeave(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	db.Create(&dave)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Dinner",
+			Cost:      100.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "mixed",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID), SplitAmount: 30},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), SplitAmount: 10.5},
+			{GroupId: int32(group.ID), ParticipantId: int32(carol.ID), SharesRemainder: true},
+			{GroupId: int32(group.ID), ParticipantId: int32(dave.ID), SharesRemainder: true},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert: Carol and Dave split the 59.50 left after the exact amounts
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, result.Splits[0].SplitAmount)
+	assert.Equal(t, 10.5, result.Splits[1].SplitAmount)
+	assert.Equal(t, 29.75, result.Splits[2].SplitAmount)
+	assert.Equal(t, 29.75, result.Splits[3].SplitAmount)
+
+	var stored []database.Split
+	db.Where("expense_id = ?", result.Expense.Id).Order("id").Find(&stored)
+	assert.False(t, stored[1].SharesRemainder)
+	assert.True(t, stored[2].SharesRemainder)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestCreateExpense_MixedSplitRejectsExactAmountsAboveCost(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Dinner",
+			Cost:      50.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "mixed",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID), SplitAmount: 60},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID), SharesRemainder: true},
+		},
+	}
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "exact amounts (60.00) exceed cost (50.00)")
+}
+
+func TestResplitExpense_SplitsExistingExpenseAcrossNewParticipants(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	created := seedEqualExpense(t, db, group.ID, alice.ID, 90, alice.ID, bob.ID)
+
+	// Act
+	resp, err := service.ResplitExpense(context.Background(), &services.ResplitExpenseRequest{
+		ExpenseId:      created.Expense.Id,
+		ParticipantIds: []int32{int32(alice.ID), int32(bob.ID), int32(carol.ID)},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 90.0, resp.Expense.Cost)
+	assert.Equal(t, int32(alice.ID), resp.Expense.PayerId)
+	assert.Len(t, resp.Splits, 3)
+	for _, split := range resp.Splits {
+		assert.Equal(t, 30.0, split.SplitAmount)
+	}
+
+	var splitCount int64
+	db.Model(&database.Split{}).Where("expense_id = ?", created.Expense.Id).Count(&splitCount)
+	assert.Equal(t, int64(3), splitCount)
+
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debts)
+	assert.Len(t, debts, 2)
+	for _, debt := range debts {
+		assert.Equal(t, alice.ID, debt.LenderID)
+		assert.Equal(t, 30.0, debt.DebtAmount)
+	}
+}
+
+func TestResplitExpense_RejectsParticipantFromAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	other := database.Group{Name: "Other Group", URLSlug: "other-group", Currency: "USD"}
+	db.Create(&group)
+	db.Create(&other)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	outsider := database.Participant{Name: "Outsider", GroupID: other.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&outsider)
+	created := seedEqualExpense(t, db, group.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	// Act
+	_, err := service.ResplitExpense(context.Background(), &services.ResplitExpenseRequest{
+		ExpenseId:      created.Expense.Id,
+		ParticipantIds: []int32{int32(alice.ID), int32(outsider.ID)},
+	})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not in the group")
+
+	var splitCount int64
+	db.Model(&database.Split{}).Where("expense_id = ?", created.Expense.Id).Count(&splitCount)
+	assert.Equal(t, int64(2), splitCount)
+}
+
+func TestCreateExpense_PersonalExpenseDoesNotChangeDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	var debtsBefore []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debtsBefore)
+
+	notShared := false
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Souvenir",
+			Cost:      35.0,
+			PayerId:   int32(bob.ID),
+			SplitType: "equal",
+			IsShared:  &notShared,
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID)},
+		},
+	}
+
+	// Act
+	resp, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, resp.Expense.Shared())
+	assert.Len(t, resp.Splits, 1)
+	assert.Equal(t, int32(bob.ID), resp.Splits[0].ParticipantId)
+	assert.Equal(t, 35.0, resp.Splits[0].SplitAmount)
+
+	var debtsAfter []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debtsAfter)
+	assert.Len(t, debtsAfter, len(debtsBefore))
+	for i := range debtsAfter {
+		assert.Equal(t, debtsBefore[i].LenderID, debtsAfter[i].LenderID)
+		assert.Equal(t, debtsBefore[i].DebtorID, debtsAfter[i].DebtorID)
+		assert.Equal(t, debtsBefore[i].DebtAmount, debtsAfter[i].DebtAmount)
+	}
+}
+
+func TestCreateExpense_ExpensesAreSharedByDefault(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	// Act
+	resp := seedEqualExpense(t, db, group.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	// Assert
+	assert.True(t, resp.Expense.Shared())
+	var stored database.Expense
+	db.First(&stored, resp.Expense.Id)
+	assert.NotNil(t, stored.IsShared)
+	assert.True(t, *stored.IsShared)
+}
+
+func TestGetParticipantPaidExpenses_ReturnsOnlyTheirExpensesWithTotal(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30.10, alice.ID, bob.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 50, alice.ID, bob.ID)
+	seedEqualExpense(t, db, group.ID, alice.ID, 12.20, alice.ID, bob.ID)
+
+	// Act
+	resp, err := service.GetParticipantPaidExpenses(context.Background(), &services.GetParticipantPaidExpensesRequest{
+		UrlSlug:       "test-group",
+		ParticipantId: int32(alice.ID),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Expenses, 2)
+	for _, expense := range resp.Expenses {
+		assert.Equal(t, int32(alice.ID), expense.PayerId)
+	}
+	assert.Equal(t, 42.30, resp.Total)
+	assert.Equal(t, "USD", resp.Currency)
+}
+
+func TestGetParticipantPaidExpenses_ReturnsErrorForParticipantOutsideGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	db.Create(&database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"})
+
+	// Act
+	_, err := service.GetParticipantPaidExpenses(context.Background(), &services.GetParticipantPaidExpensesRequest{UrlSlug: "test-group", ParticipantId: 99})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestGetExpenseDetail_ResolvesPayerAndParticipantNames(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "EUR"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	created := seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID)
+
+	// Act
+	resp, err := service.GetExpenseDetail(context.Background(), &services.GetExpenseDetailRequest{ExpenseId: created.Expense.Id})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, created.Expense.Id, resp.Expense.Id)
+	assert.Equal(t, "Alice", resp.PayerName)
+	assert.Equal(t, "Test Group", resp.GroupName)
+	assert.Equal(t, "EUR", resp.Currency)
+	assert.Len(t, resp.Shares, 2)
+	assert.Equal(t, "Alice", resp.Shares[0].ParticipantName)
+	assert.Equal(t, "Bob", resp.Shares[1].ParticipantName)
+	assert.Equal(t, int32(bob.ID), resp.Shares[1].ParticipantId)
+	assert.Equal(t, 15.0, resp.Shares[1].Amount)
+}
+
+func TestGetExpenseWithSplits_ResolvesPayerAndParticipantNames(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "EUR"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	created := seedEqualExpense(t, db, group.ID, bob.ID, 30, alice.ID, bob.ID)
+
+	// Act
+	resp, err := service.GetExpenseWithSplits(context.Background(), &services.GetExpenseWithSplitsRequest{ExpenseId: created.Expense.Id})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob", resp.PayerName)
+	assert.Len(t, resp.Splits, 2)
+	assert.Equal(t, int32(alice.ID), resp.Splits[0].ParticipantId)
+	assert.Equal(t, "Alice", resp.Splits[0].ParticipantName)
+	assert.Equal(t, 15.0, resp.Splits[0].SplitAmount)
+	assert.Equal(t, int32(bob.ID), resp.Splits[1].ParticipantId)
+	assert.Equal(t, "Bob", resp.Splits[1].ParticipantName)
+}
+
+func TestCreateExpense_ThreeDecimalAmountsRoundTripExactly(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "KWD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	req := &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Rent",
+			Cost:      123456789.125,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: int32(group.ID), ParticipantId: int32(alice.ID)},
+			{GroupId: int32(group.ID), ParticipantId: int32(bob.ID)},
+		},
+	}
+
+	// Act
+	resp, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	var stored database.Expense
+	db.First(&stored, resp.Expense.Id)
+	assert.Equal(t, 123456789.125, stored.Cost)
+
+	var splits []database.Split
+	db.Where("expense_id = ?", resp.Expense.Id).Order("id").Find(&splits)
+	assert.Len(t, splits, 2)
+	assert.Equal(t, 61728394.562, splits[0].SplitAmount)
+	assert.Equal(t, 61728394.563, splits[1].SplitAmount)
+	assert.Equal(t, services.ToMinorUnits(stored.Cost, "KWD"),
+		services.ToMinorUnits(splits[0].SplitAmount, "KWD")+services.ToMinorUnits(splits[1].SplitAmount, "KWD"))
+}
+
+func TestUpdateExpense_AttachesSplitsToUpdatedExpenseIgnoringClientExpenseID(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	target := seedEqualExpense(t, db, group.ID, alice.ID, 20, alice.ID, bob.ID)
+	other := seedEqualExpense(t, db, group.ID, bob.ID, 40, alice.ID, bob.ID)
+
+	req := &services.UpdateExpenseRequest{
+		Expense: &services.Expense{
+			Id:        target.Expense.Id,
+			Name:      "Lunch",
+			Cost:      30.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{GroupId: 999, ExpenseId: other.Expense.Id, ParticipantId: int32(alice.ID)},
+			{GroupId: 999, ExpenseId: 12345, ParticipantId: int32(bob.ID)},
+		},
+	}
+
+	// Act
+	resp, err := service.UpdateExpense(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	for _, split := range resp.Splits {
+		assert.Equal(t, target.Expense.Id, split.ExpenseId)
+		assert.Equal(t, int32(group.ID), split.GroupId)
+	}
+
+	var targetSplits, otherSplits int64
+	db.Model(&database.Split{}).Where("expense_id = ?", target.Expense.Id).Count(&targetSplits)
+	db.Model(&database.Split{}).Where("expense_id = ?", other.Expense.Id).Count(&otherSplits)
+	assert.Equal(t, int64(2), targetSplits)
+	assert.Equal(t, int64(2), otherSplits)
+}
+
+func TestUpdateExpense_ReturnsNotFoundForMissingExpense(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+
+	req := &services.UpdateExpenseRequest{
+		Expense: &services.Expense{Id: 42, Name: "Ghost", Cost: 10, PayerId: int32(alice.ID), SplitType: "equal", GroupId: int32(group.ID)},
+		Splits:  []*services.Split{{ParticipantId: int32(alice.ID)}},
+	}
+
+	// Act
+	_, err := service.UpdateExpense(context.Background(), req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expense not found")
+	var count int64
+	db.Model(&database.Expense{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUpdateExpense_RejectsMovingExpenseToAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	trip := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat", Currency: "USD"}
+	db.Create(&trip)
+	db.Create(&flat)
+	alice := database.Participant{Name: "Alice", GroupID: trip.ID}
+	bob := database.Participant{Name: "Bob", GroupID: trip.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	created := seedEqualExpense(t, db, trip.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	req := &services.UpdateExpenseRequest{
+		Expense: &services.Expense{
+			Id:        created.Expense.Id,
+			Name:      "Dinner",
+			Cost:      20.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   int32(flat.ID),
+		},
+		Splits: []*services.Split{
+			{ParticipantId: int32(alice.ID)},
+			{ParticipantId: int32(bob.ID)},
+		},
+	}
+
+	// Act
+	resp, err := service.UpdateExpense(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "invalid expense")
+	assert.ErrorContains(t, err, "cannot be moved")
+
+	var stored database.Expense
+	db.First(&stored, created.Expense.Id)
+	assert.Equal(t, trip.ID, stored.GroupID)
+	var tripDebts int64
+	db.Model(&database.Debt{}).Where("group_id = ?", trip.ID).Count(&tripDebts)
+	assert.Equal(t, int64(1), tripDebts)
+}
+
+// seedLockedExpense creates a 20.00 dinner paid by Alice and split with Bob, then locks it
+func seedLockedExpense(t *testing.T, db *gorm.DB) (*services.CreateExpenseResponse, database.Participant, database.Participant) {
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	created := seedEqualExpense(t, db, group.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	resp, err := services.NewExpenseService(db).SetExpenseLocked(context.Background(), &services.SetExpenseLockedRequest{ExpenseId: created.Expense.Id, Locked: true})
+	assert.NoError(t, err)
+	assert.True(t, resp.Expense.Locked)
+	return created, alice, bob
+}
+
+func TestUpdateExpense_RejectsLockedExpense(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	created, alice, bob := seedLockedExpense(t, db)
+
+	req := &services.UpdateExpenseRequest{
+		Expense: &services.Expense{
+			Id:        created.Expense.Id,
+			Name:      "Dinner",
+			Cost:      50.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "equal",
+			GroupId:   created.Expense.GroupId,
+		},
+		Splits: []*services.Split{
+			{ParticipantId: int32(alice.ID)},
+			{ParticipantId: int32(bob.ID)},
+		},
+	}
+
+	// Act
+	resp, err := service.UpdateExpense(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "is locked")
+	var stored database.Expense
+	db.First(&stored, created.Expense.Id)
+	assert.Equal(t, 20.0, stored.Cost)
+}
+
+func TestDeleteExpense_RejectsLockedExpenseUntilUnlocked(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	created, _, _ := seedLockedExpense(t, db)
+
+	// Act
+	err := service.DeleteExpense(context.Background(), &services.DeleteExpenseRequest{ExpenseId: created.Expense.Id})
+
+	// Assert
+	assert.ErrorContains(t, err, "is locked")
+	var count int64
+	db.Model(&database.Expense{}).Where("id = ?", created.Expense.Id).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// Unlocking allows the delete again
+	_, err = service.SetExpenseLocked(context.Background(), &services.SetExpenseLockedRequest{ExpenseId: created.Expense.Id, Locked: false})
+	assert.NoError(t, err)
+	assert.NoError(t, service.DeleteExpense(context.Background(), &services.DeleteExpenseRequest{ExpenseId: created.Expense.Id}))
+}
+
+func TestDeleteExpense_LastExpenseLeavesNoStaleDebts(t *testing.T) {
+	for _, simplify := range []bool{true, false} {
+		// Arrange
+		db := setupTestDB()
+		service := services.NewExpenseService(db)
+		group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+		db.Create(&group)
+		db.Model(&group).Update("simplify_debts", simplify)
+		alice := database.Participant{Name: "Alice", GroupID: group.ID}
+		bob := database.Participant{Name: "Bob", GroupID: group.ID}
+		carol := database.Participant{Name: "Carol", GroupID: group.ID}
+		db.Create(&alice)
+		db.Create(&bob)
+		db.Create(&carol)
+		created := seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID)
+
+		// Act: the only expense involving Bob goes away
+		err := service.DeleteExpense(context.Background(), &services.DeleteExpenseRequest{ExpenseId: created.Expense.Id})
+
+		// Assert
+		assert.NoError(t, err)
+		var debts int64
+		db.Model(&database.Debt{}).Where("group_id = ?", group.ID).Count(&debts)
+		assert.Zero(t, debts, "simplify_debts=%v", simplify)
+		balances, _, err := services.CalculateBalances(db, group.ID)
+		assert.NoError(t, err)
+		for _, participant := range []database.Participant{alice, bob, carol} {
+			assert.Zero(t, balances[participant.ID], "%s, simplify_debts=%v", participant.Name, simplify)
+		}
+	}
+}
+
+func TestDeleteExpenses_DeletesBatchAndRecalculatesDebts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, carol.ID)
+	misImported := []int32{
+		seedEqualExpense(t, db, group.ID, bob.ID, 60, alice.ID, bob.ID, carol.ID).Expense.Id,
+		seedEqualExpense(t, db, group.ID, carol.ID, 90, alice.ID, bob.ID, carol.ID).Expense.Id,
+	}
+
+	// Act
+	resp, err := service.DeleteExpenses(context.Background(), &services.DeleteExpensesRequest{UrlSlug: "trip", ExpenseIds: misImported})
+
+	// Assert: only Alice's 30 is left, so Bob and Carol owe her 10 each
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), resp.DeletedCount)
+	var expenses, splits int64
+	db.Model(&database.Expense{}).Count(&expenses)
+	db.Model(&database.Split{}).Where("expense_id IN ?", misImported).Count(&splits)
+	assert.Equal(t, int64(1), expenses)
+	assert.Zero(t, splits)
+
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Order("debtor_id").Find(&debts)
+	assert.Len(t, debts, 2)
+	for i, debtor := range []database.Participant{bob, carol} {
+		assert.Equal(t, debtor.ID, debts[i].DebtorID)
+		assert.Equal(t, alice.ID, debts[i].LenderID)
+		assert.Equal(t, 10.0, debts[i].DebtAmount)
+	}
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestDeleteExpenses_DeletesNothingWhenAnExpenseBelongsToAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	trip := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat", Currency: "USD"}
+	db.Create(&trip)
+	db.Create(&flat)
+	alice := database.Participant{Name: "Alice", GroupID: trip.ID}
+	bob := database.Participant{Name: "Bob", GroupID: flat.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	own := seedEqualExpense(t, db, trip.ID, alice.ID, 30, alice.ID)
+	foreign := seedEqualExpense(t, db, flat.ID, bob.ID, 30, bob.ID)
+
+	// Act
+	resp, err := service.DeleteExpenses(context.Background(), &services.DeleteExpensesRequest{
+		UrlSlug:    "trip",
+		ExpenseIds: []int32{own.Expense.Id, foreign.Expense.Id},
+	})
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "not found in this group")
+	var count int64
+	db.Model(&database.Expense{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestValidateSplit_EqualSplitReturnsComputedAmounts(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+
+	// Act
+	resp, err := service.ValidateSplit(context.Background(), &services.ValidateSplitRequest{
+		UrlSlug:   "trip",
+		Cost:      10,
+		SplitType: "equal",
+		PayerId:   int32(alice.ID),
+		Splits: []*services.Split{
+			{ParticipantId: int32(alice.ID)},
+			{ParticipantId: int32(bob.ID)},
+			{ParticipantId: int32(carol.ID)},
+		},
+	})
+
+	// Assert: the rounding cent goes to the last participant, and nothing is saved
+	assert.NoError(t, err)
+	assert.True(t, resp.Valid)
+	assert.Empty(t, resp.Errors)
+	assert.Len(t, resp.ComputedAmounts, 3)
+	for i, want := range []float64{3.33, 3.33, 3.34} {
+		assert.Equal(t, want, resp.ComputedAmounts[i].SplitAmount)
+	}
+	var count int64
+	db.Model(&database.Expense{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestValidateSplit_AmountSplitNotAddingUpIsInvalid(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	// Act
+	resp, err := service.ValidateSplit(context.Background(), &services.ValidateSplitRequest{
+		UrlSlug:   "trip",
+		Cost:      50,
+		SplitType: "amount",
+		PayerId:   int32(alice.ID),
+		Splits: []*services.Split{
+			{ParticipantId: int32(alice.ID), SplitAmount: 20},
+			{ParticipantId: int32(bob.ID), SplitAmount: 20},
+		},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0], "must add up to cost")
+	assert.Empty(t, resp.ComputedAmounts)
+}
+
+// roundedSharesRequest builds a 100.00 expense whose amount splits were rounded by hand to 33 + 33 + 33
+func roundedSharesRequest(db *gorm.DB, tolerance *float64) *services.CreateExpenseRequest {
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+
+	return &services.CreateExpenseRequest{
+		Expense: &services.Expense{
+			Name:      "Cabin",
+			Cost:      100.0,
+			PayerId:   int32(alice.ID),
+			SplitType: "amount",
+			GroupId:   int32(group.ID),
+		},
+		Splits: []*services.Split{
+			{ParticipantId: int32(alice.ID), SplitAmount: 33},
+			{ParticipantId: int32(bob.ID), SplitAmount: 33},
+			{ParticipantId: int32(charlie.ID), SplitAmount: 33},
+		},
+		SplitTolerance: tolerance,
+	}
+}
+
+func TestCreateExpense_AmountSplitOffByMoreThanDefaultToleranceIsRejected(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	req := roundedSharesRequest(db, nil)
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "invalid expense: split amounts (99.00) must add up to cost (100.00) within 0.01")
+}
+
+func TestCreateExpense_AmountSplitWithinLooseToleranceReconciles(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	tolerance := 1.0
+	req := roundedSharesRequest(db, &tolerance)
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 33.0, result.Splits[0].SplitAmount)
+	assert.Equal(t, 33.0, result.Splits[1].SplitAmount)
+	assert.Equal(t, 34.0, result.Splits[2].SplitAmount)
+
+	// The last participant absorbed the difference, so the debts cover the full cost
+	var debts []database.Debt
+	db.Find(&debts)
+	var owed float64
+	for _, debt := range debts {
+		owed += debt.DebtAmount
+	}
+	assert.Equal(t, 67.0, owed)
+}
+
+func TestCreateExpense_RejectsOutOfRangeSplitTolerance(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	tolerance := 5.0
+	req := roundedSharesRequest(db, &tolerance)
+
+	// Act
+	result, err := service.CreateExpense(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "invalid expense: split_tolerance must be between 0 and 1.00")
+}
+
+func TestUpdateExpense_KeepsCreationTime(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewExpenseService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	created := seedEqualExpense(t, db, group.ID, alice.ID, 10, alice.ID)
+	var before database.Expense
+	db.First(&before, created.Expense.Id)
+
+	// Act
+	_, err := service.UpdateExpense(context.Background(), &services.UpdateExpenseRequest{
+		Expense: &services.Expense{Id: created.Expense.Id, Name: "Snacks", Cost: 12, PayerId: int32(alice.ID), SplitType: "equal", GroupId: int32(group.ID)},
+		Splits:  []*services.Split{{ParticipantId: int32(alice.ID)}},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	var after database.Expense
+	db.First(&after, created.Expense.Id)
+	assert.True(t, before.CreatedAt.Equal(after.CreatedAt))
+}
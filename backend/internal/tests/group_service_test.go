@@ -0,0 +1,1187 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"freesplit/internal/database"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCreateGroup_TrimsParticipantNames(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+
+	req := &services.CreateGroupRequest{
+		Name:             "Trip",
+		Currency:         "USD",
+		ParticipantNames: []string{" Alice ", "Bob\n"},
+	}
+
+	// Act
+	result, err := service.CreateGroup(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result.Participants))
+	assert.Equal(t, "Alice", result.Participants[0].Name)
+	assert.Equal(t, "Bob", result.Participants[1].Name)
+}
+
+func TestCreateGroup_ReturnsErrorForWhitespaceOnlyParticipantName(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+
+	req := &services.CreateGroupRequest{
+		Name:             "Trip",
+		Currency:         "USD",
+		ParticipantNames: []string{"Alice", "  "},
+	}
+
+	// Act
+	result, err := service.CreateGroup(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "participant name cannot be empty")
+
+	var count int64
+	db.Model(&database.Group{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestGetGroup_ByIDReturnsSameDataAsBySlug(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+
+	created, err := service.CreateGroup(ctx, &services.CreateGroupRequest{
+		Name:             "Trip",
+		Currency:         "EUR",
+		ParticipantNames: []string{"Alice", "Bob"},
+	})
+	assert.NoError(t, err)
+
+	// Act
+	bySlug, slugErr := service.GetGroup(ctx, &services.GetGroupRequest{UrlSlug: created.Group.UrlSlug})
+	byID, idErr := service.GetGroup(ctx, &services.GetGroupRequest{GroupId: created.Group.Id})
+
+	// Assert
+	assert.NoError(t, slugErr)
+	assert.NoError(t, idErr)
+	assert.Equal(t, bySlug, byID)
+	assert.Equal(t, 2, len(byID.Participants))
+}
+
+func TestGetGroup_ReturnsNotFoundForUnknownID(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+
+	// Act
+	result, err := service.GetGroup(ctx, &services.GetGroupRequest{GroupId: 999})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "group not found")
+}
+
+func TestResetGroup_KeepsParticipantsAndClearsLedger(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Flatmates", URLSlug: "flat"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID)
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 5})
+
+	// Act
+	resp, err := service.ResetGroup(context.Background(), &services.ResetGroupRequest{UrlSlug: "flat"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Participants, 2)
+
+	var expenses, splits, debts, payments, participants int64
+	db.Model(&database.Expense{}).Where("group_id = ?", group.ID).Count(&expenses)
+	db.Model(&database.Split{}).Where("group_id = ?", group.ID).Count(&splits)
+	db.Model(&database.Debt{}).Where("group_id = ?", group.ID).Count(&debts)
+	db.Model(&database.Payment{}).Where("group_id = ?", group.ID).Count(&payments)
+	db.Model(&database.Participant{}).Where("group_id = ?", group.ID).Count(&participants)
+	assert.Zero(t, expenses)
+	assert.Zero(t, splits)
+	assert.Zero(t, debts)
+	assert.Zero(t, payments)
+	assert.Equal(t, int64(2), participants)
+}
+
+func TestResetGroup_ReturnsErrorForUnknownSlug(t *testing.T) {
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+
+	_, err := service.ResetGroup(context.Background(), &services.ResetGroupRequest{UrlSlug: "missing"})
+
+	assert.EqualError(t, err, "group not found")
+}
+
+func TestResetGroup_RequiresEditTokenWhenGroupHasOne(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+	created, err := service.CreateGroup(ctx, &services.CreateGroupRequest{Name: "Flatmates", Currency: "USD", ParticipantNames: []string{"Alice", "Bob"}})
+	assert.NoError(t, err)
+	slug := created.Group.UrlSlug
+	alice, bob := uint(created.Participants[0].Id), uint(created.Participants[1].Id)
+	seedEqualExpense(t, db, uint(created.Group.Id), alice, 40, alice, bob)
+
+	// Act
+	_, missingErr := service.ResetGroup(ctx, &services.ResetGroupRequest{UrlSlug: slug})
+	_, wrongErr := service.ResetGroup(ctx, &services.ResetGroupRequest{UrlSlug: slug, EditToken: "guess"})
+	var kept int64
+	db.Model(&database.Expense{}).Where("group_id = ?", created.Group.Id).Count(&kept)
+	_, err = service.ResetGroup(ctx, &services.ResetGroupRequest{UrlSlug: slug, EditToken: created.EditToken})
+
+	// Assert
+	assert.ErrorContains(t, missingErr, "invalid edit token")
+	assert.ErrorContains(t, wrongErr, "invalid edit token")
+	assert.Equal(t, int64(1), kept)
+	assert.NoError(t, err)
+	var left int64
+	db.Model(&database.Expense{}).Where("group_id = ?", created.Group.Id).Count(&left)
+	assert.Zero(t, left)
+}
+
+func TestGetGroupStatistics_PercentagesSumToHundred(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 100, alice.ID, bob.ID, carol.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 50, alice.ID, bob.ID)
+
+	// Act
+	resp, err := service.GetGroupStatistics(context.Background(), &services.GetGroupStatisticsRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.ExpenseCount)
+	assert.Equal(t, 150.0, resp.TotalSpent)
+	assert.Len(t, resp.Participants, 3)
+
+	var paidSum, consumedSum float64
+	for _, p := range resp.Participants {
+		paidSum += p.PercentOfPaid
+		consumedSum += p.PercentOfConsumed
+	}
+	assert.InDelta(t, 100, paidSum, 0.05)
+	assert.InDelta(t, 100, consumedSum, 0.05)
+	assert.InDelta(t, 66.67, resp.Participants[0].PercentOfPaid, 0.001)
+}
+
+func TestGetGroupStatistics_ReturnsZeroPercentagesWithoutSpending(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Empty", URLSlug: "empty"}
+	db.Create(&group)
+	db.Create(&database.Participant{Name: "Alice", GroupID: group.ID})
+
+	// Act
+	resp, err := service.GetGroupStatistics(context.Background(), &services.GetGroupStatisticsRequest{UrlSlug: "empty"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, resp.Participants[0].PercentOfPaid)
+	assert.Equal(t, 0.0, resp.Participants[0].PercentOfConsumed)
+}
+
+func TestGetExpensesByPayer_CountsAndTotalsPerPayer(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, bob.ID, 20, alice.ID, bob.ID)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, carol.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 12.5, bob.ID, carol.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 7.25, alice.ID, bob.ID)
+
+	// Act
+	resp, err := service.GetExpensesByPayer(context.Background(), &services.GetExpensesByPayerRequest{UrlSlug: "trip"})
+
+	// Assert: Bob paid most, and Carol is listed even though she never paid
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.Len(t, resp.Payers, 3)
+	assert.Equal(t, services.PayerTotals{ParticipantId: int32(bob.ID), Name: "Bob", ExpenseCount: 3, TotalPaid: 39.75}, *resp.Payers[0])
+	assert.Equal(t, services.PayerTotals{ParticipantId: int32(alice.ID), Name: "Alice", ExpenseCount: 1, TotalPaid: 30}, *resp.Payers[1])
+	assert.Equal(t, services.PayerTotals{ParticipantId: int32(carol.ID), Name: "Carol"}, *resp.Payers[2])
+}
+
+func TestGetGroupSummaryMarkdown_ListsExpensesAndSettlement(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Ski_Trip", URLSlug: "ski", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, carol.ID)
+
+	// Act
+	resp, err := service.GetGroupSummaryMarkdown(context.Background(), &services.GetGroupSummaryMarkdownRequest{UrlSlug: "ski"})
+
+	// Assert: names are escaped, and the expense and both debts are rendered with the currency
+	assert.NoError(t, err)
+	md := resp.Markdown
+	assert.True(t, strings.HasPrefix(md, "# Ski\\_Trip\n"))
+	assert.Contains(t, md, "**Expense** for $30.00, paid by Alice; shares: Alice $10.00, Bob $10.00, Carol $10.00\n")
+	assert.Contains(t, md, "## Payments\n\nNo payments yet.\n")
+	assert.Contains(t, md, "## Settlement\n\n")
+	assert.Contains(t, md, "- Bob pays Alice $10.00\n")
+	assert.Contains(t, md, "- Carol pays Alice $10.00\n")
+}
+
+func TestGroupExists_ReportsExistingAndMissingSlugs(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip"})
+
+	// Act
+	existing, err := service.GroupExists(context.Background(), &services.GroupExistsRequest{UrlSlug: "trip"})
+	assert.NoError(t, err)
+	missing, err := service.GroupExists(context.Background(), &services.GroupExistsRequest{UrlSlug: "gone"})
+	assert.NoError(t, err)
+
+	// Assert
+	assert.True(t, existing.Exists)
+	assert.False(t, missing.Exists)
+}
+
+func TestUpdateGroup_RejectsCurrencyChangeWhenExpensesExist(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 20, alice.ID, bob.ID)
+
+	// Act
+	_, currencyErr := service.UpdateGroup(context.Background(), &services.UpdateGroupRequest{Name: "Trip", Currency: "EUR", ParticipantId: int32(group.ID)})
+	renamed, renameErr := service.UpdateGroup(context.Background(), &services.UpdateGroupRequest{Name: "Road Trip", Currency: "USD", ParticipantId: int32(group.ID)})
+
+	// Assert
+	assert.Error(t, currencyErr)
+	assert.Contains(t, currencyErr.Error(), "cannot change currency")
+	assert.NoError(t, renameErr)
+	assert.Equal(t, "Road Trip", renamed.Group.Name)
+	assert.Equal(t, "USD", renamed.Group.Currency)
+}
+
+func TestUpdateGroup_AllowsCurrencyChangeWithoutExpenses(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+
+	// Act
+	resp, err := service.UpdateGroup(context.Background(), &services.UpdateGroupRequest{Name: "Trip", Currency: "EUR", ParticipantId: int32(group.ID)})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", resp.Group.Currency)
+}
+
+func TestUpdateGroup_BankerRoutesEveryDebtThroughBanker(t *testing.T) {
+	// Arrange: Alice is owed 60 and Dave 20; Bob and Carol owe 40 each
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	for _, p := range []*database.Participant{&alice, &bob, &carol, &dave} {
+		db.Create(p)
+	}
+	seedEqualExpense(t, db, group.ID, alice.ID, 80, alice.ID, bob.ID, carol.ID, dave.ID)
+	seedEqualExpense(t, db, group.ID, dave.ID, 40, bob.ID, carol.ID)
+	banker := int32(bob.ID)
+
+	// Act
+	resp, err := service.UpdateGroup(context.Background(), &services.UpdateGroupRequest{
+		Name:          "Trip",
+		Currency:      "USD",
+		BankerId:      &banker,
+		ParticipantId: int32(group.ID),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, banker, resp.Group.BankerId)
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debts)
+	assert.Len(t, debts, 3)
+	for _, debt := range debts {
+		assert.True(t, debt.LenderID == bob.ID || debt.DebtorID == bob.ID, "debt %d → %d skips the banker", debt.DebtorID, debt.LenderID)
+	}
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestUpdateGroup_RejectsBankerFromAnotherGroupAndCanRemoveBanker(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	other := database.Group{Name: "Flat", URLSlug: "flat", Currency: "USD"}
+	db.Create(&group)
+	db.Create(&other)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	stranger := database.Participant{Name: "Stranger", GroupID: other.ID}
+	db.Create(&alice)
+	db.Create(&stranger)
+	update := func(bankerID int32) (*services.UpdateGroupResponse, error) {
+		return service.UpdateGroup(context.Background(), &services.UpdateGroupRequest{
+			Name:          "Trip",
+			Currency:      "USD",
+			BankerId:      &bankerID,
+			ParticipantId: int32(group.ID),
+		})
+	}
+
+	// Act
+	_, strangerErr := update(int32(stranger.ID))
+	_, setErr := update(int32(alice.ID))
+	removed, removeErr := update(0)
+
+	// Assert
+	assert.ErrorContains(t, strangerErr, "invalid group: banker must be a participant of the group")
+	assert.NoError(t, setErr)
+	assert.NoError(t, removeErr)
+	assert.Zero(t, removed.Group.BankerId)
+	var stored database.Group
+	db.First(&stored, group.ID)
+	assert.Nil(t, stored.BankerID)
+}
+
+func TestUpdateGroup_RejectsBankerWithoutSimplification(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD", SimplifyDebts: true}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	banker, noBanker := int32(alice.ID), int32(0)
+	on, off := true, false
+	update := func(bankerID *int32, simplify *bool) error {
+		_, err := service.UpdateGroup(context.Background(), &services.UpdateGroupRequest{
+			Name:          "Trip",
+			Currency:      "USD",
+			BankerId:      bankerID,
+			SimplifyDebts: simplify,
+			ParticipantId: int32(group.ID),
+		})
+		return err
+	}
+
+	// Act
+	bothErr := update(&banker, &off)
+	setErr := update(&banker, nil)
+	offErr := update(nil, &off)
+	clearAndOffErr := update(&noBanker, &off)
+	bankerWhileOffErr := update(&banker, nil)
+	bankerAndOnErr := update(&banker, &on)
+
+	// Assert
+	assert.ErrorContains(t, bothErr, "invalid group: a banker requires simplify_debts")
+	assert.NoError(t, setErr)
+	assert.ErrorContains(t, offErr, "invalid group: a banker requires simplify_debts")
+	assert.NoError(t, clearAndOffErr)
+	assert.ErrorContains(t, bankerWhileOffErr, "invalid group: a banker requires simplify_debts")
+	assert.NoError(t, bankerAndOnErr)
+	var stored database.Group
+	db.First(&stored, group.ID)
+	assert.True(t, stored.SimplifyDebts)
+	assert.Equal(t, alice.ID, *stored.BankerID)
+}
+
+func TestCreateGroup_WordSlugsAreUniqueAcrossManyGroups(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	wordSlug := regexp.MustCompile(`^[a-z]+-[a-z]+-[a-z]+-[1-9][0-9]{4}$`)
+	seen := make(map[string]bool)
+
+	for i := 0; i < 300; i++ {
+		// Act
+		resp, err := service.CreateGroup(context.Background(), &services.CreateGroupRequest{
+			Name:             fmt.Sprintf("Group %d", i),
+			Currency:         "USD",
+			ParticipantNames: []string{"Alice"},
+			SlugStyle:        services.SlugStyleWords,
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Regexp(t, wordSlug, resp.Group.UrlSlug)
+		assert.False(t, seen[resp.Group.UrlSlug], "slug %s generated twice", resp.Group.UrlSlug)
+		seen[resp.Group.UrlSlug] = true
+	}
+}
+
+func TestWordSlugSpace_IsAtLeastAsLargeAsHexSlugSpace(t *testing.T) {
+	// A 10-character hex slug has 16^10 = 2^40 values; anyone holding a slug can access the group,
+	// so word slugs must be no easier to guess
+	hexSpace := new(big.Int).Lsh(big.NewInt(1), 40)
+
+	assert.GreaterOrEqual(t, services.WordSlugSpace().Cmp(hexSpace), 0, "word slug space %s is smaller than 2^40", services.WordSlugSpace())
+}
+
+func TestCreateGroup_ConcurrentCallsGetUniqueSlugs(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	// Each connection to ":memory:" opens its own database, so the goroutines share a single one
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	service := services.NewGroupService(db)
+
+	const groups = 50
+	slugs := make([]string, groups)
+	errs := make([]error, groups)
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < groups; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := service.CreateGroup(context.Background(), &services.CreateGroupRequest{
+				Name:             fmt.Sprintf("Group %d", i),
+				Currency:         "USD",
+				ParticipantNames: []string{"Alice", "Bob"},
+				SlugStyle:        services.SlugStyleWords,
+			})
+			errs[i] = err
+			if err == nil {
+				slugs[i] = resp.Group.UrlSlug
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	seen := make(map[string]bool)
+	for i := 0; i < groups; i++ {
+		assert.NoError(t, errs[i])
+		assert.False(t, seen[slugs[i]], "slug %s generated twice", slugs[i])
+		seen[slugs[i]] = true
+	}
+	var count int64
+	db.Model(&database.Group{}).Count(&count)
+	assert.Equal(t, int64(groups), count)
+	var participantCount int64
+	db.Model(&database.Participant{}).Count(&participantCount)
+	assert.Equal(t, int64(2*groups), participantCount)
+}
+
+func TestCreateGroup_UsesHexSlugsByDefaultAndRejectsUnknownStyle(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+
+	// Act
+	resp, err := service.CreateGroup(context.Background(), &services.CreateGroupRequest{Name: "Trip", Currency: "USD", ParticipantNames: []string{"Alice"}})
+	_, styleErr := service.CreateGroup(context.Background(), &services.CreateGroupRequest{Name: "Trip", Currency: "USD", ParticipantNames: []string{"Alice"}, SlugStyle: "emoji"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{10}$`), resp.Group.UrlSlug)
+	assert.Error(t, styleErr)
+	assert.Contains(t, styleErr.Error(), "invalid slug style")
+	assert.Error(t, services.SetSlugStyle("emoji"))
+	assert.Equal(t, services.SlugStyleHex, services.SlugStyle())
+}
+
+func TestRotateEditToken_InvalidatesOldToken(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	created, err := service.CreateGroup(context.Background(), &services.CreateGroupRequest{Name: "Trip", Currency: "USD", ParticipantNames: []string{"Alice"}})
+	assert.NoError(t, err)
+	oldToken := created.EditToken
+	slug := created.Group.UrlSlug
+
+	// Act
+	rotated, err := service.RotateEditToken(context.Background(), &services.RotateEditTokenRequest{UrlSlug: slug, EditToken: oldToken})
+	assert.NoError(t, err)
+	_, oldErr := service.RotateEditToken(context.Background(), &services.RotateEditTokenRequest{UrlSlug: slug, EditToken: oldToken})
+	_, newErr := service.RotateEditToken(context.Background(), &services.RotateEditTokenRequest{UrlSlug: slug, EditToken: rotated.EditToken})
+
+	// Assert
+	assert.NotEmpty(t, oldToken)
+	assert.NotEqual(t, oldToken, rotated.EditToken)
+	assert.Error(t, oldErr)
+	assert.Contains(t, oldErr.Error(), "invalid edit token")
+	assert.NoError(t, newErr)
+}
+
+func TestRotateEditToken_RejectsGroupWithoutToken(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	db.Create(&database.Group{Name: "Old Trip", URLSlug: "old-trip", Currency: "USD"})
+
+	// Act
+	_, err := service.RotateEditToken(context.Background(), &services.RotateEditTokenRequest{UrlSlug: "old-trip", EditToken: "guess"})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no edit token")
+}
+
+func TestGetParticipantFairShare_MatchesThreePersonExample(t *testing.T) {
+	// Arrange: Alice pays 30 for dinner and Bob 24 for gas, both split equally between all three
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, charlie.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 24, alice.ID, bob.ID, charlie.ID)
+
+	expected := map[uint]struct{ paid, difference float64 }{
+		alice.ID:   {30, 12},
+		bob.ID:     {24, 6},
+		charlie.ID: {0, -18},
+	}
+
+	for participantID, want := range expected {
+		// Act
+		resp, err := service.GetParticipantFairShare(context.Background(), &services.GetParticipantFairShareRequest{UrlSlug: "trip", ParticipantId: int32(participantID)})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 54.0, resp.GroupTotal)
+		assert.Equal(t, 18.0, resp.FairShare)
+		assert.Equal(t, want.paid, resp.Paid)
+		assert.Equal(t, want.difference, resp.Difference)
+	}
+}
+
+func TestFindParticipant_MatchesNameCaseInsensitivelyAcrossGroups(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	trip := database.Group{Name: "Trip", URLSlug: "trip"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat"}
+	office := database.Group{Name: "Office", URLSlug: "office"}
+	db.Create(&trip)
+	db.Create(&flat)
+	db.Create(&office)
+	tripAlice := database.Participant{Name: "Alice", GroupID: trip.ID}
+	flatBob := database.Participant{Name: "Bob", GroupID: flat.ID}
+	officeAlice := database.Participant{Name: "ALICE", GroupID: office.ID}
+	officeAlice2 := database.Participant{Name: "alice", GroupID: office.ID}
+	db.Create(&tripAlice)
+	db.Create(&flatBob)
+	db.Create(&officeAlice)
+	db.Create(&officeAlice2)
+
+	// Act
+	resp, err := service.FindParticipant(context.Background(), &services.FindParticipantRequest{
+		Name:       " alice ",
+		GroupSlugs: []string{"office", "flat", "trip", "missing"},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Matches, 3)
+	assert.Equal(t, "office", resp.Matches[0].GroupUrlSlug)
+	assert.Equal(t, int32(officeAlice.ID), resp.Matches[0].ParticipantId)
+	assert.Equal(t, "ALICE", resp.Matches[0].ParticipantName)
+	assert.Equal(t, int32(officeAlice2.ID), resp.Matches[1].ParticipantId)
+	assert.Equal(t, "trip", resp.Matches[2].GroupUrlSlug)
+	assert.Equal(t, "Trip", resp.Matches[2].GroupName)
+	assert.Equal(t, int32(tripAlice.ID), resp.Matches[2].ParticipantId)
+}
+
+func TestFindParticipant_ReturnsEmptyWhenNameIsAbsent(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	flat := database.Group{Name: "Flat", URLSlug: "flat"}
+	db.Create(&flat)
+	db.Create(&database.Participant{Name: "Bob", GroupID: flat.ID})
+
+	// Act
+	resp, err := service.FindParticipant(context.Background(), &services.FindParticipantRequest{Name: "Alice", GroupSlugs: []string{"flat"}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Matches)
+}
+
+func TestCreateGroup_RollsBackGroupWhenParticipantInsertFails(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	db.Callback().Create().Before("gorm:create").Register("test:fail_participants", func(tx *gorm.DB) {
+		if tx.Statement.Table == "participants" {
+			tx.AddError(fmt.Errorf("simulated participant insert failure"))
+		}
+	})
+
+	// Act
+	resp, err := service.CreateGroup(context.Background(), &services.CreateGroupRequest{
+		Name:             "Trip",
+		Currency:         "USD",
+		ParticipantNames: []string{"Alice", "Bob"},
+	})
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "failed to create participants")
+	var groupCount int64
+	db.Model(&database.Group{}).Count(&groupCount)
+	assert.Equal(t, int64(0), groupCount)
+}
+
+func TestGroupDescription_RoundTripsThroughCreateUpdateAndGet(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+	created, err := service.CreateGroup(ctx, &services.CreateGroupRequest{
+		Name:             "Italy",
+		Currency:         "EUR",
+		Description:      "  Summer Italy trip 2024. Fuel is split by distance.  ",
+		ParticipantNames: []string{"Alice", "Bob"},
+	})
+	assert.NoError(t, err)
+	renamed := "Summer Italy trip 2024, rules: settle up before flying home"
+
+	// Act
+	fetched, getErr := service.GetGroup(ctx, &services.GetGroupRequest{UrlSlug: created.Group.UrlSlug})
+	_, keepErr := service.UpdateGroup(ctx, &services.UpdateGroupRequest{Name: "Italy 2024", Currency: "EUR", ParticipantId: created.Group.Id})
+	kept, _ := service.GetGroup(ctx, &services.GetGroupRequest{UrlSlug: created.Group.UrlSlug})
+	updated, updateErr := service.UpdateGroup(ctx, &services.UpdateGroupRequest{Name: "Italy 2024", Currency: "EUR", Description: &renamed, ParticipantId: created.Group.Id})
+
+	// Assert
+	assert.NoError(t, getErr)
+	assert.Equal(t, "Summer Italy trip 2024. Fuel is split by distance.", created.Group.Description)
+	assert.Equal(t, created.Group.Description, fetched.Group.Description)
+	assert.NoError(t, keepErr)
+	assert.Equal(t, created.Group.Description, kept.Group.Description)
+	assert.NoError(t, updateErr)
+	assert.Equal(t, renamed, updated.Group.Description)
+}
+
+func TestCreateGroup_RejectsOverlongDescription(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+
+	// Act
+	result, err := service.CreateGroup(context.Background(), &services.CreateGroupRequest{
+		Name:             "Trip",
+		Currency:         "USD",
+		Description:      strings.Repeat("é", 2001),
+		ParticipantNames: []string{"Alice"},
+	})
+
+	// Assert
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "description cannot be longer than 2000 characters")
+}
+
+func TestCreateGroup_DefaultsAmountDisplayToCentsAndRejectsUnknownMode(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	create := func(display string) (*services.CreateGroupResponse, error) {
+		return service.CreateGroup(context.Background(), &services.CreateGroupRequest{
+			Name:             "Trip",
+			Currency:         "USD",
+			AmountDisplay:    display,
+			ParticipantNames: []string{"Alice"},
+		})
+	}
+
+	// Act
+	defaulted, defaultErr := create("")
+	whole, wholeErr := create(services.AmountDisplayWhole)
+	_, badErr := create("dollars")
+
+	// Assert
+	assert.NoError(t, defaultErr)
+	assert.Equal(t, services.AmountDisplayCents, defaulted.Group.AmountDisplay)
+	assert.NoError(t, wholeErr)
+	assert.Equal(t, services.AmountDisplayWhole, whole.Group.AmountDisplay)
+	assert.ErrorContains(t, badErr, "invalid group: amount_display")
+}
+
+func TestGetParticipantSpendingSummary_ExcludesExpensesOutsideRange(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	// Alice pays 40 in May, then 30 in June; Bob pays 20 in June and 50 in July
+	dated := func(payerID uint, cost float64, date time.Time) {
+		created := seedEqualExpense(t, db, group.ID, payerID, cost, alice.ID, bob.ID)
+		db.Model(&database.Expense{}).Where("id = ?", created.Expense.Id).Update("created_at", date)
+	}
+	dated(alice.ID, 40, time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC))
+	dated(alice.ID, 30, time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	dated(bob.ID, 20, time.Date(2024, 6, 30, 20, 0, 0, 0, time.UTC))
+	dated(bob.ID, 50, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	resp, err := service.GetParticipantSpendingSummary(context.Background(), &services.GetParticipantSpendingSummaryRequest{
+		UrlSlug:       "trip",
+		ParticipantId: int32(alice.ID),
+		From:          &from,
+		To:            &to,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.Equal(t, 30.0, resp.Paid)
+	assert.Equal(t, 25.0, resp.Consumed)
+	assert.Equal(t, 5.0, resp.Net)
+}
+
+func TestGetParticipantSpendingSummary_RejectsEmptyRange(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	resp, err := service.GetParticipantSpendingSummary(context.Background(), &services.GetParticipantSpendingSummaryRequest{
+		UrlSlug:       "trip",
+		ParticipantId: int32(alice.ID),
+		From:          &day,
+		To:            &day,
+	})
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "invalid range")
+}
+
+func TestGetGroupDiagnostics_ReportsNothingForConsistentGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&charlie)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, charlie.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 24, alice.ID, bob.ID, charlie.ID)
+	_, err := services.NewDebtService(db).SettlePair(context.Background(), &services.SettlePairRequest{
+		UrlSlug: "trip", PayerId: int32(charlie.ID), PayeeId: int32(alice.ID), Amount: 12,
+	})
+	assert.NoError(t, err)
+
+	// Act
+	resp, err := service.GetGroupDiagnostics(context.Background(), &services.GetGroupDiagnosticsRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.Empty(t, resp.Anomalies)
+}
+
+func TestGetGroupDiagnostics_ReportsExpenseWhoseSplitsDontAddUp(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	// Written directly so the expense service's validation doesn't fix the splits
+	bad := database.Expense{Name: "Dinner", Cost: 30, Emoji: "🍽️", PayerID: alice.ID, GroupID: group.ID, SplitType: "amount"}
+	db.Create(&bad)
+	db.Create(&database.Split{GroupID: group.ID, ExpenseID: bad.ID, ParticipantID: alice.ID, SplitAmount: 15})
+	db.Create(&database.Split{GroupID: group.ID, ExpenseID: bad.ID, ParticipantID: bob.ID, SplitAmount: 10})
+
+	// Act
+	resp, err := service.GetGroupDiagnostics(context.Background(), &services.GetGroupDiagnosticsRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, resp.Healthy)
+	var mismatch *services.GroupAnomaly
+	for _, anomaly := range resp.Anomalies {
+		if anomaly.Kind == services.AnomalySplitSumMismatch {
+			mismatch = anomaly
+		}
+	}
+	if assert.NotNil(t, mismatch) {
+		assert.Equal(t, "expense", mismatch.EntityType)
+		assert.Equal(t, int32(bad.ID), mismatch.EntityId)
+		assert.Equal(t, 5.0, mismatch.Amount)
+		assert.Contains(t, mismatch.Message, "splits add up to 25.00")
+	}
+}
+
+func TestGetGroupDiagnostics_ChecksEachPaymentAgainstDebtsBeforeIt(t *testing.T) {
+	// Arrange: Bob owes Alice 15, pays 10, then pays another 10 when only 5 was left.
+	// A later expense paid by Bob doesn't excuse the second payment
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	dinner := seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID)
+	db.Model(&database.Expense{}).Where("id = ?", dinner.Expense.Id).Update("created_at", start)
+	first := database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 10, CreatedAt: start.Add(time.Hour)}
+	second := database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 10, CreatedAt: start.Add(2 * time.Hour)}
+	db.Create(&first)
+	db.Create(&second)
+	taxi := seedEqualExpense(t, db, group.ID, bob.ID, 20, alice.ID, bob.ID)
+	db.Model(&database.Expense{}).Where("id = ?", taxi.Expense.Id).Update("created_at", start.Add(3*time.Hour))
+
+	// Act
+	resp, err := service.GetGroupDiagnostics(context.Background(), &services.GetGroupDiagnosticsRequest{UrlSlug: "trip"})
+
+	// Assert
+	assert.NoError(t, err)
+	var overpayments []*services.GroupAnomaly
+	for _, anomaly := range resp.Anomalies {
+		if anomaly.Kind == services.AnomalyOverpayment {
+			overpayments = append(overpayments, anomaly)
+		}
+	}
+	if assert.Len(t, overpayments, 1) {
+		assert.Equal(t, int32(second.ID), overpayments[0].EntityId)
+		assert.Equal(t, 5.0, overpayments[0].Amount)
+	}
+}
+
+func TestMergeGroups_MergedDebtsReflectBothGroups(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+
+	target := database.Group{Name: "Ski trip", URLSlug: "ski", Currency: "USD"}
+	source := database.Group{Name: "Ski trip (2)", URLSlug: "ski-2", Currency: "USD"}
+	db.Create(&target)
+	db.Create(&source)
+	alice := database.Participant{Name: "Alice", GroupID: target.ID}
+	bob := database.Participant{Name: "Bob", GroupID: target.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	sourceAlice := database.Participant{Name: "alice", GroupID: source.ID}
+	charlie := database.Participant{Name: "Charlie", GroupID: source.ID}
+	db.Create(&sourceAlice)
+	db.Create(&charlie)
+
+	// Bob owes Alice 15 in the target; Alice owes Charlie 20 in the source
+	seedEqualExpense(t, db, target.ID, alice.ID, 30, alice.ID, bob.ID)
+	seedEqualExpense(t, db, source.ID, charlie.ID, 40, sourceAlice.ID, charlie.ID)
+	db.Create(&database.Payment{GroupID: source.ID, PayerID: sourceAlice.ID, PayeeID: charlie.ID, Amount: 5})
+
+	// Act
+	resp, err := service.MergeGroups(ctx, &services.MergeGroupsRequest{SourceSlug: "ski-2", TargetSlug: "ski"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), resp.ParticipantsCreated)
+	assert.Equal(t, int32(1), resp.ExpensesMoved)
+	assert.Equal(t, int32(1), resp.PaymentsMoved)
+	assert.Len(t, resp.Participants, 3)
+	newCharlieID := uint(resp.Participants[2].Id)
+	assert.Equal(t, "Charlie", resp.Participants[2].Name)
+
+	balances, _, err := services.CalculateBalances(db, target.ID)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.0, balances[alice.ID], 0.001) // +15 from Bob, -20 to Charlie, +5 paid
+	assert.InDelta(t, -15.0, balances[bob.ID], 0.001)
+	assert.InDelta(t, 15.0, balances[newCharlieID], 0.001)
+
+	var debts []database.Debt
+	db.Where("group_id = ?", target.ID).Find(&debts)
+	assert.Len(t, debts, 1)
+	assert.Equal(t, bob.ID, debts[0].DebtorID)
+	assert.Equal(t, newCharlieID, debts[0].LenderID)
+	assert.InDelta(t, 15.0, debts[0].DebtAmount, 0.001)
+	assertMoneyConserved(t, db, target.ID)
+
+	var archived database.Group
+	db.First(&archived, source.ID)
+	assert.Equal(t, "archived", archived.State)
+	var leftover int64
+	db.Model(&database.Expense{}).Where("group_id = ?", source.ID).Count(&leftover)
+	assert.Equal(t, int64(0), leftover)
+	db.Model(&database.Debt{}).Where("group_id = ?", source.ID).Count(&leftover)
+	assert.Equal(t, int64(0), leftover)
+}
+
+func TestMergeGroups_RequiresBothEditTokens(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+	target, err := service.CreateGroup(ctx, &services.CreateGroupRequest{Name: "Ski", Currency: "USD", ParticipantNames: []string{"Alice"}})
+	assert.NoError(t, err)
+	source, err := service.CreateGroup(ctx, &services.CreateGroupRequest{Name: "Ski 2", Currency: "USD", ParticipantNames: []string{"Alice"}})
+	assert.NoError(t, err)
+	req := func(targetToken, sourceToken string) *services.MergeGroupsRequest {
+		return &services.MergeGroupsRequest{
+			SourceSlug:      source.Group.UrlSlug,
+			SourceEditToken: sourceToken,
+			TargetSlug:      target.Group.UrlSlug,
+			EditToken:       targetToken,
+		}
+	}
+
+	// Act
+	_, noTargetErr := service.MergeGroups(ctx, req("", source.EditToken))
+	_, noSourceErr := service.MergeGroups(ctx, req(target.EditToken, ""))
+	_, swappedErr := service.MergeGroups(ctx, req(source.EditToken, target.EditToken))
+	var sourceState database.Group
+	db.First(&sourceState, source.Group.Id)
+	_, err = service.MergeGroups(ctx, req(target.EditToken, source.EditToken))
+
+	// Assert
+	assert.ErrorContains(t, noTargetErr, "invalid edit token")
+	assert.ErrorContains(t, noSourceErr, "source invalid edit token")
+	assert.ErrorContains(t, swappedErr, "invalid edit token")
+	assert.Equal(t, "active", sourceState.State)
+	assert.NoError(t, err)
+}
+
+func TestMergeGroups_RejectsSelfMergeAndCurrencyMismatch(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	ctx := context.Background()
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"})
+	db.Create(&database.Group{Name: "Trip EUR", URLSlug: "trip-eur", Currency: "EUR"})
+
+	// Act
+	_, selfErr := service.MergeGroups(ctx, &services.MergeGroupsRequest{SourceSlug: "trip", TargetSlug: "trip"})
+	_, currencyErr := service.MergeGroups(ctx, &services.MergeGroupsRequest{SourceSlug: "trip-eur", TargetSlug: "trip"})
+	_, missingErr := service.MergeGroups(ctx, &services.MergeGroupsRequest{SourceSlug: "gone", TargetSlug: "trip"})
+
+	// Assert
+	assert.ErrorContains(t, selfErr, "invalid merge")
+	assert.ErrorContains(t, currencyErr, "invalid merge")
+	assert.ErrorContains(t, missingErr, "not found")
+	var group database.Group
+	db.Where("url_slug = ?", "trip-eur").First(&group)
+	assert.Equal(t, "active", group.State)
+}
+
+func TestGetSpendingTimeSeries_BucketsExpensesByMonth(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	for _, e := range []struct {
+		cost float64
+		at   time.Time
+	}{
+		{30, time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)},
+		{12.5, time.Date(2024, 1, 31, 23, 59, 0, 0, time.UTC)},
+		{20, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		db.Create(&database.Expense{Name: "Expense", Cost: e.cost, PayerID: alice.ID, SplitType: "equal", GroupID: group.ID, CreatedAt: e.at})
+	}
+
+	// Act
+	resp, err := service.GetSpendingTimeSeries(context.Background(), &services.GetSpendingTimeSeriesRequest{UrlSlug: "trip", Bucket: services.BucketMonth})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "month", resp.Bucket)
+	assert.Equal(t, []*services.SpendingBucket{
+		{Period: "2024-01-01", Total: 42.5},
+		{Period: "2024-02-01", Total: 20},
+	}, resp.Buckets)
+}
+
+func TestGetSpendingTimeSeries_FillsEmptyWeeksAndRejectsUnknownBucket(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	// Sunday 7 Jan 2024 belongs to the week starting Monday 1 Jan; 22 Jan is two weeks later
+	db.Create(&database.Expense{Name: "Taxi", Cost: 10, PayerID: alice.ID, SplitType: "equal", GroupID: group.ID, CreatedAt: time.Date(2024, 1, 7, 18, 0, 0, 0, time.UTC)})
+	db.Create(&database.Expense{Name: "Hotel", Cost: 80, PayerID: alice.ID, SplitType: "equal", GroupID: group.ID, CreatedAt: time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC)})
+	empty := database.Group{Name: "Empty", URLSlug: "empty", Currency: "USD"}
+	db.Create(&empty)
+
+	// Act
+	resp, err := service.GetSpendingTimeSeries(context.Background(), &services.GetSpendingTimeSeriesRequest{UrlSlug: "trip", Bucket: services.BucketWeek})
+	emptyResp, emptyErr := service.GetSpendingTimeSeries(context.Background(), &services.GetSpendingTimeSeriesRequest{UrlSlug: "empty"})
+	_, bucketErr := service.GetSpendingTimeSeries(context.Background(), &services.GetSpendingTimeSeriesRequest{UrlSlug: "trip", Bucket: "year"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []*services.SpendingBucket{
+		{Period: "2024-01-01", Total: 10},
+		{Period: "2024-01-08", Total: 0},
+		{Period: "2024-01-15", Total: 0},
+		{Period: "2024-01-22", Total: 80},
+	}, resp.Buckets)
+	assert.NoError(t, emptyErr)
+	assert.Equal(t, "month", emptyResp.Bucket)
+	assert.Empty(t, emptyResp.Buckets)
+	assert.ErrorContains(t, bucketErr, "invalid bucket")
+}
+
+func TestGetParticipantStatement_CollectsEverySection(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+	dinner := seedEqualExpense(t, db, group.ID, alice.ID, 60, alice.ID, bob.ID)
+	taxi := seedEqualExpense(t, db, group.ID, bob.ID, 30, bob.ID, carol.ID)
+	seedEqualExpense(t, db, group.ID, carol.ID, 20, alice.ID, carol.ID) // Bob is not part of it
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 10})
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: carol.ID, PayeeID: alice.ID, Amount: 5}) // not Bob's
+
+	// Act
+	resp, err := service.GetParticipantStatement(context.Background(), &services.GetParticipantStatementRequest{
+		UrlSlug:       "trip",
+		ParticipantId: int32(bob.ID),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob", resp.Participant.Name)
+	assert.Len(t, resp.PaidExpenses, 1)
+	assert.Equal(t, taxi.Expense.Id, resp.PaidExpenses[0].Id)
+	assert.Len(t, resp.Splits, 2)
+	splitsByExpense := make(map[int32]*services.StatementSplit)
+	for _, split := range resp.Splits {
+		splitsByExpense[split.ExpenseId] = split
+	}
+	assert.Equal(t, "Alice", splitsByExpense[dinner.Expense.Id].PayerName)
+	assert.Equal(t, 30.0, splitsByExpense[dinner.Expense.Id].SplitAmount)
+	assert.Equal(t, "Bob", splitsByExpense[taxi.Expense.Id].PayerName)
+	assert.Equal(t, 15.0, splitsByExpense[taxi.Expense.Id].SplitAmount)
+	assert.Len(t, resp.Payments, 1)
+	assert.Equal(t, "sent", resp.Payments[0].Direction)
+	assert.Equal(t, "Alice", resp.Payments[0].CounterpartName)
+	assert.Equal(t, 30.0, resp.TotalPaid)
+	assert.Equal(t, 45.0, resp.TotalShare)
+	assert.Equal(t, -5.0, resp.NetBalance) // paid 30, share 45, sent 10
+}
+
+func TestGetParticipantStatement_ReturnsErrorForParticipantInAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	other := database.Group{Name: "Other", URLSlug: "other", Currency: "USD"}
+	db.Create(&group)
+	db.Create(&other)
+	outsider := database.Participant{Name: "Eve", GroupID: other.ID}
+	db.Create(&outsider)
+
+	// Act
+	_, err := service.GetParticipantStatement(context.Background(), &services.GetParticipantStatementRequest{
+		UrlSlug:       "trip",
+		ParticipantId: int32(outsider.ID),
+	})
+
+	// Assert
+	assert.ErrorContains(t, err, "participant not found")
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"freesplit/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_SuppressesDebugAtInfoLevel(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo)
+
+	// Act
+	log.Debugf("[GET_GROUP] Starting request for %s", "abc123")
+	log.Errorf("Error getting group %s: %v", "abc123", "boom")
+
+	// Assert
+	assert.NotContains(t, buf.String(), "Starting request")
+	assert.Contains(t, buf.String(), "ERROR Error getting group abc123: boom")
+}
+
+func TestLogger_WritesDebugAtDebugLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelDebug)
+
+	log.Debugf("step %d", 1)
+
+	assert.Contains(t, buf.String(), "DEBUG step 1")
+}
+
+func TestParseLevel_AcceptsKnownNamesAndRejectsOthers(t *testing.T) {
+	level, err := logger.ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, logger.LevelWarn, level)
+
+	level, err = logger.ParseLevel("")
+	assert.NoError(t, err)
+	assert.Equal(t, logger.LevelInfo, level)
+
+	_, err = logger.ParseLevel("verbose")
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"freesplit/internal/openapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIDocument_IsValidJSONWithMainPaths(t *testing.T) {
+	// Act
+	raw, err := json.Marshal(openapi.Document())
+
+	// Assert
+	assert.NoError(t, err)
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	assert.Contains(t, doc.Paths["/api/group"], "post")
+	assert.Contains(t, doc.Paths["/api/group/{url_slug}"], "get")
+	assert.Contains(t, doc.Paths["/api/group/{group_id}/expenses"], "post")
+	assert.Contains(t, doc.Paths["/api/expense/{expense_id}"], "put")
+	assert.Contains(t, doc.Paths["/api/debts/{debt_id}/paid"], "put")
+	assert.Contains(t, doc.Paths["/api/user-groups/summary"], "post")
+
+	assert.Contains(t, doc.Components.Schemas, "Expense")
+	assert.Contains(t, doc.Components.Schemas, "CreateGroupRequest")
+}
+
+func TestOpenAPIDocument_DeclaresPathParameters(t *testing.T) {
+	// Act
+	raw, _ := json.Marshal(openapi.Document())
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+		} `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(raw, &doc))
+
+	// Assert
+	params := doc.Paths["/api/group/{url_slug}/participants/{participant_id}/payments"]["get"].Parameters
+	assert.Equal(t, 2, len(params))
+	assert.Equal(t, "url_slug", params[0].Name)
+	assert.Equal(t, "participant_id", params[1].Name)
+	assert.Equal(t, "path", params[1].In)
+}
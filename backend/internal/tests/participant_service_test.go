@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"freesplit/internal/database"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddParticipant_TrimsSurroundingWhitespace(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	db.Create(&group)
+
+	req := &services.AddParticipantRequest{Name: "  Alice \t", GroupId: int32(group.ID)}
+
+	// Act
+	result, err := service.AddParticipant(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", result.Participant.Name)
+
+	var stored database.Participant
+	db.First(&stored, result.Participant.Id)
+	assert.Equal(t, "Alice", stored.Name)
+}
+
+func TestAddParticipant_ReturnsErrorForWhitespaceOnlyName(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	db.Create(&group)
+
+	req := &services.AddParticipantRequest{Name: "   ", GroupId: int32(group.ID)}
+
+	// Act
+	result, err := service.AddParticipant(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "participant name cannot be empty")
+
+	var count int64
+	db.Model(&database.Participant{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestAddParticipants_CreatesAllParticipantsInBatch(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	db.Create(&group)
+
+	req := &services.AddParticipantsRequest{UrlSlug: group.URLSlug, Names: []string{"Alice", " Bob ", "Charlie"}}
+
+	// Act
+	result, err := service.AddParticipants(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(result.Participants))
+	assert.Equal(t, "Bob", result.Participants[1].Name)
+}
+
+func TestAddParticipants_RejectsBatchWithInternalDuplicate(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	db.Create(&group)
+
+	req := &services.AddParticipantsRequest{UrlSlug: group.URLSlug, Names: []string{"Alice", "Bob", "alice"}}
+
+	// Act
+	result, err := service.AddParticipants(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "duplicate participant name")
+
+	var count int64
+	db.Model(&database.Participant{}).Where("group_id = ?", group.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestAddParticipants_RejectsNameAlreadyInGroup(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	ctx := context.Background()
+
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	db.Create(&group)
+	db.Create(&database.Participant{Name: "Alice", GroupID: group.ID})
+
+	req := &services.AddParticipantsRequest{UrlSlug: group.URLSlug, Names: []string{"Bob", "Alice"}}
+
+	// Act
+	result, err := service.AddParticipants(ctx, req)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	var count int64
+	db.Model(&database.Participant{}).Where("group_id = ?", group.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestDeleteParticipant_RefusesToDeleteLastParticipant(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+
+	// Act
+	err := service.DeleteParticipant(context.Background(), &services.DeleteParticipantRequest{ParticipantId: int32(alice.ID)})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "last participant")
+
+	var count int64
+	db.Model(&database.Participant{}).Where("group_id = ?", group.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestDeleteParticipant_DeletesParticipantWhenOthersRemain(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	group := database.Group{Name: "Test Group", URLSlug: "test-group"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	// Act
+	err := service.DeleteParticipant(context.Background(), &services.DeleteParticipantRequest{ParticipantId: int32(bob.ID)})
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+func TestListParticipants_SortByBalancePutsMostOwedFirst(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	charlie := database.Participant{Name: "Charlie", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&charlie)
+	db.Create(&bob)
+	db.Create(&alice)
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID, charlie.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 24, alice.ID, bob.ID, charlie.ID)
+
+	// Act
+	resp, err := service.ListParticipants(context.Background(), &services.ListParticipantsRequest{UrlSlug: "trip", Sort: "balance"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.Len(t, resp.Participants, 3)
+	assert.Equal(t, "Alice", resp.Participants[0].Name)
+	assert.Equal(t, 12.0, resp.Participants[0].NetBalance)
+	assert.Equal(t, "Bob", resp.Participants[1].Name)
+	assert.Equal(t, 6.0, resp.Participants[1].NetBalance)
+	assert.Equal(t, "Charlie", resp.Participants[2].Name)
+	assert.Equal(t, -18.0, resp.Participants[2].NetBalance)
+}
+
+func TestListParticipants_RejectsUnknownSort(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip"})
+
+	// Act
+	resp, err := service.ListParticipants(context.Background(), &services.ListParticipantsRequest{UrlSlug: "trip", Sort: "name"})
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "invalid sort")
+}
+
+func TestVerifyParticipant_ReturnsCurrentNameOfExistingParticipant(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	db.Model(&alice).Update("name", "Alicia")
+
+	// Act
+	resp, err := service.VerifyParticipant(context.Background(), &services.VerifyParticipantRequest{UrlSlug: "trip", ParticipantId: int32(alice.ID)})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, resp.Exists)
+	assert.Equal(t, int32(alice.ID), resp.Participant.Id)
+	assert.Equal(t, "Alicia", resp.Participant.Name)
+}
+
+func TestVerifyParticipant_ReturnsNotFoundForDeletedParticipant(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewParticipantService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	assert.NoError(t, service.DeleteParticipant(context.Background(), &services.DeleteParticipantRequest{ParticipantId: int32(bob.ID)}))
+
+	// Act
+	resp, err := service.VerifyParticipant(context.Background(), &services.VerifyParticipantRequest{UrlSlug: "trip", ParticipantId: int32(bob.ID)})
+
+	// Assert
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "participant not found")
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"freesplit/internal/database"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIdentityToken = "2f6c1a9e4b7d4c0f9a3e5b8d1c7f0a2e"
+
+func TestSetUserGroupPreferences_PersistsPinnedStatusAcrossRequests(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewPreferenceService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"})
+	db.Create(&database.Group{Name: "Flat", URLSlug: "flat", Currency: "EUR"})
+	ctx := context.Background()
+
+	_, err := service.SetUserGroupPreferences(ctx, &services.SetUserGroupPreferencesRequest{
+		IdentityToken: testIdentityToken,
+		Preferences: []*services.UserGroupPreference{
+			{GroupUrlSlug: "trip", Pinned: true, SortOrder: 1},
+			{GroupUrlSlug: "flat", SortOrder: 0},
+		},
+	})
+	assert.NoError(t, err)
+
+	// Act: pin the other group later, e.g. from another device
+	_, updateErr := service.SetUserGroupPreferences(ctx, &services.SetUserGroupPreferencesRequest{
+		IdentityToken: testIdentityToken,
+		Preferences:   []*services.UserGroupPreference{{GroupUrlSlug: "flat", Pinned: true, SortOrder: 0}},
+	})
+	resp, getErr := service.GetUserGroupPreferences(ctx, &services.GetUserGroupPreferencesRequest{IdentityToken: testIdentityToken})
+
+	// Assert
+	assert.NoError(t, updateErr)
+	assert.NoError(t, getErr)
+	assert.Len(t, resp.Preferences, 2)
+	assert.Equal(t, "flat", resp.Preferences[0].GroupUrlSlug)
+	assert.True(t, resp.Preferences[0].Pinned)
+	assert.Equal(t, "trip", resp.Preferences[1].GroupUrlSlug)
+	assert.True(t, resp.Preferences[1].Pinned)
+	assert.Equal(t, int32(1), resp.Preferences[1].SortOrder)
+
+	var stored int64
+	db.Model(&database.UserGroupPreference{}).Count(&stored)
+	assert.Equal(t, int64(2), stored)
+}
+
+func TestSetUserGroupPreferences_RejectsUnknownGroupAndShortToken(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	service := services.NewPreferenceService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"})
+	ctx := context.Background()
+
+	// Act
+	_, unknownErr := service.SetUserGroupPreferences(ctx, &services.SetUserGroupPreferencesRequest{
+		IdentityToken: testIdentityToken,
+		Preferences: []*services.UserGroupPreference{
+			{GroupUrlSlug: "trip", Pinned: true},
+			{GroupUrlSlug: "missing", Pinned: true},
+		},
+	})
+	_, tokenErr := service.GetUserGroupPreferences(ctx, &services.GetUserGroupPreferencesRequest{IdentityToken: "short"})
+
+	// Assert
+	assert.ErrorContains(t, unknownErr, "group not found")
+	assert.ErrorContains(t, tokenErr, "invalid identity token")
+	var stored int64
+	db.Model(&database.UserGroupPreference{}).Count(&stored)
+	assert.Equal(t, int64(0), stored)
+}
+
+func TestGetUserGroupsSummary_MergesIdentityPreferences(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	trip := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat", Currency: "USD"}
+	db.Create(&trip)
+	db.Create(&flat)
+	alice := database.Participant{Name: "Alice", GroupID: trip.ID}
+	flatAlice := database.Participant{Name: "Alice", GroupID: flat.ID}
+	db.Create(&alice)
+	db.Create(&flatAlice)
+	_, err := services.NewPreferenceService(db).SetUserGroupPreferences(context.Background(), &services.SetUserGroupPreferencesRequest{
+		IdentityToken: testIdentityToken,
+		Preferences:   []*services.UserGroupPreference{{GroupUrlSlug: "flat", Pinned: true, SortOrder: 3}},
+	})
+	assert.NoError(t, err)
+
+	// Act
+	resp, err := services.NewDebtService(db).GetUserGroupsSummary(context.Background(), &services.UserGroupsSummaryRequest{
+		IdentityToken: testIdentityToken,
+		Groups: []*services.UserGroupRequest{
+			{GroupUrlSlug: "trip", UserParticipantId: int32(alice.ID)},
+			{GroupUrlSlug: "flat", UserParticipantId: int32(flatAlice.ID)},
+		},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Groups, 2)
+	assert.Equal(t, "trip", resp.Groups[0].GroupUrlSlug)
+	assert.False(t, resp.Groups[0].Pinned)
+	assert.Equal(t, "flat", resp.Groups[1].GroupUrlSlug)
+	assert.True(t, resp.Groups[1].Pinned)
+	assert.Equal(t, int32(3), resp.Groups[1].SortOrder)
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"freesplit/internal/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_RejectsKeyOverQuotaUntilWindowPasses(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := ratelimit.New(2, 0, time.Hour)
+	limiter.SetClock(func() time.Time { return now })
+
+	// Act
+	first := limiter.Allow("10.0.0.1")
+	second := limiter.Allow("10.0.0.1")
+	third := limiter.Allow("10.0.0.1")
+	otherIP := limiter.Allow("10.0.0.2")
+	now = now.Add(time.Hour + time.Second)
+	afterWindow := limiter.Allow("10.0.0.1")
+
+	// Assert
+	assert.True(t, first)
+	assert.True(t, second)
+	assert.False(t, third)
+	assert.True(t, otherIP)
+	assert.True(t, afterWindow)
+}
+
+func TestLimiter_GlobalCapAppliesAcrossKeys(t *testing.T) {
+	// Arrange
+	limiter := ratelimit.New(0, 2, time.Hour)
+
+	// Act & Assert
+	assert.True(t, limiter.Allow("10.0.0.1"))
+	assert.True(t, limiter.Allow("10.0.0.2"))
+	assert.False(t, limiter.Allow("10.0.0.3"))
+}
+
+func TestClientIP_UsesForwardedForOnlyWhenTrusted(t *testing.T) {
+	// Arrange
+	req := httptest.NewRequest("POST", "/api/group", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	// Act & Assert
+	assert.Equal(t, "10.0.0.1", ratelimit.ClientIP(req, false))
+	assert.Equal(t, "203.0.113.7", ratelimit.ClientIP(req, true))
+}
@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"freesplit/internal/database"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSemaphore_BoundsConcurrentHolders(t *testing.T) {
+	// Arrange
+	semaphore := services.NewSemaphore(2)
+	var inFlight, peak int32
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore.Acquire()
+			defer semaphore.Release()
+
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				previous := atomic.LoadInt32(&peak)
+				if current <= previous || atomic.CompareAndSwapInt32(&peak, previous, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(2), peak)
+	assert.Equal(t, int32(0), inFlight)
+}
+
+func TestSetMaxConcurrentRecalculations_UpdatesLimit(t *testing.T) {
+	// Arrange
+	original := services.MaxConcurrentRecalculations()
+	defer services.SetMaxConcurrentRecalculations(original)
+
+	// Act
+	services.SetMaxConcurrentRecalculations(3)
+
+	// Assert
+	assert.Equal(t, 3, services.MaxConcurrentRecalculations())
+
+	// Non-positive limits fall back to a single slot
+	services.SetMaxConcurrentRecalculations(0)
+	assert.Equal(t, 1, services.MaxConcurrentRecalculations())
+}
+
+func TestCreateExpense_ConcurrentWritesQueueForRecalculationSlot(t *testing.T) {
+	// Arrange: a shared-cache database, so every goroutine's connection sees the same data. SQLite refuses a
+	// second concurrent writer outright, so any write transaction opened outside the single slot fails
+	original := services.MaxConcurrentRecalculations()
+	defer services.SetMaxConcurrentRecalculations(original)
+	services.SetMaxConcurrentRecalculations(1)
+	db, err := gorm.Open(sqlite.Open("file:concurrent_recalculations?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	database.Migrate(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD", SimplifyDebts: true}
+	db.Create(&group)
+	participants := make([]database.Participant, 4)
+	for i, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		participants[i] = database.Participant{Name: name, GroupID: group.ID}
+		db.Create(&participants[i])
+	}
+	// Hold each transaction open a little after it writes the expense, so unqueued writers would overlap
+	db.Callback().Create().After("gorm:create").Register("test:slow_expense_insert", func(tx *gorm.DB) {
+		if tx.Statement.Table == "expenses" {
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+	service := services.NewExpenseService(db)
+	const writes = 12
+
+	// Act
+	var wg sync.WaitGroup
+	errs := make(chan error, writes)
+	for i := 0; i < writes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			payer := participants[i%3]
+			splits := make([]*services.Split, len(participants))
+			for j, p := range participants {
+				splits[j] = &services.Split{GroupId: int32(group.ID), ParticipantId: int32(p.ID), SplitAmount: 10}
+			}
+			_, err := service.CreateExpense(context.Background(), &services.CreateExpenseRequest{
+				Expense: &services.Expense{Name: "Round", Cost: 40, PayerId: int32(payer.ID), SplitType: "equal", GroupId: int32(group.ID)},
+				Splits:  splits,
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	// Assert: every write went through, and the stored debts match the final ledger
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	var expenses int64
+	db.Model(&database.Expense{}).Where("group_id = ?", group.ID).Count(&expenses)
+	assert.Equal(t, int64(writes), expenses)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+// seedResidualDebt leaves Bob owing Alice 0.007 USD: an expense of 40 split with Bob, then a payment of 19.993
+// and returns the group's stored debts and debt-settled activity entries
+func seedResidualDebt(t *testing.T) ([]database.Debt, []database.Activity) {
+	db, group := seedResidualGroup(t)
+	return residualState(db, group.ID)
+}
+
+// seedResidualGroup builds the group behind seedResidualDebt and returns it with its database
+func seedResidualGroup(t *testing.T) (*gorm.DB, database.Group) {
+	db := setupTestDB()
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	seedEqualExpense(t, db, group.ID, alice.ID, 40, alice.ID, bob.ID)
+
+	var debt database.Debt
+	db.Where("group_id = ?", group.ID).First(&debt)
+	_, err := services.NewDebtService(db).CreatePayment(context.Background(), &services.CreatePaymentRequest{DebtId: int32(debt.ID), PaidAmount: 19.993})
+	assert.NoError(t, err)
+	return db, group
+}
+
+// residualState returns a group's stored debts and debt-settled activity entries
+func residualState(db *gorm.DB, groupID uint) ([]database.Debt, []database.Activity) {
+	var debts []database.Debt
+	db.Where("group_id = ?", groupID).Find(&debts)
+	var activities []database.Activity
+	db.Where("group_id = ? AND action = ?", groupID, services.ActionDebtSettled).Find(&activities)
+	return debts, activities
+}
+
+func TestRecalculateDebts_DropsSubThresholdResidual(t *testing.T) {
+	// Arrange
+	original := services.SettledDebtThreshold()
+	defer services.SetSettledDebtThreshold(original)
+	services.SetSettledDebtThreshold(1)
+
+	// Act
+	debts, activities := seedResidualDebt(t)
+
+	// Assert
+	assert.Empty(t, debts)
+	assert.Len(t, activities, 1)
+	assert.Equal(t, "Settled residual debt of 0.0070 from Bob to Alice", activities[0].Description)
+}
+
+func TestRecalculateDebts_LogsResidualOnlyOnce(t *testing.T) {
+	// Arrange
+	original := services.SettledDebtThreshold()
+	defer services.SetSettledDebtThreshold(original)
+	services.SetSettledDebtThreshold(1)
+	db, group := seedResidualGroup(t)
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	dave := database.Participant{Name: "Dave", GroupID: group.ID}
+	db.Create(&carol)
+	db.Create(&dave)
+
+	// Act: an unrelated expense rebuilds Bob's residual again
+	seedEqualExpense(t, db, group.ID, carol.ID, 10, carol.ID, dave.ID)
+	debts, activities := residualState(db, group.ID)
+
+	// Assert
+	assert.Len(t, debts, 1)
+	assert.Equal(t, dave.ID, debts[0].DebtorID)
+	assert.Len(t, activities, 1)
+	assert.Equal(t, "Settled residual debt of 0.0070 from Bob to Alice", activities[0].Description)
+}
+
+func TestRecalculateDebts_ZeroThresholdKeepsResidual(t *testing.T) {
+	// Arrange
+	original := services.SettledDebtThreshold()
+	defer services.SetSettledDebtThreshold(original)
+	services.SetSettledDebtThreshold(0)
+
+	// Act
+	debts, activities := seedResidualDebt(t)
+
+	// Assert
+	assert.Len(t, debts, 1)
+	assert.InDelta(t, 0.007, debts[0].DebtAmount, 1e-9)
+	assert.Empty(t, activities)
+}
+
+func TestRecalculateDebts_SimplificationOffStoresPairwiseDebts(t *testing.T) {
+	// Arrange: Bob owes Alice 15 and Carol owes Bob 15, which simplifies to Carol owing Alice 15
+	db := setupTestDB()
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	db.Model(&group).Update("simplify_debts", false)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+
+	// Act
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 30, bob.ID, carol.ID)
+
+	// Assert
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Order("debtor_id").Find(&debts)
+	assert.Len(t, debts, 2)
+	assert.Equal(t, bob.ID, debts[0].DebtorID)
+	assert.Equal(t, alice.ID, debts[0].LenderID)
+	assert.Equal(t, 15.0, debts[0].DebtAmount)
+	assert.Equal(t, carol.ID, debts[1].DebtorID)
+	assert.Equal(t, bob.ID, debts[1].LenderID)
+	assert.Equal(t, 15.0, debts[1].DebtAmount)
+	assertMoneyConserved(t, db, group.ID)
+}
+
+func TestRecalculateDebts_SimplifiesByDefault(t *testing.T) {
+	// Arrange
+	db := setupTestDB()
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	carol := database.Participant{Name: "Carol", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	db.Create(&carol)
+
+	// Act
+	seedEqualExpense(t, db, group.ID, alice.ID, 30, alice.ID, bob.ID)
+	seedEqualExpense(t, db, group.ID, bob.ID, 30, bob.ID, carol.ID)
+
+	// Assert: Bob's debt and credit cancel out
+	var debts []database.Debt
+	db.Where("group_id = ?", group.ID).Find(&debts)
+	assert.Len(t, debts, 1)
+	assert.Equal(t, carol.ID, debts[0].DebtorID)
+	assert.Equal(t, alice.ID, debts[0].LenderID)
+	assert.Equal(t, 15.0, debts[0].DebtAmount)
+}
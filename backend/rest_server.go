@@ -2,15 +2,22 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"freesplit/internal/database"
+	"freesplit/internal/logger"
+	"freesplit/internal/openapi"
+	"freesplit/internal/ratelimit"
 	"freesplit/internal/services"
 
 	"gorm.io/driver/postgres"
@@ -18,46 +25,102 @@ import (
 )
 
 func main() {
+	// Configure log verbosity first so startup messages respect it
+	level, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		log.Fatalf("Invalid LOG_LEVEL: %v", err)
+	}
+	logger.SetLevel(level)
+
 	// Get database URL from environment variable
 	databaseURL := os.Getenv("DATABASE_URL")
 	if databaseURL == "" {
 		// Default to local PostgreSQL for development
 		databaseURL = "host=localhost user=postgres password=postgres dbname=freesplit port=5432 sslmode=disable"
-		log.Printf("🔧 Using local PostgreSQL for development")
+		logger.Infof("Using local PostgreSQL for development")
 	} else {
-		log.Printf("🔧 Using DATABASE_URL from environment")
+		logger.Infof("Using DATABASE_URL from environment")
 	}
 
 	// Initialize database
-	log.Printf("🔄 Connecting to database...")
+	logger.Infof("Connecting to database...")
 	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	log.Printf("✅ Successfully connected to database")
+	logger.Infof("Successfully connected to database")
 
 	// Run migrations
 	if err := database.Migrate(db); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	// Bound concurrent debt recalculations (each expense/payment write triggers one)
+	if limit := os.Getenv("MAX_CONCURRENT_RECALCULATIONS"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid MAX_CONCURRENT_RECALCULATIONS: %q", limit)
+		}
+		services.SetMaxConcurrentRecalculations(n)
+	}
+	logger.Infof("Allowing %d concurrent debt recalculations", services.MaxConcurrentRecalculations())
+
+	if threshold := os.Getenv("SETTLED_DEBT_THRESHOLD"); threshold != "" {
+		minorUnits, err := strconv.ParseFloat(threshold, 64)
+		if err != nil || minorUnits < 0 {
+			log.Fatalf("Invalid SETTLED_DEBT_THRESHOLD: %q", threshold)
+		}
+		services.SetSettledDebtThreshold(minorUnits)
+	}
+
+	if limit := os.Getenv("MAX_EXPENSE_NAME_LENGTH"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid MAX_EXPENSE_NAME_LENGTH: %q", limit)
+		}
+		services.SetMaxExpenseNameLength(n)
+	}
+
+	if style := os.Getenv("SLUG_STYLE"); style != "" {
+		if err := services.SetSlugStyle(style); err != nil {
+			log.Fatalf("Invalid SLUG_STYLE: %v", err)
+		}
+	}
+
+	// Cap group creation per client IP (and optionally overall) so the public endpoint can't be used to fill the database
+	perIPLimit := envInt("GROUP_CREATION_LIMIT_PER_IP", defaultGroupCreationLimitPerIP)
+	globalLimit := envInt("GROUP_CREATION_LIMIT_GLOBAL", 0)
+	trustProxyHeaders := os.Getenv("TRUST_PROXY_HEADERS") == "true"
+	groupCreationLimiter := ratelimit.New(perIPLimit, globalLimit, groupCreationWindow)
+	logger.Infof("Allowing %d group creations per IP and %d overall per %s (0 = unlimited)", perIPLimit, globalLimit, groupCreationWindow)
+
+	// Admin endpoints stay disabled unless a token is configured
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		logger.Infof("ADMIN_TOKEN not set, admin endpoints are disabled")
+	}
+
 	// Create service instances
 	groupService := services.NewGroupService(db)
 	participantService := services.NewParticipantService(db)
 	expenseService := services.NewExpenseService(db)
 	debtService := services.NewDebtService(db)
+	activityService := services.NewActivityService(db)
+	preferenceService := services.NewPreferenceService(db)
+	adminService := services.NewAdminService(db)
 
 	// CORS middleware
 	corsMiddleware := func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("🌐 [CORS] %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			logger.Debugf("[CORS] %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
 
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Cache-Control, Pragma, Expires")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Cache-Control, Pragma, Expires, If-None-Match, X-Edit-Token, X-Identity-Token, X-Admin-Token")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 			if r.Method == "OPTIONS" {
-				log.Printf("✅ [CORS] Handling preflight request for %s", r.URL.Path)
+				logger.Debugf("[CORS] Handling preflight request for %s", r.URL.Path)
 				w.WriteHeader(http.StatusOK)
 				return
 			}
@@ -70,7 +133,9 @@ func main() {
 	http.HandleFunc("/api/group", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "POST":
-			createGroup(w, r, groupService)
+			limitGroupCreation(groupCreationLimiter, trustProxyHeaders, func(w http.ResponseWriter, r *http.Request) {
+				createGroup(w, r, groupService)
+			})(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -79,13 +144,106 @@ func main() {
 	// Group operations (by URL slug)
 	http.HandleFunc("/api/group/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		// Check if this is a nested operation
-		if strings.Contains(r.URL.Path, "/participants") {
+		if strings.HasPrefix(r.URL.Path, "/api/group/by-id/") {
+			switch r.Method {
+			case "GET":
+				getGroupByID(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/balance") {
+			switch r.Method {
+			case "GET":
+				getParticipantBalance(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/fair-share") {
+			switch r.Method {
+			case "GET":
+				getParticipantFairShare(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/paid-expenses") {
+			switch r.Method {
+			case "GET":
+				getParticipantPaidExpenses(w, r, expenseService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/summary") {
+			switch r.Method {
+			case "GET":
+				getParticipantSpendingSummary(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/verify") {
+			switch r.Method {
+			case "GET":
+				verifyParticipant(w, r, participantService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/statement") {
+			switch r.Method {
+			case "GET":
+				getParticipantStatement(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/owes") {
+			switch r.Method {
+			case "GET":
+				getParticipantOwedDebts(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/settle-plan") {
+			switch r.Method {
+			case "GET":
+				getParticipantSettlePlan(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants/") && strings.HasSuffix(r.URL.Path, "/payments") {
+			switch r.Method {
+			case "GET":
+				getParticipantPayments(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/participants/bulk") {
+			switch r.Method {
+			case "POST":
+				addParticipants(w, r, participantService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants") {
 			switch r.Method {
+			case "GET":
+				listParticipants(w, r, participantService)
 			case "POST":
 				addParticipant(w, r, participantService)
 			default:
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
+		} else if strings.HasSuffix(r.URL.Path, "/validate-split") {
+			switch r.Method {
+			case "POST":
+				validateSplit(w, r, expenseService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/expenses/delete-multiple") {
+			switch r.Method {
+			case "POST":
+				deleteExpenses(w, r, expenseService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
 		} else if strings.Contains(r.URL.Path, "/expenses") {
 			switch r.Method {
 			case "GET":
@@ -102,10 +260,185 @@ func main() {
 			default:
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
+		} else if strings.HasSuffix(r.URL.Path, "/rotate-token") {
+			switch r.Method {
+			case "POST":
+				rotateEditToken(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/meta") {
+			switch r.Method {
+			case "GET":
+				getGroupMeta(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/diagnostics") {
+			switch r.Method {
+			case "GET":
+				getGroupDiagnostics(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/statistics") {
+			switch r.Method {
+			case "GET":
+				getGroupStatistics(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/by-payer") {
+			switch r.Method {
+			case "GET":
+				getExpensesByPayer(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/spending-timeseries") {
+			switch r.Method {
+			case "GET":
+				getSpendingTimeSeries(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/activity") {
+			switch r.Method {
+			case "GET":
+				getGroupActivity(w, r, activityService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/undo") {
+			switch r.Method {
+			case "POST":
+				undoLastAction(w, r, activityService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/reset") {
+			switch r.Method {
+			case "POST":
+				resetGroup(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/merge") {
+			switch r.Method {
+			case "POST":
+				mergeGroups(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/debt-graph") {
+			switch r.Method {
+			case "GET":
+				getDebtGraph(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/debt-cycles") {
+			switch r.Method {
+			case "GET":
+				getDebtCycles(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/settlement-comparison") {
+			switch r.Method {
+			case "GET":
+				getSettlementComparison(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/computed-balances") {
+			switch r.Method {
+			case "GET":
+				getComputedBalances(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/settlement-circles") {
+			switch r.Method {
+			case "GET":
+				getSettlementCircles(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/settlement-steps") {
+			switch r.Method {
+			case "GET":
+				getSettlementSteps(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/debts") {
+			switch r.Method {
+			case "GET":
+				getDebtsAsOf(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/debts/count") {
+			switch r.Method {
+			case "GET":
+				getDebtCount(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/debts/largest") {
+			switch r.Method {
+			case "GET":
+				getLargestDebt(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/debts/pay-multiple") {
+			switch r.Method {
+			case "POST":
+				payMultipleDebts(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/debts/project") {
+			switch r.Method {
+			case "POST":
+				projectDebts(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/settle-pair") {
+			switch r.Method {
+			case "POST":
+				settlePair(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
 		} else if strings.Contains(r.URL.Path, "/debts-page-data") {
 			switch r.Method {
 			case "GET":
-				getDebtsPageData(w, r, debtService)
+				getDebtsPageData(w, r, debtService, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/summary.md") {
+			switch r.Method {
+			case "GET":
+				getGroupSummaryMarkdown(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/payments.csv") {
+			switch r.Method {
+			case "GET":
+				exportPaymentsCSV(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/payments-page-data") {
+			switch r.Method {
+			case "GET":
+				getPaymentsPageData(w, r, debtService)
 			default:
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
@@ -117,10 +450,12 @@ func main() {
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
 		} else {
-			// Basic group operations (GET by URL slug, PUT for updates)
+			// Basic group operations (GET by URL slug, HEAD for existence, PUT for updates)
 			switch r.Method {
 			case "GET":
 				getGroup(w, r, groupService)
+			case "HEAD":
+				headGroup(w, r, groupService)
 			case "PUT":
 				updateGroup(w, r, groupService)
 			default:
@@ -141,15 +476,38 @@ func main() {
 	}))
 
 	http.HandleFunc("/api/expense/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			getExpenseWithSplits(w, r, expenseService)
-		case "PUT":
-			updateExpense(w, r, expenseService)
-		case "DELETE":
-			deleteExpense(w, r, expenseService)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		if strings.HasSuffix(r.URL.Path, "/detail") {
+			switch r.Method {
+			case "GET":
+				getExpenseDetail(w, r, expenseService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/lock") {
+			switch r.Method {
+			case "PUT":
+				setExpenseLocked(w, r, expenseService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/resplit") {
+			switch r.Method {
+			case "POST":
+				resplitExpense(w, r, expenseService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else {
+			switch r.Method {
+			case "GET":
+				getExpenseWithSplits(w, r, expenseService)
+			case "PUT":
+				updateExpense(w, r, expenseService)
+			case "DELETE":
+				deleteExpense(w, r, expenseService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
 		}
 	}))
 
@@ -174,34 +532,99 @@ func main() {
 	}))
 
 	// User Groups API
-	http.HandleFunc("/api/user-groups/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if strings.Contains(r.URL.Path, "/summary") {
-			switch r.Method {
-			case "POST":
-				getUserGroupsSummary(w, r, debtService)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		} else if strings.Contains(r.URL.Path, "/participants") {
-			switch r.Method {
-			case "POST":
-				getGroupParticipants(w, r, groupService)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		}
-	}))
-
-	log.Println("REST API server listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
-}
+	http.HandleFunc("/api/user-groups/", corsMiddleware(userGroupsHandler(groupService, debtService, activityService, preferenceService)))
+
+	// Admin API, for operators only
+	http.HandleFunc("/api/admin/currencies", corsMiddleware(requireAdminToken(adminToken, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			listCurrencies(w, r, adminService)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// OpenAPI document describing the routes above
+	http.HandleFunc("/openapi.json", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(openapi.Document())
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	logger.Infof("REST API server listening on :8080")
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
+
+// Admin handlers
+func listCurrencies(w http.ResponseWriter, r *http.Request, adminService services.AdminService) {
+	resp, err := adminService.ListCurrencies(r.Context(), &services.ListCurrenciesRequest{})
+	if err != nil {
+		logger.Errorf("Error listing currencies: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
 
 // User Groups handlers
+// getUserGroupPreferences returns the pinned flags and sort order stored for the identity in X-Identity-Token
+func getUserGroupPreferences(w http.ResponseWriter, r *http.Request, preferenceService services.PreferenceService) {
+	resp, err := preferenceService.GetUserGroupPreferences(r.Context(), &services.GetUserGroupPreferencesRequest{
+		IdentityToken: r.Header.Get("X-Identity-Token"),
+	})
+	if err != nil {
+		logger.Warnf("Error getting user group preferences: %v", err)
+		if strings.Contains(err.Error(), "invalid identity token") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// setUserGroupPreferences stores pinned flags and sort order for the identity in X-Identity-Token
+func setUserGroupPreferences(w http.ResponseWriter, r *http.Request, preferenceService services.PreferenceService) {
+	var req services.SetUserGroupPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.IdentityToken = r.Header.Get("X-Identity-Token")
+
+	resp, err := preferenceService.SetUserGroupPreferences(r.Context(), &req)
+	if err != nil {
+		logger.Warnf("Error setting user group preferences: %v", err)
+		if strings.Contains(err.Error(), "invalid identity token") || strings.Contains(err.Error(), "cannot be empty") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 func getUserGroupsSummary(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
 	var req services.UserGroupsSummaryRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Invalid JSON in user groups summary request: %v", err)
+		logger.Warnf("Invalid JSON in user groups summary request: %v", err)
 		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
 		return
 	}
@@ -223,9 +646,77 @@ func getUserGroupsSummary(w http.ResponseWriter, r *http.Request, debtService se
 		}
 	}
 
+	req.IdentityToken = r.Header.Get("X-Identity-Token")
+
 	resp, err := debtService.GetUserGroupsSummary(context.TODO(), &req)
 	if err != nil {
-		log.Printf("Error getting user groups summary: %v", err)
+		logger.Errorf("Error getting user groups summary: %v", err)
+		if strings.Contains(err.Error(), "invalid identity token") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getCombinedSettlement(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	var req services.CombinedSettlementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in combined settlement request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.GroupSlugs) == 0 {
+		http.Error(w, "Group slugs list cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := debtService.GetCombinedSettlement(r.Context(), &req)
+	if err != nil {
+		logger.Errorf("Error getting combined settlement: %v", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid combined settlement") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getUserGroupsActivity(w http.ResponseWriter, r *http.Request, activityService services.ActivityService) {
+	var req services.UserGroupsActivityRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in user groups activity request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// Validate input
+	if len(req.GroupSlugs) == 0 {
+		http.Error(w, "Group slugs list cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if req.Limit < 0 {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := activityService.GetUserGroupsActivity(r.Context(), &req)
+	if err != nil {
+		logger.Errorf("Error getting user groups activity: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -235,134 +726,322 @@ func getUserGroupsSummary(w http.ResponseWriter, r *http.Request, debtService se
 }
 
 func getGroupParticipants(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
-	log.Printf("🔍 [GET_GROUP_PARTICIPANTS] Starting request from %s", r.RemoteAddr)
+	logger.Debugf("[GET_GROUP_PARTICIPANTS] Starting request from %s", r.RemoteAddr)
 
 	var req services.GroupParticipantsRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ [GET_GROUP_PARTICIPANTS] Invalid JSON in group participants request: %v", err)
+		logger.Warnf("[GET_GROUP_PARTICIPANTS] Invalid JSON in group participants request: %v", err)
 		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("📝 [GET_GROUP_PARTICIPANTS] Request data: %+v", req)
+	logger.Debugf("[GET_GROUP_PARTICIPANTS] Request data: %+v", req)
 
 	// Validate input
 	if len(req.GroupSlugs) == 0 {
-		log.Printf("❌ [GET_GROUP_PARTICIPANTS] Group slugs list is empty")
+		logger.Warnf("[GET_GROUP_PARTICIPANTS] Group slugs list is empty")
 		http.Error(w, "Group slugs list cannot be empty", http.StatusBadRequest)
 		return
 	}
 
 	for _, slug := range req.GroupSlugs {
 		if slug == "" {
-			log.Printf("❌ [GET_GROUP_PARTICIPANTS] Empty group slug found")
+			logger.Warnf("[GET_GROUP_PARTICIPANTS] Empty group slug found")
 			http.Error(w, "Group slug cannot be empty", http.StatusBadRequest)
 			return
 		}
 	}
 
-	log.Printf("🔄 [GET_GROUP_PARTICIPANTS] Calling service with %d group slugs", len(req.GroupSlugs))
+	logger.Debugf("[GET_GROUP_PARTICIPANTS] Calling service with %d group slugs", len(req.GroupSlugs))
 	resp, err := groupService.GetGroupParticipants(context.TODO(), &req)
 	if err != nil {
-		log.Printf("❌ [GET_GROUP_PARTICIPANTS] Error getting group participants: %v", err)
+		logger.Errorf("[GET_GROUP_PARTICIPANTS] Error getting group participants: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Debugf("[GET_GROUP_PARTICIPANTS] Success! Returning %d groups", len(resp.Groups))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// userGroupsHandler dispatches the /api/user-groups/ endpoints, which work across the groups a user has saved
+func userGroupsHandler(groupService services.GroupService, debtService services.DebtService, activityService services.ActivityService, preferenceService services.PreferenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/preferences") {
+			switch r.Method {
+			case "GET":
+				getUserGroupPreferences(w, r, preferenceService)
+			case "PUT":
+				setUserGroupPreferences(w, r, preferenceService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/summary") {
+			switch r.Method {
+			case "POST":
+				getUserGroupsSummary(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/combined-settlement") {
+			switch r.Method {
+			case "POST":
+				getCombinedSettlement(w, r, debtService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/activity") {
+			switch r.Method {
+			case "POST":
+				getUserGroupsActivity(w, r, activityService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/find-participant") {
+			switch r.Method {
+			case "POST":
+				findParticipant(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.Contains(r.URL.Path, "/participants") {
+			switch r.Method {
+			case "POST":
+				getGroupParticipants(w, r, groupService)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		}
+	}
+}
+
+func findParticipant(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	logger.Debugf("[FIND_PARTICIPANT] Starting request from %s", r.RemoteAddr)
+
+	var req services.FindParticipantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("[FIND_PARTICIPANT] Invalid JSON in find participant request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		logger.Warnf("[FIND_PARTICIPANT] Name is empty")
+		http.Error(w, "Name cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if len(req.GroupSlugs) == 0 {
+		logger.Warnf("[FIND_PARTICIPANT] Group slugs list is empty")
+		http.Error(w, "Group slugs list cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := groupService.FindParticipant(context.TODO(), &req)
+	if err != nil {
+		logger.Errorf("[FIND_PARTICIPANT] Error finding participant: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("✅ [GET_GROUP_PARTICIPANTS] Success! Returning %d groups", len(resp.Groups))
+	logger.Debugf("[FIND_PARTICIPANT] Success! Returning %d matches", len(resp.Matches))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// Group creation quota: how long the window is and how many groups one IP may create in it by default
+const (
+	groupCreationWindow            = time.Hour
+	defaultGroupCreationLimitPerIP = 20
+)
+
+// envInt reads a non-negative integer setting, exiting on malformed values
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return n
+}
+
+// limitGroupCreation rejects requests with 429 once the client (or everyone together) has created too many groups
+func limitGroupCreation(limiter *ratelimit.Limiter, trustProxy bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := ratelimit.ClientIP(r, trustProxy)
+		if !limiter.Allow(ip) {
+			logger.Warnf("[CREATE_GROUP] Group creation quota exceeded for %s", ip)
+			w.Header().Set("Retry-After", strconv.Itoa(int(groupCreationWindow.Seconds())))
+			http.Error(w, "Too many groups created, please try again later", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireAdminToken only lets requests through whose X-Admin-Token header matches the configured token.
+// With no token configured every request is rejected, so admin endpoints are off by default.
+func requireAdminToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.Error(w, "Admin API is disabled", http.StatusForbidden)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Token")), []byte(token)) != 1 {
+			logger.Warnf("[ADMIN] Rejected request to %s from %s: invalid admin token", r.URL.Path, r.RemoteAddr)
+			http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
 // Group handlers
 func createGroup(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
-	log.Printf("🚀 [CREATE_GROUP] Starting group creation request from %s", r.RemoteAddr)
+	logger.Debugf("[CREATE_GROUP] Starting group creation request from %s", r.RemoteAddr)
 
 	var req struct {
 		Name             string   `json:"name"`
 		Currency         string   `json:"currency"`
+		Description      string   `json:"description"`
+		AmountDisplay    string   `json:"amount_display"`
 		ParticipantNames []string `json:"participant_names"`
+		SlugStyle        string   `json:"slug_style"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ [CREATE_GROUP] Failed to decode JSON: %v", err)
+		logger.Warnf("[CREATE_GROUP] Failed to decode JSON: %v", err)
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("📝 [CREATE_GROUP] Request data - Name: %s, Currency: %s, Participants: %v", req.Name, req.Currency, req.ParticipantNames)
+	logger.Debugf("[CREATE_GROUP] Request data - Name: %s, Currency: %s, Participants: %v", req.Name, req.Currency, req.ParticipantNames)
 
 	serviceReq := &services.CreateGroupRequest{
 		Name:             req.Name,
 		Currency:         req.Currency,
+		Description:      req.Description,
+		AmountDisplay:    req.AmountDisplay,
 		ParticipantNames: req.ParticipantNames,
+		SlugStyle:        req.SlugStyle,
 	}
 
 	resp, err := groupService.CreateGroup(context.TODO(), serviceReq)
 	if err != nil {
-		log.Printf("❌ [CREATE_GROUP] Error creating group: %v", err)
+		logger.Errorf("[CREATE_GROUP] Error creating group: %v", err)
+		if strings.Contains(err.Error(), "cannot be empty") || strings.Contains(err.Error(), "invalid slug style") ||
+			strings.Contains(err.Error(), "invalid group") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("❌ [CREATE_GROUP] Error encoding response: %v", err)
+		logger.Errorf("[CREATE_GROUP] Error encoding response: %v", err)
 		return
 	}
 
-	log.Printf("✅ [CREATE_GROUP] Successfully created and returned group with ID: %d, URL: %s", resp.Group.Id, resp.Group.UrlSlug)
+	logger.Debugf("[CREATE_GROUP] Successfully created and returned group with ID: %d, URL: %s", resp.Group.Id, resp.Group.UrlSlug)
 }
 
 func getGroup(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
 	urlSlug := strings.TrimPrefix(r.URL.Path, "/api/group/")
-	log.Printf("🚀 [GET_GROUP] Starting group retrieval request for URL slug: %s from %s", urlSlug, r.RemoteAddr)
+	logger.Debugf("[GET_GROUP] Starting group retrieval request for URL slug: %s from %s", urlSlug, r.RemoteAddr)
 
 	if urlSlug == "" {
-		log.Printf("❌ [GET_GROUP] Missing url_slug parameter")
+		logger.Warnf("[GET_GROUP] Missing url_slug parameter")
 		http.Error(w, "url_slug parameter required", http.StatusBadRequest)
 		return
 	}
 
+	if notModified(w, r, groupService, urlSlug) {
+		return
+	}
+
 	serviceReq := &services.GetGroupRequest{UrlSlug: urlSlug}
 	resp, err := groupService.GetGroup(context.TODO(), serviceReq)
 	if err != nil {
-		log.Printf("❌ [GET_GROUP] Error getting group %s: %v", urlSlug, err)
+		logger.Errorf("[GET_GROUP] Error getting group %s: %v", urlSlug, err)
 		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("❌ [GET_GROUP] Error encoding response for group %s: %v", urlSlug, err)
+		logger.Errorf("[GET_GROUP] Error encoding response for group %s: %v", urlSlug, err)
 		return
 	}
 
-	log.Printf("✅ [GET_GROUP] Successfully retrieved and returned group %s with %d participants", urlSlug, len(resp.Participants))
+	logger.Debugf("[GET_GROUP] Successfully retrieved and returned group %s with %d participants", urlSlug, len(resp.Participants))
 }
 
-func updateGroup(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
-	var req struct {
-		Name          string `json:"name"`
-		Currency      string `json:"currency"`
-		ParticipantID int32  `json:"participant_id"`
+// headGroup answers 200 when the URL slug resolves to a group and 404 otherwise, without a body
+func headGroup(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	urlSlug := strings.TrimPrefix(r.URL.Path, "/api/group/")
+	if urlSlug == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	resp, err := groupService.GroupExists(r.Context(), &services.GroupExistsRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error checking group %s: %v", urlSlug, err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	serviceReq := &services.UpdateGroupRequest{
-		Name:          req.Name,
-		Currency:      req.Currency,
-		ParticipantId: req.ParticipantID,
+	if !resp.Exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+}
 
-	resp, err := groupService.UpdateGroup(context.TODO(), serviceReq)
+// notModified sets a weak ETag for the group's current version and answers 304 Not Modified
+// when it matches the request's If-None-Match header. Returns true when the response is done.
+// If the version can't be computed the request is served normally without an ETag.
+func notModified(w http.ResponseWriter, r *http.Request, groupService services.GroupService, urlSlug string) bool {
+	version, err := groupService.GetGroupVersion(r.Context(), &services.GetGroupVersionRequest{UrlSlug: urlSlug})
+	if err != nil {
+		return false
+	}
+
+	etag := `W/"` + version.Version + `"`
+	w.Header().Set("ETag", etag)
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+func getGroupByID(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	groupIDStr := strings.TrimPrefix(r.URL.Path, "/api/group/by-id/")
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil || groupID <= 0 {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.GetGroupRequest{GroupId: int32(groupID)}
+	resp, err := groupService.GetGroup(r.Context(), serviceReq)
 	if err != nil {
-		log.Printf("Error updating group: %v", err)
+		logger.Errorf("Error getting group %d: %v", groupID, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Group not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -371,11 +1050,16 @@ func updateGroup(w http.ResponseWriter, r *http.Request, groupService services.G
 	json.NewEncoder(w).Encode(resp)
 }
 
-// Participant handlers
-func addParticipant(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
+func updateGroup(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
 	var req struct {
-		Name    string `json:"name"`
-		GroupID int32  `json:"group_id"`
+		Name            string  `json:"name"`
+		Currency        string  `json:"currency"`
+		Description     *string `json:"description"`
+		AmountDisplay   *string `json:"amount_display"`
+		BankerID        *int32  `json:"banker_id"`
+		SimplifyDebts   *bool   `json:"simplify_debts"`
+		RotateRemainder *bool   `json:"rotate_remainder"`
+		ParticipantID   int32   `json:"participant_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -383,14 +1067,28 @@ func addParticipant(w http.ResponseWriter, r *http.Request, participantService s
 		return
 	}
 
-	serviceReq := &services.AddParticipantRequest{
-		Name:    req.Name,
-		GroupId: req.GroupID,
+	serviceReq := &services.UpdateGroupRequest{
+		Name:            req.Name,
+		Currency:        req.Currency,
+		Description:     req.Description,
+		AmountDisplay:   req.AmountDisplay,
+		BankerId:        req.BankerID,
+		SimplifyDebts:   req.SimplifyDebts,
+		RotateRemainder: req.RotateRemainder,
+		ParticipantId:   req.ParticipantID,
 	}
 
-	resp, err := participantService.AddParticipant(context.TODO(), serviceReq)
+	resp, err := groupService.UpdateGroup(context.TODO(), serviceReq)
 	if err != nil {
-		log.Printf("Error adding participant: %v", err)
+		logger.Errorf("Error updating group: %v", err)
+		if strings.Contains(err.Error(), "invalid group") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "cannot change currency") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -399,19 +1097,149 @@ func addParticipant(w http.ResponseWriter, r *http.Request, participantService s
 	json.NewEncoder(w).Encode(resp)
 }
 
-func updateParticipant(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
-	// Extract participant ID from URL path
-	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(pathParts) < 3 || pathParts[0] != "api" || pathParts[1] != "participants" {
-		http.Error(w, "Invalid URL format", http.StatusBadRequest)
+// Participant handlers
+func listParticipants(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	participantIDStr := pathParts[2]
-	participantID, err := strconv.Atoi(participantIDStr)
+	resp, err := participantService.ListParticipants(r.Context(), &services.ListParticipantsRequest{
+		UrlSlug: urlSlug,
+		Sort:    r.URL.Query().Get("sort"),
+	})
 	if err != nil {
-		log.Printf("Invalid participant ID '%s': %v", participantIDStr, err)
-		http.Error(w, fmt.Sprintf("Invalid participant ID: %s", participantIDStr), http.StatusBadRequest)
+		logger.Errorf("Error listing participants for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "invalid sort") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// verifyParticipant lets a client check that the participant ID it stored locally is still valid
+func verifyParticipant(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := participantService.VerifyParticipant(r.Context(), &services.VerifyParticipantRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	})
+	if err != nil {
+		logger.Warnf("Could not verify participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func addParticipant(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
+	var req struct {
+		Name    string `json:"name"`
+		GroupID int32  `json:"group_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.AddParticipantRequest{
+		Name:    req.Name,
+		GroupId: req.GroupID,
+	}
+
+	resp, err := participantService.AddParticipant(context.TODO(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error adding participant: %v", err)
+		if strings.Contains(err.Error(), "cannot be empty") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func addParticipants(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	var req struct {
+		Names []string `json:"names"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in bulk add participants request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.AddParticipantsRequest{
+		UrlSlug: urlSlug,
+		Names:   req.Names,
+	}
+
+	resp, err := participantService.AddParticipants(r.Context(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error adding participants to group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "cannot be empty") || strings.Contains(err.Error(), "duplicate participant name") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func updateParticipant(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
+	// Extract participant ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 3 || pathParts[0] != "api" || pathParts[1] != "participants" {
+		http.Error(w, "Invalid URL format", http.StatusBadRequest)
+		return
+	}
+
+	participantIDStr := pathParts[2]
+	participantID, err := strconv.Atoi(participantIDStr)
+	if err != nil {
+		logger.Warnf("Invalid participant ID '%s': %v", participantIDStr, err)
+		http.Error(w, fmt.Sprintf("Invalid participant ID: %s", participantIDStr), http.StatusBadRequest)
 		return
 	}
 
@@ -421,7 +1249,7 @@ func updateParticipant(w http.ResponseWriter, r *http.Request, participantServic
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Invalid JSON in update participant request: %v", err)
+		logger.Warnf("Invalid JSON in update participant request: %v", err)
 		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
 		return
 	}
@@ -437,17 +1265,1366 @@ func updateParticipant(w http.ResponseWriter, r *http.Request, participantServic
 		ParticipantId: int32(participantID),
 	}
 
-	resp, err := participantService.UpdateParticipant(context.TODO(), serviceReq)
+	resp, err := participantService.UpdateParticipant(context.TODO(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error updating participant %d: %v", participantID, err)
+
+		// Check if it's a business logic error (participant not found, etc.)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		// For other errors, return internal server error
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func deleteParticipant(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
+	participantIDStr := strings.TrimPrefix(r.URL.Path, "/api/participants/")
+	participantID, err := strconv.Atoi(participantIDStr)
+	if err != nil {
+		http.Error(w, "Invalid participant ID", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.DeleteParticipantRequest{
+		ParticipantId: int32(participantID),
+	}
+
+	err = participantService.DeleteParticipant(context.TODO(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error deleting participant: %v", err)
+
+		// Check if it's a business logic error (participant has active expenses/splits/debts)
+		if strings.Contains(err.Error(), "cannot delete participant") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		// For other errors, return internal server error
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Participant deleted successfully"}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Expense handlers
+func getExpensesByGroup(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	// Extract group ID from URL path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	groupID, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.GetExpensesByGroupRequest{
+		GroupId:       int32(groupID),
+		IncludeSplits: r.URL.Query().Get("include") == "splits",
+	}
+
+	resp, err := expenseService.GetExpensesByGroup(context.TODO(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error getting expenses: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp.Expenses)
+}
+
+func getSplitsByGroup(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	// Extract urlSlug from URL path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	urlSlug := pathParts[3]
+	if urlSlug == "" {
+		http.Error(w, "Invalid URL slug", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.GetSplitsByGroupRequest{
+		UrlSlug: urlSlug,
+	}
+
+	resp, err := expenseService.GetSplitsByGroup(context.TODO(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error getting splits: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp.Splits)
+}
+
+// expensePayload is the JSON body accepted by the create and update expense endpoints
+type expensePayload struct {
+	Expense struct {
+		ID        int32   `json:"id"`
+		Name      string  `json:"name"`
+		Cost      float64 `json:"cost"`
+		Subtotal  float64 `json:"subtotal"`
+		Tax       float64 `json:"tax"`
+		Tip       float64 `json:"tip"`
+		Emoji     string  `json:"emoji"`
+		PayerID   int32   `json:"payer_id"`
+		SplitType string  `json:"split_type"`
+		IsShared  *bool   `json:"is_shared"`
+		GroupID   int32   `json:"group_id"`
+	} `json:"expense"`
+	Splits []struct {
+		ParticipantID   int32   `json:"participant_id"`
+		SplitAmount     float64 `json:"split_amount"`
+		Adjustment      float64 `json:"adjustment"`
+		Weight          float64 `json:"weight"`
+		SharesRemainder bool    `json:"shares_remainder"`
+	} `json:"splits"`
+	RemainderParticipantID int32    `json:"remainder_participant_id"`
+	SplitTolerance         *float64 `json:"split_tolerance"`
+	AllParticipants        bool     `json:"all_participants"`
+}
+
+// validate returns one message per missing or malformed field, so callers see every problem at once
+// instead of the service acting on zero values
+func (p *expensePayload) validate(requireID bool) []string {
+	var problems []string
+	if requireID && p.Expense.ID <= 0 {
+		problems = append(problems, "expense.id is required")
+	}
+	if strings.TrimSpace(p.Expense.Name) == "" {
+		problems = append(problems, "expense.name is required")
+	}
+	if p.Expense.Cost <= 0 {
+		problems = append(problems, "expense.cost must be greater than 0")
+	}
+	if p.Expense.PayerID <= 0 {
+		problems = append(problems, "expense.payer_id is required")
+	}
+	if p.Expense.GroupID <= 0 {
+		problems = append(problems, "expense.group_id is required")
+	}
+	// Personal expenses are carried by the payer alone, so they need no splits
+	isShared := p.Expense.IsShared == nil || *p.Expense.IsShared
+	// With all_participants the server fills in the splits
+	if isShared && len(p.Splits) == 0 && !p.AllParticipants {
+		problems = append(problems, "splits must contain at least one entry")
+	}
+	for i, split := range p.Splits {
+		if split.ParticipantID <= 0 {
+			problems = append(problems, fmt.Sprintf("splits[%d].participant_id is required", i))
+		}
+	}
+	return problems
+}
+
+// decodeExpensePayload decodes and validates an expense body, writing a 400 and returning false if it is unusable.
+// Updates also need the expense ID, which is sent in the body
+func decodeExpensePayload(w http.ResponseWriter, r *http.Request, payload *expensePayload, requireID bool) bool {
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	if problems := payload.validate(requireID); len(problems) > 0 {
+		logger.Warnf("Rejected expense payload: %s", strings.Join(problems, "; "))
+		http.Error(w, "Invalid expense payload: "+strings.Join(problems, "; "), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// serviceExpense converts the payload into the service's expense and splits
+func (p *expensePayload) serviceExpense() (*services.Expense, []*services.Split) {
+	splits := make([]*services.Split, len(p.Splits))
+	for i, split := range p.Splits {
+		splits[i] = &services.Split{
+			GroupId:         p.Expense.GroupID,
+			ParticipantId:   split.ParticipantID,
+			SplitAmount:     split.SplitAmount,
+			Adjustment:      split.Adjustment,
+			Weight:          split.Weight,
+			SharesRemainder: split.SharesRemainder,
+		}
+	}
+
+	expense := &services.Expense{
+		Id:        p.Expense.ID,
+		Name:      p.Expense.Name,
+		Cost:      p.Expense.Cost,
+		Subtotal:  p.Expense.Subtotal,
+		Tax:       p.Expense.Tax,
+		Tip:       p.Expense.Tip,
+		Emoji:     p.Expense.Emoji,
+		PayerId:   p.Expense.PayerID,
+		SplitType: p.Expense.SplitType,
+		IsShared:  p.Expense.IsShared,
+		GroupId:   p.Expense.GroupID,
+	}
+	return expense, splits
+}
+
+func createExpense(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	var requestData expensePayload
+	if !decodeExpensePayload(w, r, &requestData, false) {
+		return
+	}
+
+	expense, splits := requestData.serviceExpense()
+	expense.Id = 0
+	serviceReq := &services.CreateExpenseRequest{
+		Expense:                expense,
+		Splits:                 splits,
+		RemainderParticipantId: requestData.RemainderParticipantID,
+		SplitTolerance:         requestData.SplitTolerance,
+		AllParticipants:        requestData.AllParticipants,
+	}
+
+	resp, err := expenseService.CreateExpense(context.Background(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error creating expense: %v", err)
+		if strings.Contains(err.Error(), "invalid expense") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "group not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to create expense", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getExpenseWithSplits(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	expenseIDStr := strings.TrimPrefix(r.URL.Path, "/api/expense/")
+	expenseID, err := strconv.Atoi(expenseIDStr)
+	if err != nil {
+		http.Error(w, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.GetExpenseWithSplitsRequest{ExpenseId: int32(expenseID)}
+	resp, err := expenseService.GetExpenseWithSplits(context.Background(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error getting expense with splits: %v", err)
+		http.Error(w, "Expense not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func updateExpense(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	var requestData expensePayload
+	if !decodeExpensePayload(w, r, &requestData, true) {
+		return
+	}
+
+	expense, splits := requestData.serviceExpense()
+	serviceReq := &services.UpdateExpenseRequest{
+		Expense:                expense,
+		Splits:                 splits,
+		RemainderParticipantId: requestData.RemainderParticipantID,
+		SplitTolerance:         requestData.SplitTolerance,
+	}
+
+	resp, err := expenseService.UpdateExpense(r.Context(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error updating expense: %v", err)
+		if strings.Contains(err.Error(), "is locked") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid expense") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update expense", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getExpenseDetail(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	expenseIDStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/expense/"), "/detail")
+	expenseID, err := strconv.Atoi(expenseIDStr)
+	if err != nil || expenseID <= 0 {
+		http.Error(w, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := expenseService.GetExpenseDetail(r.Context(), &services.GetExpenseDetailRequest{ExpenseId: int32(expenseID)})
+	if err != nil {
+		logger.Errorf("Error getting detail for expense %d: %v", expenseID, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func resplitExpense(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	expenseIDStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/expense/"), "/resplit")
+	expenseID, err := strconv.Atoi(expenseIDStr)
+	if err != nil || expenseID <= 0 {
+		http.Error(w, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ParticipantIDs []int32 `json:"participant_ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in resplit expense request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.ResplitExpenseRequest{
+		ExpenseId:      int32(expenseID),
+		ParticipantIds: req.ParticipantIDs,
+	}
+
+	resp, err := expenseService.ResplitExpense(r.Context(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error re-splitting expense %d: %v", expenseID, err)
+		if strings.Contains(err.Error(), "is locked") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid expense") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func setExpenseLocked(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	expenseIDStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/expense/"), "/lock")
+	expenseID, err := strconv.Atoi(expenseIDStr)
+	if err != nil || expenseID <= 0 {
+		http.Error(w, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Locked *bool `json:"locked"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in lock expense request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.Locked == nil {
+		http.Error(w, "locked is required", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := expenseService.SetExpenseLocked(r.Context(), &services.SetExpenseLockedRequest{
+		ExpenseId: int32(expenseID),
+		Locked:    *req.Locked,
+	})
+	if err != nil {
+		logger.Errorf("Error setting lock on expense %d: %v", expenseID, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func deleteExpense(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	expenseIDStr := strings.TrimPrefix(r.URL.Path, "/api/expense/")
+	expenseID, err := strconv.Atoi(expenseIDStr)
+	if err != nil {
+		http.Error(w, "Invalid expense ID", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.DeleteExpenseRequest{
+		ExpenseId: int32(expenseID),
+	}
+
+	err = expenseService.DeleteExpense(r.Context(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error deleting expense: %v", err)
+		if strings.Contains(err.Error(), "is locked") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete expense", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "Expense deleted successfully"}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func deleteExpenses(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	var req struct {
+		ExpenseIds []int32 `json:"expense_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := expenseService.DeleteExpenses(r.Context(), &services.DeleteExpensesRequest{
+		UrlSlug:    urlSlug,
+		ExpenseIds: req.ExpenseIds,
+	})
+	if err != nil {
+		logger.Errorf("Error deleting expenses in group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "invalid expense IDs") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "is locked") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete expenses", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func validateSplit(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	var req services.ValidateSplitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.UrlSlug = urlSlug
+
+	resp, err := expenseService.ValidateSplit(r.Context(), &req)
+	if err != nil {
+		logger.Errorf("Error validating split in group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to validate split", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Debt handlers
+func getPayments(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	// Extract group ID from URL path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	groupID, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get payments using service
+	req := &services.GetPaymentsRequest{GroupId: int32(groupID)}
+	response, err := debtService.GetPayments(r.Context(), req)
+	if err != nil {
+		http.Error(w, "Failed to get payments", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Payments)
+}
+
+func getPaymentsPageData(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetPaymentsPageData(r.Context(), &services.GetPaymentsPageDataRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error getting payments page data for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// exportPaymentsCSV writes a group's payments as a CSV download, newest first, for reconciliation
+func getGroupSummaryMarkdown(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := groupService.GetGroupSummaryMarkdown(r.Context(), &services.GetGroupSummaryMarkdownRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error rendering summary for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	if _, err := io.WriteString(w, resp.Markdown); err != nil {
+		logger.Errorf("Error writing summary for group %s: %v", urlSlug, err)
+	}
+}
+
+func exportPaymentsCSV(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetPaymentsPageData(r.Context(), &services.GetPaymentsPageDataRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error exporting payments for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", urlSlug+"-payments.csv"))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "payer", "payee", "amount", "currency", "note"})
+	for _, p := range resp.Payments {
+		writer.Write([]string{
+			p.CreatedAt.UTC().Format(time.RFC3339),
+			csvText(p.PayerName),
+			csvText(p.PayeeName),
+			strconv.FormatFloat(services.DisplayAmount(p.Amount, resp.Currency, resp.AmountDisplay), 'f', services.DisplayDecimals(resp.Currency, resp.AmountDisplay), 64),
+			resp.Currency,
+			csvText(p.Note),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.Errorf("Error writing payments CSV for group %s: %v", urlSlug, err)
+	}
+}
+
+// csvText neutralizes user-entered text for spreadsheets: a cell starting with =, +, -, @, tab or carriage return
+// would be evaluated as a formula, so it gets a leading apostrophe and is shown as plain text
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func getParticipantPayments(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantPaymentsRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	}
+
+	resp, err := debtService.GetParticipantPayments(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting payments for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getParticipantOwedDebts(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantOwedDebtsRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	}
+
+	resp, err := debtService.GetParticipantOwedDebts(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting owed debts for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getParticipantSettlePlan(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantSettlePlanRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	}
+
+	resp, err := debtService.GetParticipantSettlePlan(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting settle plan for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getParticipantStatement(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantStatementRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	}
+
+	resp, err := groupService.GetParticipantStatement(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting statement for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getParticipantFairShare(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantFairShareRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	}
+
+	resp, err := groupService.GetParticipantFairShare(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting fair share for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// getParticipantSpendingSummary totals a participant's spending between the optional from and to query parameters
+func getParticipantSpendingSummary(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := parseRangeBound(r.URL.Query().Get("from"), false)
+	if err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseRangeBound(r.URL.Query().Get("to"), true)
+	if err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantSpendingSummaryRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+		From:          from,
+		To:            to,
+	}
+
+	resp, err := groupService.GetParticipantSpendingSummary(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting spending summary for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "invalid range") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parseRangeBound parses a date range query parameter, either an RFC 3339 timestamp or a YYYY-MM-DD date (UTC).
+// An empty value means no bound. A date used as the end of a range covers that whole day
+func parseRangeBound(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("expected a YYYY-MM-DD date or an RFC 3339 timestamp")
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return &day, nil
+}
+
+func getParticipantPaidExpenses(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantPaidExpensesRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	}
+
+	resp, err := expenseService.GetParticipantPaidExpenses(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting paid expenses for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getParticipantBalance(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	urlSlug, participantID, err := parseGroupParticipantPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &services.GetParticipantBalanceRequest{
+		UrlSlug:       urlSlug,
+		ParticipantId: participantID,
+	}
+
+	resp, err := debtService.GetParticipantBalance(r.Context(), req)
+	if err != nil {
+		logger.Errorf("Error getting balance for participant %d in group %s: %v", participantID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getDebtsPageData(w http.ResponseWriter, r *http.Request, debtService services.DebtService, groupService services.GroupService) {
+	// Extract group URL slug from URL path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	urlSlug := pathParts[3]
+	if urlSlug == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+
+	if notModified(w, r, groupService, urlSlug) {
+		return
+	}
+
+	serviceReq := &services.GetDebtsRequest{
+		UrlSlug: urlSlug,
+	}
+
+	resp, err := debtService.GetDebtsPageData(context.TODO(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error getting debts page data: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func createPayment(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	var req struct {
+		DebtID     int32   `json:"debt_id"`
+		PaidAmount float64 `json:"paid_amount"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in debt update request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// Validate input
+	if req.DebtID <= 0 {
+		http.Error(w, "Invalid debt ID", http.StatusBadRequest)
+		return
+	}
+
+	if req.PaidAmount < 0 {
+		http.Error(w, "Paid amount cannot be negative", http.StatusBadRequest)
+		return
+	}
+	if req.PaidAmount == 0 {
+		http.Error(w, "Paid amount must be positive", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.CreatePaymentRequest{
+		DebtId:     req.DebtID,
+		PaidAmount: req.PaidAmount,
+	}
+
+	resp, err := debtService.CreatePayment(context.TODO(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error creating payment for debt %d: %v", req.DebtID, err)
+
+		// Check if it's a business logic error
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		// Check if the group no longer accepts payments
+		if strings.Contains(err.Error(), "reopen it") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		// Check if it's a validation error (overpayment, etc.)
+		if strings.Contains(err.Error(), "cannot exceed") || strings.Contains(err.Error(), "cannot be negative") ||
+			strings.Contains(err.Error(), "must be positive") || strings.Contains(err.Error(), "invalid debt ID") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// For other errors, return internal server error
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func settlePair(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	var req struct {
+		PayerID int32   `json:"payer_id"`
+		PayeeID int32   `json:"payee_id"`
+		Amount  float64 `json:"amount"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in settle pair request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if req.PayerID <= 0 || req.PayeeID <= 0 {
+		http.Error(w, "Payer and payee IDs must be positive", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.SettlePairRequest{
+		UrlSlug: urlSlug,
+		PayerId: req.PayerID,
+		PayeeId: req.PayeeID,
+		Amount:  req.Amount,
+	}
+
+	resp, err := debtService.SettlePair(r.Context(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error settling pair %d -> %d in group %s: %v", req.PayerID, req.PayeeID, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "reopen it") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "no debt from") || strings.Contains(err.Error(), "cannot exceed") ||
+			strings.Contains(err.Error(), "must be positive") || strings.Contains(err.Error(), "must be different") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getDebtGraph(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetDebtGraph(r.Context(), &services.GetDebtGraphRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error getting debt graph for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getSettlementComparison(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetSettlementComparison(r.Context(), &services.GetSettlementComparisonRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error getting settlement comparison for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getSettlementCircles(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetSettlementCircles(r.Context(), &services.GetSettlementCirclesRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error getting settlement circles for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getDebtCycles(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetDebtCycles(r.Context(), &services.GetDebtCyclesRequest{
+		UrlSlug:  urlSlug,
+		Collapse: r.URL.Query().Get("collapse") == "true",
+	})
+	if err != nil {
+		logger.Errorf("Error finding debt cycles for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getComputedBalances(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetComputedBalances(r.Context(), &services.GetComputedBalancesRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error computing balances for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getSettlementSteps(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetSettlementSteps(r.Context(), &services.GetSettlementStepsRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error getting settlement steps for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getDebtsAsOf(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	asOfParam := r.URL.Query().Get("asof")
+	if asOfParam == "" {
+		http.Error(w, "asof is required (RFC 3339 timestamp, e.g. 2024-01-31T18:00:00Z)", http.StatusBadRequest)
+		return
+	}
+	asOf, err := time.Parse(time.RFC3339, asOfParam)
+	if err != nil {
+		http.Error(w, "Invalid asof: expected an RFC 3339 timestamp, e.g. 2024-01-31T18:00:00Z", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := debtService.GetDebtsAsOf(r.Context(), &services.GetDebtsAsOfRequest{UrlSlug: urlSlug, AsOf: asOf})
+	if err != nil {
+		logger.Errorf("Error getting debts as of %s for group %s: %v", asOfParam, urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getDebtCount(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetDebtCount(r.Context(), &services.GetDebtCountRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error counting debts for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getLargestDebt(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	resp, err := debtService.GetLargestDebt(r.Context(), &services.GetLargestDebtRequest{UrlSlug: urlSlug})
+	if err != nil {
+		logger.Errorf("Error getting largest debt for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func payMultipleDebts(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	var req struct {
+		Payments []*services.DebtPaymentItem `json:"payments"`
+		Note     string                      `json:"note"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in pay multiple debts request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	serviceReq := &services.PayMultipleDebtsRequest{
+		UrlSlug:  urlSlug,
+		Payments: req.Payments,
+		Note:     req.Note,
+	}
+
+	resp, err := debtService.PayMultipleDebts(r.Context(), serviceReq)
+	if err != nil {
+		logger.Errorf("Error paying multiple debts in group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "reopen it") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid debt ID") || strings.Contains(err.Error(), "duplicate debt ID") ||
+			strings.Contains(err.Error(), "cannot exceed") || strings.Contains(err.Error(), "must be positive") ||
+			strings.Contains(err.Error(), "cannot be empty") || strings.Contains(err.Error(), "invalid note") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func projectDebts(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
+	}
+	urlSlug := pathParts[3]
+
+	var req struct {
+		Payments []*services.DebtPaymentItem `json:"payments"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warnf("Invalid JSON in project debts request: %v", err)
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := debtService.ProjectDebts(r.Context(), &services.ProjectDebtsRequest{
+		UrlSlug:  urlSlug,
+		Payments: req.Payments,
+	})
 	if err != nil {
-		log.Printf("Error updating participant %d: %v", participantID, err)
-
-		// Check if it's a business logic error (participant not found, etc.)
+		logger.Errorf("Error projecting debts in group %s: %v", urlSlug, err)
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
-
-		// For other errors, return internal server error
+		if strings.Contains(err.Error(), "invalid debt ID") || strings.Contains(err.Error(), "duplicate debt ID") ||
+			strings.Contains(err.Error(), "cannot exceed") || strings.Contains(err.Error(), "must be positive") ||
+			strings.Contains(err.Error(), "cannot be empty") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -456,144 +2633,136 @@ func updateParticipant(w http.ResponseWriter, r *http.Request, participantServic
 	json.NewEncoder(w).Encode(resp)
 }
 
-func deleteParticipant(w http.ResponseWriter, r *http.Request, participantService services.ParticipantService) {
-	participantIDStr := strings.TrimPrefix(r.URL.Path, "/api/participants/")
-	participantID, err := strconv.Atoi(participantIDStr)
-	if err != nil {
-		http.Error(w, "Invalid participant ID", http.StatusBadRequest)
+func rotateEditToken(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	serviceReq := &services.DeleteParticipantRequest{
-		ParticipantId: int32(participantID),
+	req := &services.RotateEditTokenRequest{
+		UrlSlug:   urlSlug,
+		EditToken: r.Header.Get("X-Edit-Token"),
 	}
 
-	err = participantService.DeleteParticipant(context.TODO(), serviceReq)
+	resp, err := groupService.RotateEditToken(r.Context(), req)
 	if err != nil {
-		log.Printf("Error deleting participant: %v", err)
-
-		// Check if it's a business logic error (participant has active expenses/splits/debts)
-		if strings.Contains(err.Error(), "cannot delete participant") {
+		logger.Warnf("Error rotating edit token for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid edit token") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "no edit token") {
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
-
-		// For other errors, return internal server error
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	response := map[string]string{"message": "Participant deleted successfully"}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(resp)
 }
 
-// Expense handlers
-func getExpensesByGroup(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
-	// Extract group ID from URL path
+func getGroupMeta(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
 	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 4 {
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	groupID, err := strconv.Atoi(pathParts[3])
+	resp, err := groupService.GetGroupMeta(r.Context(), &services.GetGroupMetaRequest{UrlSlug: urlSlug})
 	if err != nil {
-		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		logger.Errorf("Error getting metadata for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	serviceReq := &services.GetExpensesByGroupRequest{
-		GroupId: int32(groupID),
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func getGroupStatistics(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
+		return
 	}
+	urlSlug := pathParts[3]
 
-	resp, err := expenseService.GetExpensesByGroup(context.TODO(), serviceReq)
+	resp, err := groupService.GetGroupStatistics(r.Context(), &services.GetGroupStatisticsRequest{UrlSlug: urlSlug})
 	if err != nil {
-		log.Printf("Error getting expenses: %v", err)
+		logger.Errorf("Error getting statistics for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp.Expenses)
+	json.NewEncoder(w).Encode(resp)
 }
 
-func getSplitsByGroup(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
-	// Extract urlSlug from URL path
+func getExpensesByPayer(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
 	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 4 {
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
-
 	urlSlug := pathParts[3]
-	if urlSlug == "" {
-		http.Error(w, "Invalid URL slug", http.StatusBadRequest)
-		return
-	}
-
-	serviceReq := &services.GetSplitsByGroupRequest{
-		UrlSlug: urlSlug,
-	}
 
-	resp, err := expenseService.GetSplitsByGroup(context.TODO(), serviceReq)
+	resp, err := groupService.GetExpensesByPayer(r.Context(), &services.GetExpensesByPayerRequest{UrlSlug: urlSlug})
 	if err != nil {
-		log.Printf("Error getting splits: %v", err)
+		logger.Errorf("Error getting expenses by payer for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp.Splits)
+	json.NewEncoder(w).Encode(resp)
 }
 
-func createExpense(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
-	var requestData struct {
-		Expense struct {
-			Name      string  `json:"name"`
-			Cost      float64 `json:"cost"`
-			Emoji     string  `json:"emoji"`
-			PayerID   int32   `json:"payer_id"`
-			SplitType string  `json:"split_type"`
-			GroupID   int32   `json:"group_id"`
-		} `json:"expense"`
-		Splits []struct {
-			ParticipantID int32   `json:"participant_id"`
-			SplitAmount   float64 `json:"split_amount"`
-		} `json:"splits"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+func getSpendingTimeSeries(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	// Convert splits
-	splits := make([]*services.Split, len(requestData.Splits))
-	for i, split := range requestData.Splits {
-		splits[i] = &services.Split{
-			GroupId:       requestData.Expense.GroupID,
-			ParticipantId: split.ParticipantID,
-			SplitAmount:   split.SplitAmount,
-		}
-	}
-
-	serviceReq := &services.CreateExpenseRequest{
-		Expense: &services.Expense{
-			Name:      requestData.Expense.Name,
-			Cost:      requestData.Expense.Cost,
-			Emoji:     requestData.Expense.Emoji,
-			PayerId:   requestData.Expense.PayerID,
-			SplitType: requestData.Expense.SplitType,
-			GroupId:   requestData.Expense.GroupID,
-		},
-		Splits: splits,
+	req := &services.GetSpendingTimeSeriesRequest{
+		UrlSlug: urlSlug,
+		Bucket:  r.URL.Query().Get("bucket"),
 	}
 
-	resp, err := expenseService.CreateExpense(context.Background(), serviceReq)
+	resp, err := groupService.GetSpendingTimeSeries(r.Context(), req)
 	if err != nil {
-		log.Printf("Error creating expense: %v", err)
-		http.Error(w, "Failed to create expense", http.StatusInternalServerError)
+		logger.Errorf("Error getting spending time series for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid bucket") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
@@ -601,75 +2770,64 @@ func createExpense(w http.ResponseWriter, r *http.Request, expenseService servic
 	json.NewEncoder(w).Encode(resp)
 }
 
-func getExpenseWithSplits(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
-	expenseIDStr := strings.TrimPrefix(r.URL.Path, "/api/expense/")
-	expenseID, err := strconv.Atoi(expenseIDStr)
-	if err != nil {
-		http.Error(w, "Invalid expense ID", http.StatusBadRequest)
+func getGroupDiagnostics(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	serviceReq := &services.GetExpenseWithSplitsRequest{ExpenseId: int32(expenseID)}
-	resp, err := expenseService.GetExpenseWithSplits(context.Background(), serviceReq)
+	resp, err := groupService.GetGroupDiagnostics(r.Context(), &services.GetGroupDiagnosticsRequest{UrlSlug: urlSlug})
 	if err != nil {
-		log.Printf("Error getting expense with splits: %v", err)
-		http.Error(w, "Expense not found", http.StatusNotFound)
+		logger.Errorf("Error running diagnostics for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	if !resp.Healthy {
+		logger.Warnf("Diagnostics found %d anomalies in group %s", len(resp.Anomalies), urlSlug)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-func updateExpense(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
-	var requestData struct {
-		Expense struct {
-			ID        int32   `json:"id"`
-			Name      string  `json:"name"`
-			Cost      float64 `json:"cost"`
-			Emoji     string  `json:"emoji"`
-			PayerID   int32   `json:"payer_id"`
-			SplitType string  `json:"split_type"`
-			GroupID   int32   `json:"group_id"`
-		} `json:"expense"`
-		Splits []struct {
-			ParticipantID int32   `json:"participant_id"`
-			SplitAmount   float64 `json:"split_amount"`
-		} `json:"splits"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+// Activity handlers
+func getGroupActivity(w http.ResponseWriter, r *http.Request, activityService services.ActivityService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	// Convert splits
-	splits := make([]*services.Split, len(requestData.Splits))
-	for i, split := range requestData.Splits {
-		splits[i] = &services.Split{
-			GroupId:       requestData.Expense.GroupID,
-			ParticipantId: split.ParticipantID,
-			SplitAmount:   split.SplitAmount,
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
 		}
 	}
 
-	serviceReq := &services.UpdateExpenseRequest{
-		Expense: &services.Expense{
-			Id:        requestData.Expense.ID,
-			Name:      requestData.Expense.Name,
-			Cost:      requestData.Expense.Cost,
-			Emoji:     requestData.Expense.Emoji,
-			PayerId:   requestData.Expense.PayerID,
-			SplitType: requestData.Expense.SplitType,
-			GroupId:   requestData.Expense.GroupID,
-		},
-		Splits: splits,
+	req := &services.GetGroupActivityRequest{
+		UrlSlug: urlSlug,
+		Limit:   int32(limit),
 	}
 
-	resp, err := expenseService.UpdateExpense(r.Context(), serviceReq)
+	resp, err := activityService.GetGroupActivity(r.Context(), req)
 	if err != nil {
-		log.Printf("Error updating expense: %v", err)
-		http.Error(w, "Failed to update expense", http.StatusInternalServerError)
+		logger.Errorf("Error getting activity for group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
@@ -677,78 +2835,58 @@ func updateExpense(w http.ResponseWriter, r *http.Request, expenseService servic
 	json.NewEncoder(w).Encode(resp)
 }
 
-func deleteExpense(w http.ResponseWriter, r *http.Request, expenseService services.ExpenseService) {
-	expenseIDStr := strings.TrimPrefix(r.URL.Path, "/api/expense/")
-	expenseID, err := strconv.Atoi(expenseIDStr)
-	if err != nil {
-		http.Error(w, "Invalid expense ID", http.StatusBadRequest)
-		return
-	}
-
-	serviceReq := &services.DeleteExpenseRequest{
-		ExpenseId: int32(expenseID),
-	}
-
-	err = expenseService.DeleteExpense(r.Context(), serviceReq)
-	if err != nil {
-		log.Printf("Error deleting expense: %v", err)
-		http.Error(w, "Failed to delete expense", http.StatusInternalServerError)
-		return
-	}
-
-	response := map[string]string{"message": "Expense deleted successfully"}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// Debt handlers
-func getPayments(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
-	// Extract group ID from URL path
+func undoLastAction(w http.ResponseWriter, r *http.Request, activityService services.ActivityService) {
 	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 4 {
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
-		return
-	}
-
-	groupID, err := strconv.Atoi(pathParts[3])
-	if err != nil {
-		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	// Get payments using service
-	req := &services.GetPaymentsRequest{GroupId: int32(groupID)}
-	response, err := debtService.GetPayments(r.Context(), req)
+	resp, err := activityService.Undo(r.Context(), &services.UndoRequest{UrlSlug: urlSlug})
 	if err != nil {
-		http.Error(w, "Failed to get payments", http.StatusInternalServerError)
+		logger.Errorf("Error undoing last action in group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "nothing to undo") || strings.Contains(err.Error(), "cannot undo") ||
+			strings.Contains(err.Error(), "reopen it") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response.Payments)
+	json.NewEncoder(w).Encode(resp)
 }
 
-func getDebtsPageData(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
-	// Extract group URL slug from URL path
+func resetGroup(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
 	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 4 {
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
-		return
-	}
-
-	urlSlug := pathParts[3]
-	if urlSlug == "" {
+	if len(pathParts) < 4 || pathParts[3] == "" {
 		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	serviceReq := &services.GetDebtsRequest{
-		UrlSlug: urlSlug,
+	req := &services.ResetGroupRequest{
+		UrlSlug:   urlSlug,
+		EditToken: r.Header.Get("X-Edit-Token"),
 	}
 
-	resp, err := debtService.GetDebtsPageData(context.TODO(), serviceReq)
+	resp, err := groupService.ResetGroup(r.Context(), req)
 	if err != nil {
-		log.Printf("Error getting debts page data: %v", err)
+		logger.Errorf("Error resetting group %s: %v", urlSlug, err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid edit token") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -757,51 +2895,41 @@ func getDebtsPageData(w http.ResponseWriter, r *http.Request, debtService servic
 	json.NewEncoder(w).Encode(resp)
 }
 
-func createPayment(w http.ResponseWriter, r *http.Request, debtService services.DebtService) {
-	var req struct {
-		DebtID     int32   `json:"debt_id"`
-		PaidAmount float64 `json:"paid_amount"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Invalid JSON in debt update request: %v", err)
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
-		return
-	}
-
-	// Validate input
-	if req.DebtID <= 0 {
-		http.Error(w, "Invalid debt ID", http.StatusBadRequest)
+func mergeGroups(w http.ResponseWriter, r *http.Request, groupService services.GroupService) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		http.Error(w, "Invalid group URL slug", http.StatusBadRequest)
 		return
 	}
+	urlSlug := pathParts[3]
 
-	if req.PaidAmount < 0 {
-		http.Error(w, "Paid amount cannot be negative", http.StatusBadRequest)
+	var req services.MergeGroupsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	req.TargetSlug = urlSlug
+	req.EditToken = r.Header.Get("X-Edit-Token")
 
-	serviceReq := &services.CreatePaymentRequest{
-		DebtId:     req.DebtID,
-		PaidAmount: req.PaidAmount,
-	}
-
-	resp, err := debtService.CreatePayment(context.TODO(), serviceReq)
+	resp, err := groupService.MergeGroups(r.Context(), &req)
 	if err != nil {
-		log.Printf("Error creating payment for debt %d: %v", req.DebtID, err)
-
-		// Check if it's a business logic error
+		logger.Errorf("Error merging group %s into %s: %v", req.SourceSlug, urlSlug, err)
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
-
-		// Check if it's a validation error (overpayment, etc.)
-		if strings.Contains(err.Error(), "cannot exceed") || strings.Contains(err.Error(), "cannot be negative") || strings.Contains(err.Error(), "invalid debt ID") {
+		if strings.Contains(err.Error(), "invalid merge") {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-
-		// For other errors, return internal server error
+		if strings.Contains(err.Error(), "invalid edit token") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "reopen it") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -823,10 +2951,31 @@ func deletePayment(w http.ResponseWriter, r *http.Request, debtService services.
 	}
 
 	if _, err := debtService.DeletePayment(context.TODO(), req); err != nil {
-		log.Printf("Error deleting payment %d: %v", paymentID, err)
+		logger.Errorf("Error deleting payment %d: %v", paymentID, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// parseGroupParticipantPath extracts the group URL slug and participant ID from
+// paths shaped like /api/group/{url_slug}/participants/{participant_id}/...
+func parseGroupParticipantPath(path string) (string, int32, error) {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathParts) < 5 || pathParts[3] != "participants" {
+		return "", 0, fmt.Errorf("Invalid URL format")
+	}
+
+	urlSlug := pathParts[2]
+	if urlSlug == "" {
+		return "", 0, fmt.Errorf("Invalid group URL slug")
+	}
+
+	participantID, err := strconv.Atoi(pathParts[4])
+	if err != nil || participantID <= 0 {
+		return "", 0, fmt.Errorf("Invalid participant ID: %s", pathParts[4])
+	}
+
+	return urlSlug, int32(participantID), nil
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"freesplit/internal/database"
+	"freesplit/internal/ratelimit"
+	"freesplit/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupHandlerTestDB creates an in-memory SQLite database for handler tests
+func setupHandlerTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	database.Migrate(db)
+	return db
+}
+
+func TestGetGroup_ReturnsNotModifiedForMatchingETag(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	groupService := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip"}
+	db.Create(&group)
+	db.Create(&database.Participant{Name: "Alice", GroupID: group.ID})
+
+	first := httptest.NewRecorder()
+	getGroup(first, httptest.NewRequest("GET", "/api/group/trip", nil), groupService)
+	etag := first.Header().Get("ETag")
+
+	// Act
+	req := httptest.NewRequest("GET", "/api/group/trip", nil)
+	req.Header.Set("If-None-Match", etag)
+	second := httptest.NewRecorder()
+	getGroup(second, req, groupService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, http.StatusNotModified, second.Code)
+	assert.Empty(t, second.Body.String())
+}
+
+func TestGetGroup_ReturnsFreshBodyAfterChange(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	groupService := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip"}
+	db.Create(&group)
+
+	first := httptest.NewRecorder()
+	getGroup(first, httptest.NewRequest("GET", "/api/group/trip", nil), groupService)
+	etag := first.Header().Get("ETag")
+
+	db.Create(&database.Participant{Name: "Bob", GroupID: group.ID})
+
+	// Act
+	req := httptest.NewRequest("GET", "/api/group/trip", nil)
+	req.Header.Set("If-None-Match", etag)
+	second := httptest.NewRecorder()
+	getGroup(second, req, groupService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.NotEqual(t, etag, second.Header().Get("ETag"))
+	assert.Contains(t, second.Body.String(), "Bob")
+}
+
+func TestHeadGroup_ReturnsStatusByExistence(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	groupService := services.NewGroupService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip"})
+
+	// Act
+	existing := httptest.NewRecorder()
+	headGroup(existing, httptest.NewRequest("HEAD", "/api/group/trip", nil), groupService)
+	missing := httptest.NewRecorder()
+	headGroup(missing, httptest.NewRequest("HEAD", "/api/group/gone", nil), groupService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, existing.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+	assert.Empty(t, existing.Body.String())
+}
+
+func TestGetGroupMeta_ReturnsGroupWithoutParticipants(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	groupService := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "EUR"}
+	db.Create(&group)
+	db.Create(&database.Participant{Name: "Alice", GroupID: group.ID})
+
+	// Act
+	rec := httptest.NewRecorder()
+	getGroupMeta(rec, httptest.NewRequest("GET", "/api/group/trip/meta", nil), groupService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body map[string]map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.NotContains(t, body, "participants")
+	assert.Equal(t, "Trip", body["group"]["name"])
+	assert.Equal(t, "EUR", body["group"]["currency"])
+}
+
+func TestGetGroupMeta_ReturnsNotFoundForUnknownSlug(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	groupService := services.NewGroupService(db)
+
+	// Act
+	rec := httptest.NewRecorder()
+	getGroupMeta(rec, httptest.NewRequest("GET", "/api/group/gone/meta", nil), groupService)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCreateExpense_RejectsPayloadMissingPayerID(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	expenseService := services.NewExpenseService(db)
+	body := `{"expense": {"name": "Dinner", "cost": 20, "split_type": "equal", "group_id": 1}, "splits": [{"participant_id": 1}]}`
+
+	// Act
+	rec := httptest.NewRecorder()
+	createExpense(rec, httptest.NewRequest("POST", "/api/group/1/expenses", strings.NewReader(body)), expenseService)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "expense.payer_id is required")
+	assert.NotContains(t, rec.Body.String(), "expense.name")
+}
+
+func TestCreateExpense_ReportsEveryInvalidField(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	expenseService := services.NewExpenseService(db)
+	body := `{"expense": {"split_type": "equal"}, "splits": []}`
+
+	// Act
+	rec := httptest.NewRecorder()
+	createExpense(rec, httptest.NewRequest("POST", "/api/group/1/expenses", strings.NewReader(body)), expenseService)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	for _, field := range []string{"expense.name", "expense.cost", "expense.payer_id", "expense.group_id", "splits must contain"} {
+		assert.Contains(t, rec.Body.String(), field)
+	}
+}
+
+func TestCreateGroup_RejectsRequestsOverPerIPQuota(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	groupService := services.NewGroupService(db)
+	handler := limitGroupCreation(ratelimit.New(2, 0, time.Hour), false, func(w http.ResponseWriter, r *http.Request) {
+		createGroup(w, r, groupService)
+	})
+	post := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/group", strings.NewReader(`{"name":"Trip","currency":"USD","participant_names":["Alice"]}`))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	// Act
+	first := post("198.51.100.1:1000")
+	second := post("198.51.100.1:1001")
+	third := post("198.51.100.1:1002")
+	otherIP := post("198.51.100.2:1000")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	assert.Equal(t, "3600", third.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, otherIP.Code)
+
+	var groups int64
+	db.Model(&database.Group{}).Count(&groups)
+	assert.Equal(t, int64(3), groups)
+}
+
+func TestPayMultipleDebts_RecordsNoteFromBody(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	debtService := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	debt := database.Debt{GroupID: group.ID, LenderID: alice.ID, DebtorID: bob.ID, DebtAmount: 20}
+	db.Create(&debt)
+	body := fmt.Sprintf(`{"payments": [{"debt_id": %d, "amount": 20}], "note": "settled after Italy trip"}`, debt.ID)
+
+	// Act
+	rec := httptest.NewRecorder()
+	payMultipleDebts(rec, httptest.NewRequest("POST", "/api/group/trip/debts/pay-multiple", strings.NewReader(body)), debtService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var payment database.Payment
+	db.Where("group_id = ?", group.ID).First(&payment)
+	assert.Equal(t, "settled after Italy trip", payment.Note)
+}
+
+func TestGetGroupSummaryMarkdown_ServesMarkdownAndNotFound(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	groupService := services.NewGroupService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "EUR"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	paidAt := time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC)
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 12.5, Note: "Dinner", CreatedAt: paidAt})
+
+	// Act
+	rec := httptest.NewRecorder()
+	getGroupSummaryMarkdown(rec, httptest.NewRequest("GET", "/api/group/trip/summary.md", nil), groupService)
+	missing := httptest.NewRecorder()
+	getGroupSummaryMarkdown(missing, httptest.NewRequest("GET", "/api/group/missing/summary.md", nil), groupService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/markdown; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Contains(t, body, "# Trip\n")
+	assert.Contains(t, body, "- 2024-03-05: Bob paid Alice €12.50 (Dinner)\n")
+	assert.Contains(t, body, "## Settlement\n\nEveryone is settled up.\n")
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestExportPaymentsCSV_WritesHeaderAndNamedRows(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	debtService := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "EUR"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob, Jr.", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	paidAt := time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC)
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 12.5, Note: "Dinner", CreatedAt: paidAt})
+
+	// Act
+	rec := httptest.NewRecorder()
+	exportPaymentsCSV(rec, httptest.NewRequest("GET", "/api/group/trip/payments.csv", nil), debtService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="trip-payments.csv"`, rec.Header().Get("Content-Disposition"))
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	assert.Equal(t, []string{
+		"date,payer,payee,amount,currency,note",
+		`2024-03-05T18:30:00Z,"Bob, Jr.",Alice,12.50,EUR,Dinner`,
+	}, lines)
+}
+
+func TestExportPaymentsCSV_WholeDisplayRoundsAmountsButKeepsStoredPrecision(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	debtService := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD", AmountDisplay: services.AmountDisplayWhole}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	bob := database.Participant{Name: "Bob", GroupID: group.ID}
+	db.Create(&alice)
+	db.Create(&bob)
+	paidAt := time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC)
+	payment := database.Payment{GroupID: group.ID, PayerID: bob.ID, PayeeID: alice.ID, Amount: 10.50, CreatedAt: paidAt}
+	db.Create(&payment)
+
+	// Act
+	rec := httptest.NewRecorder()
+	exportPaymentsCSV(rec, httptest.NewRequest("GET", "/api/group/trip/payments.csv", nil), debtService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	assert.Equal(t, "2024-03-05T18:30:00Z,Bob,Alice,11,USD,", lines[1])
+	var stored database.Payment
+	db.First(&stored, payment.ID)
+	assert.Equal(t, 10.50, stored.Amount)
+}
+
+func TestExportPaymentsCSV_NeutralizesFormulaCells(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	debtService := services.NewDebtService(db)
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	payer := database.Participant{Name: "=HYPERLINK(\"http://evil\")", GroupID: group.ID}
+	payee := database.Participant{Name: "@Alice", GroupID: group.ID}
+	db.Create(&payer)
+	db.Create(&payee)
+	paidAt := time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC)
+	db.Create(&database.Payment{GroupID: group.ID, PayerID: payer.ID, PayeeID: payee.ID, Amount: 5, Note: "-2+3", CreatedAt: paidAt})
+
+	// Act
+	rec := httptest.NewRecorder()
+	exportPaymentsCSV(rec, httptest.NewRequest("GET", "/api/group/trip/payments.csv", nil), debtService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-03-05T18:30:00Z", `'=HYPERLINK("http://evil")`, "'@Alice", "5.00", "USD", "'-2+3"}, records[1])
+}
+
+func TestExportPaymentsCSV_ReturnsNotFoundForUnknownSlug(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	debtService := services.NewDebtService(db)
+
+	// Act
+	rec := httptest.NewRecorder()
+	exportPaymentsCSV(rec, httptest.NewRequest("GET", "/api/group/missing/payments.csv", nil), debtService)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetLargestDebt_ReturnsNoContentWithoutDebts(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	debtService := services.NewDebtService(db)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"})
+
+	// Act
+	rec := httptest.NewRecorder()
+	getLargestDebt(rec, httptest.NewRequest("GET", "/api/group/trip/debts/largest", nil), debtService)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestVerifyParticipant_ReturnsNotFoundForParticipantOfAnotherGroup(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	participantService := services.NewParticipantService(db)
+	trip := database.Group{Name: "Trip", URLSlug: "trip"}
+	flat := database.Group{Name: "Flat", URLSlug: "flat"}
+	db.Create(&trip)
+	db.Create(&flat)
+	alice := database.Participant{Name: "Alice", GroupID: trip.ID}
+	db.Create(&alice)
+
+	// Act
+	found := httptest.NewRecorder()
+	verifyParticipant(found, httptest.NewRequest("GET", fmt.Sprintf("/api/group/trip/participants/%d/verify", alice.ID), nil), participantService)
+	missing := httptest.NewRecorder()
+	verifyParticipant(missing, httptest.NewRequest("GET", fmt.Sprintf("/api/group/flat/participants/%d/verify", alice.ID), nil), participantService)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, found.Code)
+	assert.Contains(t, found.Body.String(), `"exists":true`)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestUserGroupsHandler_RoutesFindParticipant(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	handler := userGroupsHandler(services.NewGroupService(db), services.NewDebtService(db), services.NewActivityService(db), services.NewPreferenceService(db))
+	group := database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"}
+	db.Create(&group)
+	alice := database.Participant{Name: "Alice", GroupID: group.ID}
+	db.Create(&alice)
+	body := `{"name": "alice", "group_slugs": ["trip"]}`
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("POST", "/api/user-groups/find-participant", strings.NewReader(body)))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp services.FindParticipantResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, []*services.ParticipantMatch{
+		{GroupUrlSlug: "trip", GroupName: "Trip", ParticipantId: int32(alice.ID), ParticipantName: "Alice"},
+	}, resp.Matches)
+}
+
+func TestParseRangeBound_DateEndCoversWholeDay(t *testing.T) {
+	from, fromErr := parseRangeBound("2024-06-01", false)
+	to, toErr := parseRangeBound("2024-06-30", true)
+	exact, exactErr := parseRangeBound("2024-06-30T12:00:00Z", true)
+	none, noneErr := parseRangeBound("", true)
+	_, badErr := parseRangeBound("June", false)
+
+	assert.NoError(t, fromErr)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), *from)
+	assert.NoError(t, toErr)
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), *to)
+	assert.NoError(t, exactErr)
+	assert.Equal(t, time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC), exact.UTC())
+	assert.NoError(t, noneErr)
+	assert.Nil(t, none)
+	assert.Error(t, badErr)
+}
+
+func TestRequireAdminToken_RejectsMissingOrWrongToken(t *testing.T) {
+	// Arrange
+	db := setupHandlerTestDB(t)
+	db.Create(&database.Group{Name: "Trip", URLSlug: "trip", Currency: "USD"})
+	handler := requireAdminToken("s3cret-admin-token", func(w http.ResponseWriter, r *http.Request) {
+		listCurrencies(w, r, services.NewAdminService(db))
+	})
+	disabled := requireAdminToken("", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("disabled admin API must not reach the handler")
+	})
+	request := func(token string) *http.Request {
+		req := httptest.NewRequest("GET", "/api/admin/currencies", nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		return req
+	}
+
+	// Act
+	missing := httptest.NewRecorder()
+	handler(missing, request(""))
+	wrong := httptest.NewRecorder()
+	handler(wrong, request("guess"))
+	ok := httptest.NewRecorder()
+	handler(ok, request("s3cret-admin-token"))
+	off := httptest.NewRecorder()
+	disabled(off, request("s3cret-admin-token"))
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, missing.Code)
+	assert.Equal(t, http.StatusUnauthorized, wrong.Code)
+	assert.Equal(t, http.StatusOK, ok.Code)
+	assert.JSONEq(t, `{"currencies":[{"currency":"USD","group_count":1}]}`, ok.Body.String())
+	assert.Equal(t, http.StatusForbidden, off.Code)
+}
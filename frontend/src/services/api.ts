@@ -46,6 +46,11 @@ export interface Group {
   settle_up_date: number;
   state: string;
   currency: string;
+  description?: string;
+  amount_display?: 'cents' | 'whole';
+  banker_id?: number;
+  simplify_debts?: boolean;
+  rotate_remainder?: boolean;
   participant_ids: number[];
   expense_ids: number[];
   participants?: Participant[];
@@ -64,6 +69,8 @@ export interface Expense {
   emoji: string;
   payer_id: number;
   split_type: string;
+  is_shared?: boolean;
+  locked?: boolean;
   split_ids: number[];
   group_id: number;
 }
@@ -74,6 +81,7 @@ export interface Split {
   expense_id: number;
   participant_id: number;
   split_amount: number;
+  participant_name?: string;
 }
 
 export interface SplitWithNames {
@@ -93,6 +101,7 @@ export interface Payment {
   payer_id: number;
   payee_id: number;
   amount: number;
+  note?: string;
   created_at: string;
   updated_at: string;
 }
@@ -133,10 +142,19 @@ export interface UserGroupSummary {
   net_balance: number;
   participant_id?: number;
   participant_name?: string;
+  error?: string; // Set when the participant ID isn't in the group; net_balance is 0 then
+}
+
+export interface CurrencyTotal {
+  currency: string;
+  owed_to_user: number;
+  user_owes: number;
+  net_balance: number;
 }
 
 export interface UserGroupsSummaryResponse {
   groups: UserGroupSummary[];
+  totals: CurrencyTotal[];
 }
 
 export interface GroupParticipantsResponse {
@@ -254,10 +272,12 @@ export const deletePayment = async (paymentId: number): Promise<void> => {
   await axios.delete(`${API_BASE_URL}/api/payments/${paymentId}`);
 };
 
-export const getExpenseWithSplits = async (expenseId: number): Promise<{expense: Expense, splits: Split[]}> => {
+export const getExpenseWithSplits = async (expenseId: number): Promise<{expense: Expense, payer_name?: string, splits: Split[]}> => {
   const response = await axios.get(`${API_BASE_URL}/api/expense/${expenseId}`);
+  // Each split carries its participant_name, resolved by the server like payer_name
   return {
     expense: response.data.expense,
+    payer_name: response.data.payer_name,
     splits: response.data.splits
   };
 };
@@ -265,28 +285,30 @@ export const getExpenseWithSplits = async (expenseId: number): Promise<{expense:
 export const createExpense = async (data: {
   expense: Expense;
   splits: Split[];
-}): Promise<{expense: Expense, splits: Split[]}> => {
+}): Promise<{expense: Expense, splits: Split[], warnings?: string[]}> => {
   const response = await axios.post(`${API_BASE_URL}/api/group/${data.expense.group_id}/expenses`, {
     expense: data.expense,
     splits: data.splits
   });
   return {
     expense: response.data.expense,
-    splits: response.data.splits
+    splits: response.data.splits,
+    warnings: response.data.warnings
   };
 };
 
 export const updateExpense = async (data: {
   expense: Expense;
   splits: Split[];
-}): Promise<{expense: Expense, splits: Split[]}> => {
+}): Promise<{expense: Expense, splits: Split[], warnings?: string[]}> => {
   const response = await axios.put(`${API_BASE_URL}/api/expense/`, {
     expense: data.expense,
     splits: data.splits
   });
   return {
     expense: response.data.expense,
-    splits: response.data.splits
+    splits: response.data.splits,
+    warnings: response.data.warnings
   };
 };
 